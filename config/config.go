@@ -11,6 +11,7 @@ type Config struct {
 	SecretKey string         `mapstructure:"SECRET_KEY"`
 	DBConfig  DatabaseConfig `mapstructure:"database"`
 	Server    ServerConfig   `mapstructure:"server"`
+	Sekolah   SekolahConfig  `mapstructure:"sekolah"`
 }
 
 type DatabaseConfig struct {
@@ -22,6 +23,14 @@ type DatabaseConfig struct {
 	SSLMode  string `mapstructure:"ssl_mode"`
 }
 
+// SekolahConfig berisi identitas sekolah yang dicetak pada rapor.
+type SekolahConfig struct {
+	Nama             string `mapstructure:"nama"`
+	Kota             string `mapstructure:"kota"`
+	KepalaSekolah    string `mapstructure:"kepala_sekolah"`
+	NIPKepalaSekolah string `mapstructure:"nip_kepala_sekolah"`
+}
+
 type ServerConfig struct {
 	Port    string `mapstructure:"port"`
 	Mode    string `mapstructure:"mode"`
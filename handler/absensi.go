@@ -0,0 +1,519 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BukaSesiAbsensiRequest struct {
+	JadwalID int    `json:"jadwal_id" binding:"required"`
+	Tanggal  string `json:"tanggal" binding:"required"`
+}
+
+type AbsensiSiswaRequest struct {
+	SiswaID int     `json:"siswa_id" binding:"required"`
+	Status  string  `json:"status" binding:"required"`
+	Catatan *string `json:"catatan"`
+}
+
+type SimpanAbsensiRequest struct {
+	Absensi []AbsensiSiswaRequest `json:"absensi" binding:"required,min=1,dive"`
+}
+
+type BukaCheckInRequest struct {
+	DurasiMenit int `json:"durasi_menit"`
+}
+
+type CheckInRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type TokenCheckInResponse struct {
+	Token         string    `json:"token"`
+	BerlakuSampai time.Time `json:"berlaku_sampai"`
+	CheckInSampai time.Time `json:"check_in_sampai"`
+}
+
+type SesiAbsensiResponse struct {
+	ID              int        `json:"id"`
+	JadwalID        *int       `json:"jadwal_id"`
+	Tanggal         string     `json:"tanggal"`
+	Hari            string     `json:"hari"`
+	JamMulai        string     `json:"jam_mulai"`
+	JamSelesai      string     `json:"jam_selesai"`
+	KelasID         int        `json:"kelas_id"`
+	NamaKelas       string     `json:"nama_kelas"`
+	MataPelajaranID int        `json:"mata_pelajaran_id"`
+	NamaMapel       string     `json:"nama_mapel"`
+	GuruID          *int       `json:"guru_id"`
+	CheckInSampai   *time.Time `json:"check_in_sampai"`
+	Updated         time.Time  `json:"updated"`
+}
+
+type AbsensiSiswaResponse struct {
+	SiswaID int     `json:"siswa_id"`
+	Nama    string  `json:"nama"`
+	Status  *string `json:"status"`
+	Catatan *string `json:"catatan"`
+}
+
+type RekapAbsensiResponse struct {
+	SiswaID int    `json:"siswa_id"`
+	Nama    string `json:"nama,omitempty"`
+	Hadir   int    `json:"hadir"`
+	Izin    int    `json:"izin"`
+	Sakit   int    `json:"sakit"`
+	Alpa    int    `json:"alpa"`
+}
+
+type RiwayatAbsensiResponse struct {
+	SesiID          int     `json:"sesi_id"`
+	Tanggal         string  `json:"tanggal"`
+	KelasID         int     `json:"kelas_id"`
+	MataPelajaranID int     `json:"mata_pelajaran_id"`
+	NamaMapel       string  `json:"nama_mapel"`
+	JamMulai        string  `json:"jam_mulai"`
+	JamSelesai      string  `json:"jam_selesai"`
+	Status          string  `json:"status"`
+	Catatan         *string `json:"catatan"`
+}
+
+type absensiHandler struct {
+	absensiRepo    repositories.AbsensiRepository
+	absensiService *services.AbsensiService
+}
+
+func NewAbsensiHandler(
+	absensiRepo repositories.AbsensiRepository,
+	absensiService *services.AbsensiService,
+) *absensiHandler {
+	return &absensiHandler{
+		absensiRepo:    absensiRepo,
+		absensiService: absensiService,
+	}
+}
+
+// BukaSesi membuka sesi absensi untuk satu slot jadwal pada tanggal tertentu. Jika sesi sudah ada,
+// sesi yang sama dikembalikan beserta daftar hadirnya.
+func (h *absensiHandler) BukaSesi(c *gin.Context) {
+	guruID, ok := guruPengisiAbsensi(c)
+	if !ok {
+		return
+	}
+
+	var req BukaSesiAbsensiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "jadwal_id dan tanggal wajib diisi."})
+		return
+	}
+	tanggal, err := time.ParseInLocation("2006-01-02", req.Tanggal, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+		return
+	}
+
+	sesi, err := h.absensiService.BukaSesi(c.Request.Context(), req.JadwalID, tanggal, guruID)
+	if err != nil {
+		writeAbsensiError(c, err, "Jadwal tidak ditemukan.", "Gagal membuka sesi absensi.")
+		return
+	}
+	h.kirimSesi(c, sesi, "Sesi absensi berhasil dibuka.")
+}
+
+func (h *absensiHandler) GetSesi(c *gin.Context) {
+	guruID, ok := guruPengisiAbsensi(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID sesi absensi tidak valid."})
+		return
+	}
+
+	sesi, daftar, err := h.absensiService.GetSesi(c.Request.Context(), id, guruID)
+	if err != nil {
+		writeAbsensiError(c, err, "Sesi absensi tidak ditemukan.", "Gagal mengambil sesi absensi.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil sesi absensi.",
+		"data": gin.H{
+			"sesi":         toSesiAbsensiResponse(*sesi),
+			"daftar_hadir": toAbsensiSiswaResponses(daftar),
+		},
+	})
+}
+
+// SimpanAbsensi mengisi status kehadiran banyak siswa sekaligus. Siswa yang tidak dikirim tidak
+// berubah; siswa yang sudah tercatat ditimpa statusnya.
+func (h *absensiHandler) SimpanAbsensi(c *gin.Context) {
+	guruID, ok := guruPengisiAbsensi(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID sesi absensi tidak valid."})
+		return
+	}
+
+	var req SimpanAbsensiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "absensi wajib berisi siswa_id dan status untuk setiap siswa."})
+		return
+	}
+
+	absensi := make([]models.Absensi, 0, len(req.Absensi))
+	for _, item := range req.Absensi {
+		absensi = append(absensi, models.Absensi{
+			SesiID:  id,
+			SiswaID: item.SiswaID,
+			Status:  item.Status,
+			Catatan: item.Catatan,
+		})
+	}
+
+	if err := h.absensiService.SimpanAbsensi(c.Request.Context(), id, guruID, absensi); err != nil {
+		writeAbsensiError(c, err, "Sesi absensi tidak ditemukan.", "Gagal menyimpan absensi.")
+		return
+	}
+
+	sesi, err := h.absensiRepo.GetSesiByID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Absensi tersimpan, tetapi gagal mengambil sesi absensi."})
+		return
+	}
+	h.kirimSesi(c, sesi, "Absensi berhasil disimpan.")
+}
+
+// BukaCheckIn membuka check-in QR untuk sesi hari ini selama durasi_menit (default 10 menit) dan
+// mengembalikan token pertama yang ditampilkan sebagai QR.
+func (h *absensiHandler) BukaCheckIn(c *gin.Context) {
+	guruID, ok := guruPengisiAbsensi(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID sesi absensi tidak valid."})
+		return
+	}
+
+	req := BukaCheckInRequest{DurasiMenit: 10}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format request tidak valid."})
+			return
+		}
+	}
+
+	token, err := h.absensiService.BukaCheckIn(c.Request.Context(), id, guruID, req.DurasiMenit)
+	if err != nil {
+		writeAbsensiError(c, err, "Sesi absensi tidak ditemukan.", "Gagal membuka check-in.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Check-in QR berhasil dibuka.",
+		"data":    toTokenCheckInResponse(token),
+	})
+}
+
+func (h *absensiHandler) TutupCheckIn(c *gin.Context) {
+	guruID, ok := guruPengisiAbsensi(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID sesi absensi tidak valid."})
+		return
+	}
+
+	if err := h.absensiService.TutupCheckIn(c.Request.Context(), id, guruID); err != nil {
+		writeAbsensiError(c, err, "Sesi absensi tidak ditemukan.", "Gagal menutup check-in.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Check-in QR berhasil ditutup."})
+}
+
+// GetTokenCheckIn mengambil token QR yang berlaku saat ini. Layar guru memanggilnya lagi setelah
+// berlaku_sampai agar QR terus berganti.
+func (h *absensiHandler) GetTokenCheckIn(c *gin.Context) {
+	guruID, ok := guruPengisiAbsensi(c)
+	if !ok {
+		return
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID sesi absensi tidak valid."})
+		return
+	}
+
+	token, err := h.absensiService.GetTokenCheckIn(c.Request.Context(), id, guruID)
+	if err != nil {
+		writeAbsensiError(c, err, "Sesi absensi tidak ditemukan.", "Gagal mengambil token check-in.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil token check-in.",
+		"data":    toTokenCheckInResponse(token),
+	})
+}
+
+// CheckIn mencatat siswa yang login hadir dari token QR yang dipindai.
+func (h *absensiHandler) CheckIn(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	var req CheckInRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "token wajib diisi."})
+		return
+	}
+
+	sesi, err := h.absensiService.CheckIn(c.Request.Context(), claims.UserID, req.Token)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrTokenCheckInSalah):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "QR tidak valid."})
+		case errors.Is(err, services.ErrTokenCheckInBasi):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "QR sudah kedaluwarsa. Pindai QR terbaru di layar guru."})
+		case errors.Is(err, services.ErrCheckInDitutup):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Check-in untuk pertemuan ini belum dibuka atau sudah ditutup."})
+		case errors.Is(err, repositories.ErrSiswaBukanAnggotaKelas):
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda bukan anggota kelas pertemuan ini."})
+		case errors.Is(err, repositories.ErrSudahAbsen):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Kehadiran Anda pada pertemuan ini sudah tercatat."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal melakukan check-in."})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Check-in berhasil. Anda tercatat hadir.",
+		"data":    toSesiAbsensiResponse(*sesi),
+	})
+}
+
+// GetRekapKelas menghitung kehadiran setiap siswa kelas dalam rentang ?mulai= dan ?selesai=
+// (YYYY-MM-DD).
+func (h *absensiHandler) GetRekapKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+	mulai, selesai, ok := parseRentangAbsensi(c)
+	if !ok {
+		return
+	}
+
+	rekap, err := h.absensiRepo.GetRekapKelas(c.Request.Context(), kelasID, mulai, selesai)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rekap absensi kelas."})
+		return
+	}
+
+	response := []RekapAbsensiResponse{}
+	for _, item := range rekap {
+		response = append(response, RekapAbsensiResponse{
+			SiswaID: item.SiswaID,
+			Nama:    item.Nama,
+			Hadir:   item.Hadir,
+			Izin:    item.Izin,
+			Sakit:   item.Sakit,
+			Alpa:    item.Alpa,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil rekap absensi kelas.",
+		"data":    response,
+	})
+}
+
+// GetRekapSiswa menghitung kehadiran satu siswa beserta rincian per pertemuan dalam rentang
+// ?mulai= dan ?selesai=. Siswa hanya dapat melihat rekapnya sendiri.
+func (h *absensiHandler) GetRekapSiswa(c *gin.Context) {
+	siswaID, err := strconv.Atoi(c.Param("siswa_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID siswa tidak valid."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+	if claims.Role == "siswa" && claims.UserID != siswaID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda hanya dapat melihat absensi Anda sendiri."})
+		return
+	}
+
+	mulai, selesai, ok := parseRentangAbsensi(c)
+	if !ok {
+		return
+	}
+
+	rekap, err := h.absensiService.RekapSiswa(c.Request.Context(), siswaID, mulai, selesai)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rekap absensi siswa."})
+		return
+	}
+
+	riwayat := []RiwayatAbsensiResponse{}
+	for _, item := range rekap.Riwayat {
+		riwayat = append(riwayat, RiwayatAbsensiResponse{
+			SesiID:          item.SesiID,
+			Tanggal:         item.Tanggal.Format("2006-01-02"),
+			KelasID:         item.KelasID,
+			MataPelajaranID: item.MataPelajaranID,
+			NamaMapel:       item.NamaMapel,
+			JamMulai:        item.JamMulai.Format("15:04"),
+			JamSelesai:      item.JamSelesai.Format("15:04"),
+			Status:          item.Status,
+			Catatan:         item.Catatan,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil rekap absensi siswa.",
+		"data": gin.H{
+			"rekap": RekapAbsensiResponse{
+				SiswaID: rekap.SiswaID,
+				Hadir:   rekap.Hadir,
+				Izin:    rekap.Izin,
+				Sakit:   rekap.Sakit,
+				Alpa:    rekap.Alpa,
+			},
+			"riwayat": riwayat,
+		},
+	})
+}
+
+func (h *absensiHandler) kirimSesi(c *gin.Context, sesi *repositories.SesiAbsensiDetail, pesan string) {
+	daftar, err := h.absensiRepo.GetDaftarHadir(c.Request.Context(), sesi.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar hadir."})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": pesan,
+		"data": gin.H{
+			"sesi":         toSesiAbsensiResponse(*sesi),
+			"daftar_hadir": toAbsensiSiswaResponses(daftar),
+		},
+	})
+}
+
+// guruPengisiAbsensi mengembalikan ID guru yang login, atau nil untuk admin yang boleh mengisi
+// absensi pertemuan mana pun.
+func guruPengisiAbsensi(c *gin.Context) (*int, bool) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return nil, false
+	}
+	if claims.Role != "guru" {
+		return nil, true
+	}
+	guruID := claims.UserID
+	return &guruID, true
+}
+
+// parseRentangAbsensi membaca ?mulai= dan ?selesai=; default-nya 30 hari terakhir sampai hari ini.
+func parseRentangAbsensi(c *gin.Context) (time.Time, time.Time, bool) {
+	hariIni := time.Now()
+	mulai, selesai, ok := parseRentangTanggal(c,
+		c.DefaultQuery("mulai", hariIni.AddDate(0, 0, -30).Format("2006-01-02")),
+		c.DefaultQuery("selesai", hariIni.Format("2006-01-02")),
+	)
+	if !ok {
+		return mulai, selesai, false
+	}
+	if selesai.Before(mulai) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tanggal selesai tidak boleh sebelum tanggal mulai."})
+		return mulai, selesai, false
+	}
+	return mulai, selesai, true
+}
+
+func writeAbsensiError(c *gin.Context, err error, pesanTidakDitemukan string, pesanGagal string) {
+	switch {
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": pesanTidakDitemukan})
+	case errors.Is(err, services.ErrSesiAbsensi):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	case errors.Is(err, services.ErrBukanPengajar):
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda bukan pengajar atau guru pengganti pertemuan ini."})
+	case errors.Is(err, repositories.ErrSiswaBukanAnggotaKelas):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Terdapat siswa yang bukan anggota kelas pertemuan ini."})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": pesanGagal})
+	}
+}
+
+func toSesiAbsensiResponse(item repositories.SesiAbsensiDetail) SesiAbsensiResponse {
+	return SesiAbsensiResponse{
+		ID:              item.ID,
+		JadwalID:        item.JadwalID,
+		Tanggal:         item.Tanggal.Format("2006-01-02"),
+		Hari:            services.NamaHari(item.Tanggal),
+		JamMulai:        item.JamMulai.Format("15:04"),
+		JamSelesai:      item.JamSelesai.Format("15:04"),
+		KelasID:         item.KelasID,
+		NamaKelas:       item.NamaKelas,
+		MataPelajaranID: item.MataPelajaranID,
+		NamaMapel:       item.NamaMapel,
+		GuruID:          item.GuruID,
+		CheckInSampai:   item.CheckInSampai,
+		Updated:         item.Updated,
+	}
+}
+
+func toTokenCheckInResponse(token *services.TokenCheckIn) TokenCheckInResponse {
+	return TokenCheckInResponse{
+		Token:         token.Token,
+		BerlakuSampai: token.BerlakuSampai,
+		CheckInSampai: token.CheckInSampai,
+	}
+}
+
+func toAbsensiSiswaResponses(daftar []repositories.AbsensiSiswa) []AbsensiSiswaResponse {
+	response := []AbsensiSiswaResponse{}
+	for _, item := range daftar {
+		response = append(response, AbsensiSiswaResponse{
+			SiswaID: item.SiswaID,
+			Nama:    item.Nama,
+			Status:  item.Status,
+			Catatan: item.Catatan,
+		})
+	}
+	return response
+}
@@ -0,0 +1,345 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BandingCreateRequest struct {
+	Jenis   string `json:"jenis" binding:"required,oneof=tugas quiz"`
+	HasilID int    `json:"hasil_id" binding:"required"`
+	Alasan  string `json:"alasan" binding:"required,min=10"`
+}
+
+type TerimaBandingRequest struct {
+	NilaiBaru *float64 `json:"nilai_baru" binding:"required,gte=0,lte=100"`
+	Tanggapan *string  `json:"tanggapan"`
+}
+
+type TolakBandingRequest struct {
+	Tanggapan string `json:"tanggapan" binding:"required"`
+}
+
+type BandingResponse struct {
+	ID           int        `json:"id"`
+	SiswaID      int        `json:"siswa_id"`
+	NamaSiswa    string     `json:"nama_siswa"`
+	Jenis        string     `json:"jenis"`
+	HasilTugasID *int       `json:"hasil_tugas_id,omitempty"`
+	HasilQuizID  *int       `json:"hasil_quiz_id,omitempty"`
+	Judul        string     `json:"judul"`
+	NilaiLama    *float64   `json:"nilai_lama,omitempty"`
+	NilaiBaru    *float64   `json:"nilai_baru,omitempty"`
+	Alasan       string     `json:"alasan"`
+	Tanggapan    *string    `json:"tanggapan,omitempty"`
+	Status       string     `json:"status"`
+	Diproses     *time.Time `json:"diproses,omitempty"`
+	Created      time.Time  `json:"created"`
+}
+
+type bandingNilaiHandler struct {
+	bandingRepo    repositories.BandingNilaiRepository
+	hasilTugasRepo repositories.HasilTugasRepository
+	hasilQuizRepo  repositories.HasilQuizRepository
+	tugasRepo      repositories.TugasRepository
+	quizRepo       repositories.QuizRepository
+	nilaiService   *services.NilaiService
+}
+
+func NewBandingNilaiHandler(
+	bandingRepo repositories.BandingNilaiRepository,
+	hasilTugasRepo repositories.HasilTugasRepository,
+	hasilQuizRepo repositories.HasilQuizRepository,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+	nilaiService *services.NilaiService,
+) *bandingNilaiHandler {
+	return &bandingNilaiHandler{
+		bandingRepo:    bandingRepo,
+		hasilTugasRepo: hasilTugasRepo,
+		hasilQuizRepo:  hasilQuizRepo,
+		tugasRepo:      tugasRepo,
+		quizRepo:       quizRepo,
+		nilaiService:   nilaiService,
+	}
+}
+
+// AjukanBanding dipakai siswa untuk menyanggah nilai tugas atau quiz yang sudah dinilai.
+func (h *bandingNilaiHandler) AjukanBanding(c *gin.Context) {
+	var req BandingCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Jenis, hasil_id, dan alasan (minimal 10 karakter) wajib diisi."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	banding := models.BandingNilai{
+		SiswaID: claims.UserID,
+		Jenis:   req.Jenis,
+		Alasan:  req.Alasan,
+		Status:  "menunggu",
+	}
+
+	switch req.Jenis {
+	case "tugas":
+		hasil, err := h.hasilTugasRepo.GetByID(c.Request.Context(), req.HasilID)
+		if err != nil || hasil.SiswaID != claims.UserID {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Hasil tugas tidak ditemukan."})
+			return
+		}
+		tugas, err := h.tugasRepo.GetByID(c.Request.Context(), hasil.TugasID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+			return
+		}
+		if hasil.Nilai == nil || !tugas.NilaiTerbit() {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tugas ini belum dinilai."})
+			return
+		}
+		banding.HasilTugasID = &hasil.ID
+		banding.NilaiLama = hasil.Nilai
+	case "quiz":
+		hasil, err := h.hasilQuizRepo.GetByID(c.Request.Context(), req.HasilID)
+		if err != nil || hasil.SiswaID != claims.UserID {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Hasil quiz tidak ditemukan."})
+			return
+		}
+		quiz, err := h.quizRepo.GetByID(c.Request.Context(), hasil.QuizID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data quiz."})
+			return
+		}
+		if !quiz.NilaiTerbit() {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Nilai quiz ini belum diterbitkan."})
+			return
+		}
+		banding.HasilQuizID = &hasil.ID
+		banding.NilaiLama = &hasil.Nilai
+	}
+
+	pending, err := h.bandingRepo.HasPending(c.Request.Context(), req.Jenis, req.HasilID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa status banding."})
+		return
+	}
+	if pending {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Masih ada banding yang belum diproses untuk nilai ini."})
+		return
+	}
+
+	if err := h.bandingRepo.Create(c.Request.Context(), &banding); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan pengajuan banding."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Banding nilai berhasil diajukan."})
+}
+
+func (h *bandingNilaiHandler) GetMyBanding(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	banding, err := h.bandingRepo.GetAllBySiswaID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil riwayat banding."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil riwayat banding nilai.",
+		"data":    toBandingResponses(banding),
+	})
+}
+
+// GetBandingGuru menampilkan banding yang boleh diputuskan guru (kelas perwalian atau mapel yang
+// diajarnya), bisa difilter dengan ?status=.
+func (h *bandingNilaiHandler) GetBandingGuru(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	status := c.Query("status")
+	if status != "" && status != "menunggu" && status != "diterima" && status != "ditolak" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'status' harus menunggu, diterima, atau ditolak."})
+		return
+	}
+
+	banding, err := h.bandingRepo.GetAllByGuruID(c.Request.Context(), claims.UserID, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar banding."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil daftar banding nilai.",
+		"data":    toBandingResponses(banding),
+	})
+}
+
+func (h *bandingNilaiHandler) TerimaBanding(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID banding tidak valid."})
+		return
+	}
+
+	var req TerimaBandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Nilai baru wajib diisi dengan angka 0 sampai 100."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	banding, ok := h.getPendingBanding(c, id, claims.UserID)
+	if !ok {
+		return
+	}
+
+	if err := h.bandingRepo.Terima(c.Request.Context(), banding, claims.UserID, *req.NilaiBaru, req.Tanggapan); err != nil {
+		writeProsesBandingError(c, err)
+		return
+	}
+
+	h.hitungUlangRekap(c, banding)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Banding diterima dan nilai telah diperbarui."})
+}
+
+func (h *bandingNilaiHandler) TolakBanding(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID banding tidak valid."})
+		return
+	}
+
+	var req TolakBandingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tanggapan wajib diisi saat menolak banding."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	if _, ok := h.getPendingBanding(c, id, claims.UserID); !ok {
+		return
+	}
+
+	if err := h.bandingRepo.Tolak(c.Request.Context(), id, claims.UserID, req.Tanggapan); err != nil {
+		writeProsesBandingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Banding ditolak."})
+}
+
+// getPendingBanding mengambil banding yang masih menunggu dan boleh diputuskan guru; response error
+// sudah ditulis jika ok bernilai false.
+func (h *bandingNilaiHandler) getPendingBanding(c *gin.Context, id int, guruID int) (*models.BandingNilai, bool) {
+	banding, err := h.bandingRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Banding tidak ditemukan."})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data banding."})
+		return nil, false
+	}
+	if banding.Status != "menunggu" {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Banding ini sudah diproses."})
+		return nil, false
+	}
+
+	boleh, err := h.bandingRepo.BolehMemproses(c.Request.Context(), id, guruID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa hak memproses banding."})
+		return nil, false
+	}
+	if !boleh {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Hanya guru pengampu mapel atau wali kelas yang dapat memproses banding ini."})
+		return nil, false
+	}
+	return banding, true
+}
+
+func writeProsesBandingError(c *gin.Context, err error) {
+	if errors.Is(err, repositories.ErrBandingSudahDiproses) {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Banding ini sudah diproses."})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memproses banding."})
+}
+
+// hitungUlangRekap menerapkan remedial dan memperbarui rekap nilai setelah nilai tugas/quiz berubah
+// karena banding diterima.
+func (h *bandingNilaiHandler) hitungUlangRekap(c *gin.Context, banding *models.BandingNilai) {
+	var err error
+	if banding.HasilTugasID != nil {
+		var hasil *models.HasilTugas
+		if hasil, err = h.hasilTugasRepo.GetByID(c.Request.Context(), *banding.HasilTugasID); err == nil {
+			err = h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), "tugas", hasil.TugasID)
+		}
+	}
+	if banding.HasilQuizID != nil {
+		var hasil *models.HasilQuiz
+		if hasil, err = h.hasilQuizRepo.GetByID(c.Request.Context(), *banding.HasilQuizID); err == nil {
+			err = h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), "quiz", hasil.QuizID)
+		}
+	}
+	if err != nil {
+		log.Printf("Gagal menghitung ulang rekap nilai untuk banding %d: %v", banding.ID, err)
+	}
+}
+
+func toBandingResponses(banding []repositories.BandingNilaiSiswa) []BandingResponse {
+	var response []BandingResponse
+	for _, item := range banding {
+		response = append(response, BandingResponse{
+			ID:           item.ID,
+			SiswaID:      item.SiswaID,
+			NamaSiswa:    item.NamaSiswa,
+			Jenis:        item.Jenis,
+			HasilTugasID: item.HasilTugasID,
+			HasilQuizID:  item.HasilQuizID,
+			Judul:        item.Judul,
+			NilaiLama:    item.NilaiLama,
+			NilaiBaru:    item.NilaiBaru,
+			Alasan:       item.Alasan,
+			Tanggapan:    item.Tanggapan,
+			Status:       item.Status,
+			Diproses:     item.Diproses,
+			Created:      item.Created,
+		})
+	}
+	return response
+}
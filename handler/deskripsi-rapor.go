@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type TemplateDeskripsiRequest struct {
+	MataPelajaranID *int     `json:"mata_pelajaran_id"`
+	Kompetensi      *string  `json:"kompetensi"`
+	NilaiMin        *float64 `json:"nilai_min" binding:"required,gte=0,lte=100"`
+	NilaiMax        *float64 `json:"nilai_max" binding:"required,gte=0,lte=100"`
+	Template        string   `json:"template" binding:"required"`
+}
+
+type GenerateDeskripsiRequest struct {
+	KelasID int `json:"kelas_id" binding:"required"`
+	MapelID int `json:"mapel_id" binding:"required"`
+}
+
+type EditDeskripsiRequest struct {
+	Deskripsi string `json:"deskripsi" binding:"required"`
+}
+
+type TemplateDeskripsiResponse struct {
+	ID              int     `json:"id"`
+	MataPelajaranID *int    `json:"mata_pelajaran_id"`
+	Kompetensi      *string `json:"kompetensi"`
+	NilaiMin        float64 `json:"nilai_min"`
+	NilaiMax        float64 `json:"nilai_max"`
+	Template        string  `json:"template"`
+}
+
+type DeskripsiRaporResponse struct {
+	ID              int        `json:"id"`
+	SiswaID         int        `json:"siswa_id"`
+	NamaSiswa       string     `json:"nama_siswa"`
+	MataPelajaranID int        `json:"mata_pelajaran_id"`
+	KelasID         int        `json:"kelas_id"`
+	Deskripsi       string     `json:"deskripsi"`
+	IsFinal         bool       `json:"is_final"`
+	Difinalkan      *time.Time `json:"difinalkan,omitempty"`
+	Updated         time.Time  `json:"updated"`
+}
+
+type deskripsiRaporHandler struct {
+	deskripsiRepo    repositories.DeskripsiRaporRepository
+	deskripsiService *services.DeskripsiRaporService
+}
+
+func NewDeskripsiRaporHandler(
+	deskripsiRepo repositories.DeskripsiRaporRepository,
+	deskripsiService *services.DeskripsiRaporService,
+) *deskripsiRaporHandler {
+	return &deskripsiRaporHandler{
+		deskripsiRepo:    deskripsiRepo,
+		deskripsiService: deskripsiService,
+	}
+}
+
+func (h *deskripsiRaporHandler) CreateTemplate(c *gin.Context) {
+	template, ok := bindTemplateDeskripsi(c)
+	if !ok {
+		return
+	}
+
+	if err := h.deskripsiRepo.CreateTemplate(c.Request.Context(), template); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Mata pelajaran dengan ID yang diberikan tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan template deskripsi."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Template deskripsi berhasil dibuat."})
+}
+
+func (h *deskripsiRaporHandler) UpdateTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID template tidak valid."})
+		return
+	}
+
+	template, ok := bindTemplateDeskripsi(c)
+	if !ok {
+		return
+	}
+	template.ID = id
+
+	if err := h.deskripsiRepo.UpdateTemplate(c.Request.Context(), template); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Template deskripsi tidak ditemukan."})
+			return
+		}
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Mata pelajaran dengan ID yang diberikan tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui template deskripsi."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Template deskripsi berhasil diperbarui."})
+}
+
+func (h *deskripsiRaporHandler) DeleteTemplate(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID template tidak valid."})
+		return
+	}
+
+	if err := h.deskripsiRepo.DeleteTemplate(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Template deskripsi tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus template deskripsi."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Template deskripsi berhasil dihapus."})
+}
+
+func (h *deskripsiRaporHandler) GetAllTemplate(c *gin.Context) {
+	templates, err := h.deskripsiRepo.GetAllTemplate(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil template deskripsi."})
+		return
+	}
+
+	var response []TemplateDeskripsiResponse
+	for _, item := range templates {
+		response = append(response, TemplateDeskripsiResponse{
+			ID:              item.ID,
+			MataPelajaranID: item.MataPelajaranID,
+			Kompetensi:      item.Kompetensi,
+			NilaiMin:        item.NilaiMin,
+			NilaiMax:        item.NilaiMax,
+			Template:        item.Template,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil template deskripsi.",
+		"data":    response,
+	})
+}
+
+// GenerateDeskripsi membuat draf deskripsi capaian untuk seluruh siswa di satu kelas dan mapel.
+// Hanya wali kelas atau admin yang dapat melakukannya.
+func (h *deskripsiRaporHandler) GenerateDeskripsi(c *gin.Context) {
+	var req GenerateDeskripsiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "kelas_id dan mapel_id wajib diisi."})
+		return
+	}
+
+	if !h.pastikanBolehMengelola(c, req.KelasID) {
+		return
+	}
+
+	jumlah, err := h.deskripsiService.GenerateKelasMapel(c.Request.Context(), req.KelasID, req.MapelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelas atau mata pelajaran tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuat deskripsi rapor."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Deskripsi rapor berhasil dibuat. Deskripsi yang sudah final tidak diubah.",
+		"data":    gin.H{"jumlah_siswa": jumlah},
+	})
+}
+
+func (h *deskripsiRaporHandler) GetDeskripsiKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+	mapelID, err := strconv.Atoi(c.Query("mapel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'mapel_id' wajib diisi."})
+		return
+	}
+
+	deskripsi, err := h.deskripsiRepo.GetAllByKelasAndMapelID(c.Request.Context(), kelasID, mapelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil deskripsi rapor."})
+		return
+	}
+
+	var response []DeskripsiRaporResponse
+	for _, item := range deskripsi {
+		response = append(response, DeskripsiRaporResponse{
+			ID:              item.ID,
+			SiswaID:         item.SiswaID,
+			NamaSiswa:       item.NamaSiswa,
+			MataPelajaranID: item.MataPelajaranID,
+			KelasID:         item.KelasID,
+			Deskripsi:       item.Deskripsi,
+			IsFinal:         item.IsFinal,
+			Difinalkan:      item.Difinalkan,
+			Updated:         item.Updated,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil deskripsi rapor.",
+		"data":    response,
+	})
+}
+
+// EditDeskripsi menyimpan suntingan wali kelas atau admin atas deskripsi yang belum final.
+func (h *deskripsiRaporHandler) EditDeskripsi(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID deskripsi tidak valid."})
+		return
+	}
+
+	var req EditDeskripsiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Deskripsi wajib diisi."})
+		return
+	}
+
+	if !h.pastikanBolehUbah(c, id) {
+		return
+	}
+
+	if err := h.deskripsiRepo.UpdateDeskripsi(c.Request.Context(), id, req.Deskripsi); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Deskripsi sudah final dan tidak dapat diubah."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan deskripsi."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Deskripsi berhasil diperbarui."})
+}
+
+// FinalkanDeskripsi mengunci deskripsi sehingga dipakai di rapor dan tidak ditimpa saat generate ulang.
+// Penguncian tidak dapat dibatalkan, sehingga hanya wali kelas atau admin yang dapat melakukannya.
+func (h *deskripsiRaporHandler) FinalkanDeskripsi(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID deskripsi tidak valid."})
+		return
+	}
+
+	if !h.pastikanBolehUbah(c, id) {
+		return
+	}
+
+	if err := h.deskripsiRepo.Finalkan(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Deskripsi sudah final."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memfinalkan deskripsi."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Deskripsi berhasil difinalkan."})
+}
+
+// pastikanBolehUbah memeriksa bahwa deskripsi ada, belum final, dan pengguna boleh mengelola kelasnya.
+func (h *deskripsiRaporHandler) pastikanBolehUbah(c *gin.Context, id int) bool {
+	deskripsi, err := h.deskripsiRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Deskripsi tidak ditemukan."})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil deskripsi."})
+		return false
+	}
+	if !h.pastikanBolehMengelola(c, deskripsi.KelasID) {
+		return false
+	}
+	if deskripsi.IsFinal {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Deskripsi sudah final dan tidak dapat diubah."})
+		return false
+	}
+	return true
+}
+
+func (h *deskripsiRaporHandler) pastikanBolehMengelola(c *gin.Context, kelasID int) bool {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return false
+	}
+	boleh, err := h.deskripsiService.BolehMengelola(c.Request.Context(), kelasID, claims.Role, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelas tidak ditemukan."})
+			return false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa wali kelas."})
+		return false
+	}
+	if !boleh {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Hanya wali kelas atau admin yang dapat mengelola deskripsi rapor kelas ini."})
+		return false
+	}
+	return true
+}
+
+func bindTemplateDeskripsi(c *gin.Context) (*models.TemplateDeskripsi, bool) {
+	var req TemplateDeskripsiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Rentang nilai (0-100) dan template wajib diisi."})
+		return nil, false
+	}
+	if *req.NilaiMin > *req.NilaiMax {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "nilai_min tidak boleh lebih besar dari nilai_max."})
+		return nil, false
+	}
+
+	return &models.TemplateDeskripsi{
+		MataPelajaranID: req.MataPelajaranID,
+		Kompetensi:      req.Kompetensi,
+		NilaiMin:        *req.NilaiMin,
+		NilaiMax:        *req.NilaiMax,
+		Template:        req.Template,
+	}, true
+}
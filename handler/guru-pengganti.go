@@ -0,0 +1,361 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KetidakhadiranGuruRequest struct {
+	GuruID         int     `json:"guru_id" binding:"required"`
+	TanggalMulai   string  `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai string  `json:"tanggal_selesai" binding:"required"`
+	Alasan         *string `json:"alasan"`
+}
+
+type TetapkanPenggantiRequest struct {
+	GuruPenggantiID *int `json:"guru_pengganti_id"`
+}
+
+type KetidakhadiranGuruResponse struct {
+	ID             int       `json:"id"`
+	GuruID         int       `json:"guru_id"`
+	TanggalMulai   string    `json:"tanggal_mulai"`
+	TanggalSelesai string    `json:"tanggal_selesai"`
+	Alasan         *string   `json:"alasan"`
+	Created        time.Time `json:"created"`
+}
+
+type GuruTersediaResponse struct {
+	GuruID        int    `json:"guru_id"`
+	Nama          string `json:"nama"`
+	MengajarMapel bool   `json:"mengajar_mapel"`
+	BebanHari     int    `json:"beban_hari"`
+}
+
+type SesiPenggantiResponse struct {
+	ID                int                    `json:"id"`
+	KetidakhadiranID  int                    `json:"ketidakhadiran_id"`
+	JadwalID          int                    `json:"jadwal_id"`
+	Tanggal           string                 `json:"tanggal"`
+	Hari              string                 `json:"hari"`
+	JamMulai          string                 `json:"jam_mulai"`
+	JamSelesai        string                 `json:"jam_selesai"`
+	Ruang             *string                `json:"ruang"`
+	KelasID           int                    `json:"kelas_id"`
+	NamaKelas         string                 `json:"nama_kelas"`
+	MataPelajaranID   int                    `json:"mata_pelajaran_id"`
+	NamaMapel         string                 `json:"nama_mapel"`
+	GuruID            int                    `json:"guru_id"`
+	NamaGuru          string                 `json:"nama_guru"`
+	GuruPenggantiID   *int                   `json:"guru_pengganti_id"`
+	NamaGuruPengganti *string                `json:"nama_guru_pengganti"`
+	Saran             []GuruTersediaResponse `json:"saran,omitempty"`
+}
+
+type guruPenggantiHandler struct {
+	penggantiRepo    repositories.GuruPenggantiRepository
+	penggantiService *services.GuruPenggantiService
+}
+
+func NewGuruPenggantiHandler(
+	penggantiRepo repositories.GuruPenggantiRepository,
+	penggantiService *services.GuruPenggantiService,
+) *guruPenggantiHandler {
+	return &guruPenggantiHandler{
+		penggantiRepo:    penggantiRepo,
+		penggantiService: penggantiService,
+	}
+}
+
+// CatatKetidakhadiran mencatat guru yang berhalangan pada rentang tanggal. Setiap slot jadwal guru
+// tersebut di rentang itu menjadi pertemuan yang perlu digantikan, lengkap dengan saran pengganti.
+func (h *guruPenggantiHandler) CatatKetidakhadiran(c *gin.Context) {
+	var req KetidakhadiranGuruRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "guru_id, tanggal_mulai, dan tanggal_selesai wajib diisi."})
+		return
+	}
+	mulai, errMulai := time.ParseInLocation("2006-01-02", req.TanggalMulai, time.Local)
+	selesai, errSelesai := time.ParseInLocation("2006-01-02", req.TanggalSelesai, time.Local)
+	if errMulai != nil || errSelesai != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+		return
+	}
+
+	ketidakhadiran := &models.KetidakhadiranGuru{
+		GuruID:         req.GuruID,
+		TanggalMulai:   mulai,
+		TanggalSelesai: selesai,
+		Alasan:         req.Alasan,
+	}
+	detail, dilepas, err := h.penggantiService.CatatKetidakhadiran(c.Request.Context(), ketidakhadiran)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrRentangKetidakhadiran):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Rentang tanggal tidak valid: " + err.Error()})
+		case errors.Is(err, services.ErrGuruTidakDitemukan):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Guru tidak ditemukan."})
+		case errors.Is(err, repositories.ErrKetidakhadiranTumpang):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Guru sudah tercatat berhalangan pada sebagian tanggal tersebut."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mencatat ketidakhadiran guru."})
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Ketidakhadiran guru berhasil dicatat.",
+		"data": gin.H{
+			"ketidakhadiran":             toKetidakhadiranResponse(detail.Ketidakhadiran),
+			"sesi":                       toSesiDetailResponses(detail.Sesi),
+			"jumlah_penggantian_dilepas": dilepas,
+		},
+	})
+}
+
+// GetAllKetidakhadiran mengambil ketidakhadiran yang beririsan dengan ?dari= dan ?sampai=
+// (YYYY-MM-DD). Default-nya hari ini sampai 30 hari ke depan.
+func (h *guruPenggantiHandler) GetAllKetidakhadiran(c *gin.Context) {
+	hariIni := time.Now()
+	dari, sampai := hariIni, hariIni.AddDate(0, 0, 30)
+	var err error
+	if v := c.Query("dari"); v != "" {
+		if dari, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+			return
+		}
+	}
+	if v := c.Query("sampai"); v != "" {
+		if sampai, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+			return
+		}
+	}
+
+	daftar, err := h.penggantiRepo.GetAllKetidakhadiran(c.Request.Context(), dari, sampai)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar ketidakhadiran guru."})
+		return
+	}
+
+	response := []KetidakhadiranGuruResponse{}
+	for _, item := range daftar {
+		response = append(response, toKetidakhadiranResponse(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil daftar ketidakhadiran guru.",
+		"data":    response,
+	})
+}
+
+func (h *guruPenggantiHandler) GetKetidakhadiran(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID ketidakhadiran tidak valid."})
+		return
+	}
+
+	detail, err := h.penggantiService.GetDetail(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Ketidakhadiran tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil ketidakhadiran guru."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil ketidakhadiran guru.",
+		"data": gin.H{
+			"ketidakhadiran": toKetidakhadiranResponse(detail.Ketidakhadiran),
+			"sesi":           toSesiDetailResponses(detail.Sesi),
+		},
+	})
+}
+
+func (h *guruPenggantiHandler) DeleteKetidakhadiran(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID ketidakhadiran tidak valid."})
+		return
+	}
+
+	if err := h.penggantiRepo.DeleteKetidakhadiran(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Ketidakhadiran tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus ketidakhadiran guru."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Ketidakhadiran guru berhasil dihapus."})
+}
+
+// GetSaranPengganti mengambil seluruh guru yang bebas pada jam pertemuan tersebut.
+func (h *guruPenggantiHandler) GetSaranPengganti(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID pertemuan tidak valid."})
+		return
+	}
+
+	if _, err := h.penggantiRepo.GetSesiByID(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pertemuan tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil pertemuan."})
+		return
+	}
+
+	tersedia, err := h.penggantiRepo.GetGuruTersedia(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mencari guru pengganti."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil saran guru pengganti.",
+		"data":    toGuruTersediaResponses(tersedia),
+	})
+}
+
+// TetapkanPengganti menetapkan guru pengganti satu pertemuan; guru_pengganti_id null melepasnya.
+// Guru yang sedang mengajar, berhalangan, atau sudah menggantikan di jam yang sama ditolak.
+func (h *guruPenggantiHandler) TetapkanPengganti(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID pertemuan tidak valid."})
+		return
+	}
+
+	var req TetapkanPenggantiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format request tidak valid."})
+		return
+	}
+
+	sesi, err := h.penggantiService.Tetapkan(c.Request.Context(), id, req.GuruPenggantiID)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pertemuan tidak ditemukan."})
+		case errors.Is(err, services.ErrGuruTidakDitemukan):
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Guru pengganti tidak ditemukan."})
+		case errors.Is(err, repositories.ErrGuruTidakTersedia):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Guru tersebut tidak tersedia pada jam pertemuan ini."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menetapkan guru pengganti."})
+		}
+		return
+	}
+
+	pesan := "Guru pengganti berhasil ditetapkan."
+	if req.GuruPenggantiID == nil {
+		pesan = "Guru pengganti berhasil dilepas."
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": pesan,
+		"data":    toSesiPenggantiResponse(*sesi),
+	})
+}
+
+// GetPenggantianSaya menampilkan pertemuan yang harus digantikan guru yang login mulai hari ini.
+func (h *guruPenggantiHandler) GetPenggantianSaya(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	sesi, err := h.penggantiRepo.GetSesiByGuruPengganti(c.Request.Context(), claims.UserID, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil jadwal pengganti."})
+		return
+	}
+
+	response := []SesiPenggantiResponse{}
+	for _, item := range sesi {
+		response = append(response, toSesiPenggantiResponse(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil jadwal pengganti.",
+		"data":    response,
+	})
+}
+
+func toKetidakhadiranResponse(item models.KetidakhadiranGuru) KetidakhadiranGuruResponse {
+	return KetidakhadiranGuruResponse{
+		ID:             item.ID,
+		GuruID:         item.GuruID,
+		TanggalMulai:   item.TanggalMulai.Format("2006-01-02"),
+		TanggalSelesai: item.TanggalSelesai.Format("2006-01-02"),
+		Alasan:         item.Alasan,
+		Created:        item.Created,
+	}
+}
+
+func toSesiPenggantiResponse(item repositories.SesiPengganti) SesiPenggantiResponse {
+	return SesiPenggantiResponse{
+		ID:                item.ID,
+		KetidakhadiranID:  item.KetidakhadiranID,
+		JadwalID:          item.JadwalID,
+		Tanggal:           item.Tanggal.Format("2006-01-02"),
+		Hari:              item.Hari,
+		JamMulai:          item.JamMulai.Format("15:04"),
+		JamSelesai:        item.JamSelesai.Format("15:04"),
+		Ruang:             item.Ruang,
+		KelasID:           item.KelasID,
+		NamaKelas:         item.NamaKelas,
+		MataPelajaranID:   item.MataPelajaranID,
+		NamaMapel:         item.NamaMapel,
+		GuruID:            item.GuruID,
+		NamaGuru:          item.NamaGuru,
+		GuruPenggantiID:   item.GuruPenggantiID,
+		NamaGuruPengganti: item.NamaGuruPengganti,
+	}
+}
+
+func toSesiDetailResponses(sesi []services.SesiPenggantiDetail) []SesiPenggantiResponse {
+	response := []SesiPenggantiResponse{}
+	for _, item := range sesi {
+		baris := toSesiPenggantiResponse(item.Sesi)
+		if item.Sesi.GuruPenggantiID == nil {
+			baris.Saran = toGuruTersediaResponses(item.Saran)
+		}
+		response = append(response, baris)
+	}
+	return response
+}
+
+func toGuruTersediaResponses(tersedia []repositories.GuruTersedia) []GuruTersediaResponse {
+	response := []GuruTersediaResponse{}
+	for _, item := range tersedia {
+		response = append(response, GuruTersediaResponse{
+			GuruID:        item.ID,
+			Nama:          item.Nama,
+			MengajarMapel: item.MengajarMapel,
+			BebanHari:     item.BebanHari,
+		})
+	}
+	return response
+}
@@ -3,9 +3,11 @@ package handler
 import (
 	"be-pui/models"
 	"be-pui/repositories"
+	"be-pui/services"
 	"be-pui/utils"
 	"database/sql"
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -28,6 +30,11 @@ type GuruUpdateRequest struct {
 	NoHp  string `json:"no_hp"`
 }
 
+type NilaiTugasRequest struct {
+	Nilai    *float64 `json:"nilai" binding:"required,gte=0,lte=100"`
+	Feedback *string  `json:"feedback"`
+}
+
 type GuruResponse struct {
 	ID         int       `json:"id"`
 	Nama       string    `json:"nama"`
@@ -42,6 +49,8 @@ type guruHandler struct {
 	guruRepo       repositories.GuruRepository
 	tugasRepo      repositories.TugasRepository
 	hasilTugasRepo repositories.HasilTugasRepository
+	kelompokRepo   repositories.KelompokTugasRepository
+	nilaiService   *services.NilaiService
 	jwtUtil        *utils.JWTUtil
 }
 
@@ -62,12 +71,16 @@ func NewGuruHandler(
 	guruRepo repositories.GuruRepository,
 	tugasRepo repositories.TugasRepository,
 	hasilTugasRepo repositories.HasilTugasRepository,
+	kelompokRepo repositories.KelompokTugasRepository,
+	nilaiService *services.NilaiService,
 	jwtUtil *utils.JWTUtil,
 ) *guruHandler {
 	return &guruHandler{
 		guruRepo:       guruRepo,
 		tugasRepo:      tugasRepo,
 		hasilTugasRepo: hasilTugasRepo,
+		kelompokRepo:   kelompokRepo,
+		nilaiService:   nilaiService,
 		jwtUtil:        jwtUtil,
 	}
 }
@@ -120,6 +133,95 @@ func (h *guruHandler) CheckTugasSiswa(c *gin.Context) {
 	})
 }
 
+// GradeHasilTugas memberi nilai pada satu hasil tugas. Untuk tugas kelompok, ini menjadi nilai individu
+// yang menimpa nilai kelompok bagi siswa tersebut.
+func (h *guruHandler) GradeHasilTugas(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID hasil tugas tidak valid."})
+		return
+	}
+
+	var req NilaiTugasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Nilai wajib diisi dengan angka 0 sampai 100."})
+		return
+	}
+
+	hasil, err := h.hasilTugasRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Hasil tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil hasil tugas."})
+		return
+	}
+	if !pastikanPengampu(c, h.nilaiService, "tugas", hasil.TugasID) {
+		return
+	}
+
+	if err := h.hasilTugasRepo.UpdateNilai(c.Request.Context(), id, *req.Nilai, req.Feedback); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan nilai."})
+		return
+	}
+
+	if err := h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), "tugas", hasil.TugasID); err != nil {
+		log.Printf("Gagal memproses perubahan nilai tugas %d: %v", hasil.TugasID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Nilai berhasil disimpan."})
+}
+
+// GradeKelompok memberi nilai yang sama kepada seluruh anggota satu kelompok tugas. Anggota yang sudah
+// diberi nilai individu lewat GradeHasilTugas tetap memakai nilai individunya.
+func (h *guruHandler) GradeKelompok(c *gin.Context) {
+	kelompokID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelompok tidak valid."})
+		return
+	}
+
+	var req NilaiTugasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Nilai wajib diisi dengan angka 0 sampai 100."})
+		return
+	}
+
+	kelompok, err := h.kelompokRepo.GetByID(c.Request.Context(), kelompokID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelompok tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil kelompok."})
+		return
+	}
+	if !pastikanPengampu(c, h.nilaiService, "tugas", kelompok.TugasID) {
+		return
+	}
+
+	dinilai, err := h.hasilTugasRepo.UpdateNilaiByKelompokID(c.Request.Context(), kelompokID, *req.Nilai, req.Feedback)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelompok belum mengumpulkan tugas."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan nilai kelompok."})
+		return
+	}
+
+	if err := h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), "tugas", kelompok.TugasID); err != nil {
+		log.Printf("Gagal memproses perubahan nilai tugas %d: %v", kelompok.TugasID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Nilai kelompok berhasil disimpan.",
+		"data":    gin.H{"jumlah_dinilai": dinilai},
+	})
+}
+
 func (h *guruHandler) LoginGuru(c *gin.Context) {
 	var req LoginRequest
 
@@ -0,0 +1,158 @@
+package handler
+
+import (
+	"be-pui/repositories"
+	"be-pui/services"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type imporNilaiHandler struct {
+	tugasRepo    repositories.TugasRepository
+	quizRepo     repositories.QuizRepository
+	imporService *services.ImporNilaiService
+	nilaiService *services.NilaiService
+}
+
+func NewImporNilaiHandler(
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+	imporService *services.ImporNilaiService,
+	nilaiService *services.NilaiService,
+) *imporNilaiHandler {
+	return &imporNilaiHandler{
+		tugasRepo:    tugasRepo,
+		quizRepo:     quizRepo,
+		imporService: imporService,
+		nilaiService: nilaiService,
+	}
+}
+
+// ImporNilai menerima file CSV/XLSX berisi nilai untuk satu tugas/quiz. Secara default hanya
+// menampilkan pratinjau perubahan (dry run); nilai baru ditulis jika form dry_run bernilai false
+// dan seluruh baris valid. Hanya pengampu tugas/quiz yang dapat mengimpor nilainya.
+func (h *imporNilaiHandler) ImporNilai(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	dryRun := true
+	if value := c.PostForm("dry_run"); value != "" {
+		parsed, err := strconv.ParseBool(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "dry_run harus bernilai true atau false."})
+			return
+		}
+		dryRun = parsed
+	}
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "File nilai (.csv atau .xlsx) wajib di-upload."})
+		return
+	}
+
+	var judul string
+	var kelasID int
+	if jenis == "quiz" {
+		quiz, err := h.quizRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			writeItemNotFound(c, err, "Quiz")
+			return
+		}
+		judul, kelasID = quiz.Judul, quiz.KelasID
+	} else {
+		tugas, err := h.tugasRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			writeItemNotFound(c, err, "Tugas")
+			return
+		}
+		judul, kelasID = tugas.Judul, tugas.KelasID
+	}
+	if !pastikanPengampu(c, h.nilaiService, jenis, id) {
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuka file yang di-upload."})
+		return
+	}
+	defer file.Close()
+
+	rows, err := services.BacaSpreadsheet(file, fileHeader.Filename)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		return
+	}
+
+	hasil, err := h.imporService.Pratinjau(c.Request.Context(), jenis, id, judul, kelasID, rows)
+	if err != nil {
+		if errors.Is(err, services.ErrFormatImpor) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+			return
+		}
+		if errors.Is(err, services.ErrImporKurvaAktif) {
+			writeImporKurvaAktif(c)
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memvalidasi file nilai."})
+		return
+	}
+
+	if dryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Pratinjau impor nilai. Kirim ulang dengan dry_run=false untuk menyimpan.",
+			"data":    hasil,
+		})
+		return
+	}
+
+	if hasil.JumlahError > 0 {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{
+			"success": false,
+			"message": "Terdapat baris yang tidak valid. Perbaiki file lalu upload ulang; tidak ada nilai yang disimpan.",
+			"data":    hasil,
+		})
+		return
+	}
+
+	if err := h.imporService.Terapkan(c.Request.Context(), jenis, id, hasil); err != nil {
+		switch {
+		case errors.Is(err, services.ErrSetelahImpor):
+			log.Printf("Gagal memproses perubahan nilai %s %d: %v", jenis, id, err)
+		case errors.Is(err, services.ErrImporKurvaAktif):
+			writeImporKurvaAktif(c)
+			return
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan nilai. Tidak ada nilai yang diubah."})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Nilai berhasil diimpor.",
+		"data":    hasil,
+	})
+}
+
+func writeImporKurvaAktif(c *gin.Context) {
+	c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Nilai sedang dikurva. Batalkan kurva terlebih dahulu sebelum mengimpor nilai."})
+}
+
+func writeItemNotFound(c *gin.Context, err error, label string) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": label + " tidak ditemukan."})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data " + strings.ToLower(label) + "."})
+}
@@ -0,0 +1,508 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type JadwalKelasRequest struct {
+	KelasID         int     `json:"kelas_id" binding:"required"`
+	MataPelajaranID int     `json:"mata_pelajaran_id" binding:"required"`
+	Hari            string  `json:"hari" binding:"required,oneof=Senin Selasa Rabu Kamis Jumat Sabtu"`
+	JamMulai        string  `json:"jam_mulai" binding:"required"`
+	JamSelesai      string  `json:"jam_selesai" binding:"required"`
+	GuruID          *int    `json:"guru_id"`
+	Ruang           *string `json:"ruang"`
+}
+
+type JadwalKelasResponse struct {
+	ID              int       `json:"id"`
+	KelasID         int       `json:"kelas_id"`
+	MataPelajaranID int       `json:"mata_pelajaran_id"`
+	Hari            string    `json:"hari"`
+	JamMulai        string    `json:"jam_mulai"`
+	JamSelesai      string    `json:"jam_selesai"`
+	GuruID          *int      `json:"guru_id"`
+	Ruang           *string   `json:"ruang"`
+	Updated         time.Time `json:"updated"`
+}
+
+type SlotBentrokResponse struct {
+	JadwalKelasResponse
+	Alasan []string `json:"alasan"`
+}
+
+type PasanganBentrokResponse struct {
+	Alasan  []string            `json:"alasan"`
+	JadwalA JadwalKelasResponse `json:"jadwal_a"`
+	JadwalB JadwalKelasResponse `json:"jadwal_b"`
+}
+
+type PeriodeJadwalRequest struct {
+	Mulai   string `json:"mulai" binding:"required"`
+	Selesai string `json:"selesai" binding:"required"`
+}
+
+type KetidaktersediaanGuruRequest struct {
+	GuruID  int    `json:"guru_id" binding:"required"`
+	Hari    string `json:"hari" binding:"required"`
+	Periode []int  `json:"periode"`
+}
+
+type KebutuhanJadwalRequest struct {
+	KelasID         int     `json:"kelas_id" binding:"required"`
+	MataPelajaranID int     `json:"mata_pelajaran_id" binding:"required"`
+	GuruID          int     `json:"guru_id" binding:"required"`
+	JamPerMinggu    int     `json:"jam_per_minggu" binding:"required,gte=1"`
+	Ruang           *string `json:"ruang"`
+}
+
+type GenerateJadwalRequest struct {
+	Hari          []string                       `json:"hari"`
+	Periode       []PeriodeJadwalRequest         `json:"periode" binding:"required,min=1,dive"`
+	Ruang         []string                       `json:"ruang"`
+	TidakTersedia []KetidaktersediaanGuruRequest `json:"tidak_tersedia" binding:"dive"`
+	Kebutuhan     []KebutuhanJadwalRequest       `json:"kebutuhan" binding:"required,min=1,dive"`
+	MaksBerturut  int                            `json:"maks_berturut" binding:"gte=0"`
+	DryRun        *bool                          `json:"dry_run"`
+}
+
+type jadwalKelasHandler struct {
+	jadwalRepo       repositories.JadwalKelasRepository
+	generatorService *services.GeneratorJadwalService
+	pribadiService   *services.JadwalPribadiService
+}
+
+func NewJadwalKelasHandler(
+	jadwalRepo repositories.JadwalKelasRepository,
+	generatorService *services.GeneratorJadwalService,
+	pribadiService *services.JadwalPribadiService,
+) *jadwalKelasHandler {
+	return &jadwalKelasHandler{
+		jadwalRepo:       jadwalRepo,
+		generatorService: generatorService,
+		pribadiService:   pribadiService,
+	}
+}
+
+// CreateJadwal menambahkan slot jadwal. Slot yang beririsan dengan slot lain di kelas yang sama,
+// dengan guru yang sama, atau di ruang yang sama ditolak.
+func (h *jadwalKelasHandler) CreateJadwal(c *gin.Context) {
+	jadwal, ok := bindJadwalKelas(c)
+	if !ok {
+		return
+	}
+
+	if err := h.jadwalRepo.Create(c.Request.Context(), jadwal); err != nil {
+		writeJadwalSimpanError(c, err, "Gagal menyimpan jadwal.")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Jadwal berhasil dibuat.",
+		"data":    toJadwalResponse(*jadwal),
+	})
+}
+
+func (h *jadwalKelasHandler) UpdateJadwal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID jadwal tidak valid."})
+		return
+	}
+
+	jadwal, ok := bindJadwalKelas(c)
+	if !ok {
+		return
+	}
+	jadwal.ID = id
+
+	if err := h.jadwalRepo.Update(c.Request.Context(), jadwal); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Jadwal tidak ditemukan."})
+			return
+		}
+		writeJadwalSimpanError(c, err, "Gagal memperbarui jadwal.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Jadwal berhasil diperbarui.",
+		"data":    toJadwalResponse(*jadwal),
+	})
+}
+
+func (h *jadwalKelasHandler) DeleteJadwal(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID jadwal tidak valid."})
+		return
+	}
+
+	if err := h.jadwalRepo.Delete(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Jadwal tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus jadwal."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Jadwal berhasil dihapus."})
+}
+
+func (h *jadwalKelasHandler) GetJadwalByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID jadwal tidak valid."})
+		return
+	}
+
+	jadwal, err := h.jadwalRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Jadwal tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil jadwal."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil jadwal.",
+		"data":    toJadwalResponse(*jadwal),
+	})
+}
+
+// GetJadwalKelas mengambil seluruh slot jadwal satu kelas, terurut dari Senin lalu jam mulai.
+func (h *jadwalKelasHandler) GetJadwalKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+
+	jadwal, err := h.jadwalRepo.GetAllByKelasID(c.Request.Context(), kelasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil jadwal kelas."})
+		return
+	}
+
+	response := []JadwalKelasResponse{}
+	for _, item := range jadwal {
+		response = append(response, toJadwalResponse(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil jadwal kelas.",
+		"data":    response,
+	})
+}
+
+// GetJadwalSiswa menampilkan jadwal pelajaran siswa yang login untuk hari ini dan pekan berjalan,
+// digabung dengan deadline tugas dan jendela quiz. Pekan lain dapat dilihat lewat ?tanggal=YYYY-MM-DD.
+func (h *jadwalKelasHandler) GetJadwalSiswa(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+	tanggal, ok := parseTanggalAcuan(c)
+	if !ok {
+		return
+	}
+
+	jadwal, err := h.pribadiService.GetJadwalSiswa(c.Request.Context(), claims.UserID, tanggal)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Siswa tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil jadwal siswa."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil jadwal siswa.",
+		"data":    jadwal,
+	})
+}
+
+// GetJadwalGuru menampilkan slot mengajar guru yang login di semua kelas untuk hari ini dan pekan
+// berjalan, digabung dengan deadline tugas dan jendela quiz pada kelas dan mapel yang diampu.
+func (h *jadwalKelasHandler) GetJadwalGuru(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+	tanggal, ok := parseTanggalAcuan(c)
+	if !ok {
+		return
+	}
+
+	jadwal, err := h.pribadiService.GetJadwalGuru(c.Request.Context(), claims.UserID, tanggal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil jadwal guru."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil jadwal guru.",
+		"data":    jadwal,
+	})
+}
+
+// GetBentrokJadwal memindai seluruh jadwal dan mencantumkan setiap pasangan slot yang bentrok
+// kelas, guru, atau ruangnya, termasuk data lama yang tersimpan sebelum pemeriksaan bentrok ada.
+func (h *jadwalKelasHandler) GetBentrokJadwal(c *gin.Context) {
+	pasangan, err := h.jadwalRepo.GetSemuaBentrok(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memindai bentrok jadwal."})
+		return
+	}
+
+	response := []PasanganBentrokResponse{}
+	if len(pasangan) > 0 {
+		semua, err := h.jadwalRepo.GetAll(c.Request.Context())
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil jadwal."})
+			return
+		}
+		jadwalByID := make(map[int]models.JadwalKelas)
+		for _, item := range semua {
+			jadwalByID[item.ID] = item
+		}
+
+		for _, item := range pasangan {
+			var alasan []string
+			if item.Kelas {
+				alasan = append(alasan, "kelas")
+			}
+			if item.Guru {
+				alasan = append(alasan, "guru")
+			}
+			if item.Ruang {
+				alasan = append(alasan, "ruang")
+			}
+			response = append(response, PasanganBentrokResponse{
+				Alasan:  alasan,
+				JadwalA: toJadwalResponse(jadwalByID[item.JadwalAID]),
+				JadwalB: toJadwalResponse(jadwalByID[item.JadwalBID]),
+			})
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil memindai bentrok jadwal.",
+		"data":    gin.H{"jumlah": len(response), "bentrok": response},
+	})
+}
+
+// GenerateJadwal menyusun jadwal otomatis untuk kelas-kelas pada daftar kebutuhan. Secara default
+// hanya menampilkan pratinjau; dengan dry_run=false jadwal lama kelas-kelas tersebut diganti hasil
+// generate dalam satu transaksi.
+func (h *jadwalKelasHandler) GenerateJadwal(c *gin.Context) {
+	var req GenerateJadwalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "periode dan kebutuhan (kelas_id, mata_pelajaran_id, guru_id, jam_per_minggu) wajib diisi."})
+		return
+	}
+
+	input := services.InputJadwal{
+		Hari:         req.Hari,
+		Ruang:        req.Ruang,
+		MaksBerturut: req.MaksBerturut,
+	}
+	if len(input.Hari) == 0 {
+		input.Hari = []string{"Senin", "Selasa", "Rabu", "Kamis", "Jumat"}
+	}
+	for i, item := range req.Periode {
+		mulai, errMulai := time.Parse("15:04", item.Mulai)
+		selesai, errSelesai := time.Parse("15:04", item.Selesai)
+		if errMulai != nil || errSelesai != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Format jam periode %d harus HH:MM.", i+1)})
+			return
+		}
+		input.Periode = append(input.Periode, services.PeriodeJadwal{Mulai: mulai, Selesai: selesai})
+	}
+	for _, item := range req.TidakTersedia {
+		input.TidakTersedia = append(input.TidakTersedia, services.KetidaktersediaanGuru{
+			GuruID:  item.GuruID,
+			Hari:    item.Hari,
+			Periode: item.Periode,
+		})
+	}
+	for _, item := range req.Kebutuhan {
+		input.Kebutuhan = append(input.Kebutuhan, services.KebutuhanJadwal{
+			KelasID:         item.KelasID,
+			MataPelajaranID: item.MataPelajaranID,
+			GuruID:          item.GuruID,
+			JamPerMinggu:    item.JamPerMinggu,
+			Ruang:           item.Ruang,
+		})
+	}
+
+	hasil, err := h.generatorService.Generate(c.Request.Context(), input)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInputJadwal):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		case errors.Is(err, services.ErrJadwalTidakDitemukan):
+			c.JSON(http.StatusUnprocessableEntity, gin.H{"success": false, "message": "Tidak ditemukan jadwal tanpa bentrok untuk kebutuhan ini: " + err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun jadwal."})
+		}
+		return
+	}
+
+	jadwal := []JadwalKelasResponse{}
+	for _, item := range hasil.Jadwal {
+		jadwal = append(jadwal, toJadwalResponse(item))
+	}
+	data := gin.H{
+		"kelas_ids":   input.KelasIDs(),
+		"jumlah_slot": len(jadwal),
+		"jadwal":      jadwal,
+		"pelanggaran": hasil.Pelanggaran,
+	}
+
+	if req.DryRun == nil || *req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Pratinjau jadwal. Kirim ulang dengan dry_run=false untuk menyimpan dan mengganti jadwal kelas-kelas ini.",
+			"data":    data,
+		})
+		return
+	}
+
+	if err := h.generatorService.Simpan(c.Request.Context(), input, hasil); err != nil {
+		writeJadwalSimpanError(c, err, "Gagal menyimpan jadwal. Tidak ada jadwal yang diubah.")
+		return
+	}
+
+	jadwal = jadwal[:0]
+	for _, item := range hasil.Jadwal {
+		jadwal = append(jadwal, toJadwalResponse(item))
+	}
+	data["jadwal"] = jadwal
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Jadwal berhasil dibuat dan disimpan.",
+		"data":    data,
+	})
+}
+
+// parseTanggalAcuan membaca ?tanggal=YYYY-MM-DD; jika kosong memakai hari ini.
+func parseTanggalAcuan(c *gin.Context) (time.Time, bool) {
+	nilai := c.Query("tanggal")
+	if nilai == "" {
+		return time.Now(), true
+	}
+	tanggal, err := time.ParseInLocation("2006-01-02", nilai, time.Local)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+		return time.Time{}, false
+	}
+	return tanggal, true
+}
+
+func bindJadwalKelas(c *gin.Context) (*models.JadwalKelas, bool) {
+	var req JadwalKelasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "kelas_id, mata_pelajaran_id, hari (Senin-Sabtu), jam_mulai, dan jam_selesai wajib diisi."})
+		return nil, false
+	}
+
+	jamMulai, err := time.Parse("15:04", req.JamMulai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format jam_mulai harus HH:MM."})
+		return nil, false
+	}
+	jamSelesai, err := time.Parse("15:04", req.JamSelesai)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format jam_selesai harus HH:MM."})
+		return nil, false
+	}
+	if !jamSelesai.After(jamMulai) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "jam_selesai harus setelah jam_mulai."})
+		return nil, false
+	}
+
+	if req.Ruang != nil {
+		ruang := strings.TrimSpace(*req.Ruang)
+		req.Ruang = &ruang
+		if ruang == "" {
+			req.Ruang = nil
+		}
+	}
+
+	return &models.JadwalKelas{
+		KelasID:         req.KelasID,
+		MataPelajaranID: req.MataPelajaranID,
+		Hari:            req.Hari,
+		JamMulai:        jamMulai,
+		JamSelesai:      jamSelesai,
+		GuruID:          req.GuruID,
+		Ruang:           req.Ruang,
+	}, true
+}
+
+func writeJadwalSimpanError(c *gin.Context, err error, pesan string) {
+	var bentrokErr *repositories.BentrokJadwalError
+	if errors.As(err, &bentrokErr) {
+		bentrok := []SlotBentrokResponse{}
+		for _, item := range bentrokErr.Bentrok {
+			bentrok = append(bentrok, SlotBentrokResponse{
+				JadwalKelasResponse: toJadwalResponse(item.Jadwal),
+				Alasan:              item.Alasan,
+			})
+		}
+		c.JSON(http.StatusConflict, gin.H{
+			"success": false,
+			"message": "Jadwal bentrok dengan slot lain (kelas, guru, atau ruang yang sama).",
+			"data":    gin.H{"bentrok": bentrok},
+		})
+		return
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Kelas, mata pelajaran, atau guru dengan ID yang diberikan tidak ditemukan."})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": pesan})
+}
+
+func toJadwalResponse(jadwal models.JadwalKelas) JadwalKelasResponse {
+	return JadwalKelasResponse{
+		ID:              jadwal.ID,
+		KelasID:         jadwal.KelasID,
+		MataPelajaranID: jadwal.MataPelajaranID,
+		Hari:            jadwal.Hari,
+		JamMulai:        jadwal.JamMulai.Format("15:04"),
+		JamSelesai:      jadwal.JamSelesai.Format("15:04"),
+		GuruID:          jadwal.GuruID,
+		Ruang:           jadwal.Ruang,
+		Updated:         jadwal.Updated,
+	}
+}
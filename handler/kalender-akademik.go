@@ -0,0 +1,451 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type TahunAjaranRequest struct {
+	Nama           string `json:"nama" binding:"required"`
+	TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
+}
+
+type SemesterRequest struct {
+	TahunAjaranID  int    `json:"tahun_ajaran_id"`
+	Nama           string `json:"nama" binding:"required,oneof=Ganjil Genap"`
+	TanggalMulai   string `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai string `json:"tanggal_selesai" binding:"required"`
+}
+
+type AgendaAkademikRequest struct {
+	Jenis          string  `json:"jenis" binding:"required,oneof=libur_nasional libur_sekolah ujian"`
+	Nama           string  `json:"nama" binding:"required"`
+	TanggalMulai   string  `json:"tanggal_mulai" binding:"required"`
+	TanggalSelesai string  `json:"tanggal_selesai" binding:"required"`
+	Keterangan     *string `json:"keterangan"`
+}
+
+type TahunAjaranResponse struct {
+	ID             int       `json:"id"`
+	Nama           string    `json:"nama"`
+	TanggalMulai   string    `json:"tanggal_mulai"`
+	TanggalSelesai string    `json:"tanggal_selesai"`
+	Aktif          bool      `json:"aktif"`
+	Updated        time.Time `json:"updated"`
+}
+
+type SemesterResponse struct {
+	ID             int       `json:"id"`
+	TahunAjaranID  int       `json:"tahun_ajaran_id"`
+	Nama           string    `json:"nama"`
+	TanggalMulai   string    `json:"tanggal_mulai"`
+	TanggalSelesai string    `json:"tanggal_selesai"`
+	Updated        time.Time `json:"updated"`
+}
+
+type AgendaAkademikResponse struct {
+	ID             int       `json:"id"`
+	Jenis          string    `json:"jenis"`
+	Nama           string    `json:"nama"`
+	TanggalMulai   string    `json:"tanggal_mulai"`
+	TanggalSelesai string    `json:"tanggal_selesai"`
+	Keterangan     *string   `json:"keterangan"`
+	Updated        time.Time `json:"updated"`
+}
+
+type kalenderAkademikHandler struct {
+	kalenderRepo    repositories.KalenderAkademikRepository
+	kalenderService *services.KalenderAkademikService
+}
+
+func NewKalenderAkademikHandler(
+	kalenderRepo repositories.KalenderAkademikRepository,
+	kalenderService *services.KalenderAkademikService,
+) *kalenderAkademikHandler {
+	return &kalenderAkademikHandler{
+		kalenderRepo:    kalenderRepo,
+		kalenderService: kalenderService,
+	}
+}
+
+func (h *kalenderAkademikHandler) CreateTahunAjaran(c *gin.Context) {
+	h.simpanTahunAjaran(c, 0)
+}
+
+func (h *kalenderAkademikHandler) UpdateTahunAjaran(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tahun ajaran tidak valid."})
+		return
+	}
+	h.simpanTahunAjaran(c, id)
+}
+
+func (h *kalenderAkademikHandler) simpanTahunAjaran(c *gin.Context, id int) {
+	var req TahunAjaranRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Nama, tanggal_mulai, dan tanggal_selesai wajib diisi."})
+		return
+	}
+	mulai, selesai, ok := parseRentangTanggal(c, req.TanggalMulai, req.TanggalSelesai)
+	if !ok {
+		return
+	}
+
+	tahunAjaran := &models.TahunAjaran{ID: id, Nama: req.Nama, TanggalMulai: mulai, TanggalSelesai: selesai}
+	if err := h.kalenderService.SimpanTahunAjaran(c.Request.Context(), tahunAjaran); err != nil {
+		writeKalenderAkademikError(c, err, "Tahun ajaran")
+		return
+	}
+
+	status, pesan := http.StatusCreated, "Tahun ajaran berhasil dibuat."
+	if id != 0 {
+		status, pesan = http.StatusOK, "Tahun ajaran berhasil diperbarui."
+	}
+	c.JSON(status, gin.H{"success": true, "message": pesan, "data": toTahunAjaranResponse(*tahunAjaran)})
+}
+
+func (h *kalenderAkademikHandler) DeleteTahunAjaran(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tahun ajaran tidak valid."})
+		return
+	}
+
+	if err := h.kalenderRepo.DeleteTahunAjaran(c.Request.Context(), id); err != nil {
+		writeKalenderAkademikError(c, err, "Tahun ajaran")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Tahun ajaran berhasil dihapus."})
+}
+
+// AktifkanTahunAjaran menjadikan tahun ajaran tersebut satu-satunya yang aktif.
+func (h *kalenderAkademikHandler) AktifkanTahunAjaran(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tahun ajaran tidak valid."})
+		return
+	}
+
+	if err := h.kalenderRepo.AktifkanTahunAjaran(c.Request.Context(), id); err != nil {
+		writeKalenderAkademikError(c, err, "Tahun ajaran")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Tahun ajaran berhasil diaktifkan."})
+}
+
+func (h *kalenderAkademikHandler) GetAllTahunAjaran(c *gin.Context) {
+	daftar, err := h.kalenderRepo.GetAllTahunAjaran(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil tahun ajaran."})
+		return
+	}
+
+	response := []TahunAjaranResponse{}
+	for _, item := range daftar {
+		response = append(response, toTahunAjaranResponse(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil tahun ajaran.",
+		"data":    response,
+	})
+}
+
+func (h *kalenderAkademikHandler) GetSemesterByTahunAjaran(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tahun ajaran tidak valid."})
+		return
+	}
+
+	daftar, err := h.kalenderRepo.GetSemesterByTahunAjaranID(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil semester."})
+		return
+	}
+
+	response := []SemesterResponse{}
+	for _, item := range daftar {
+		response = append(response, toSemesterResponse(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil semester.",
+		"data":    response,
+	})
+}
+
+// CreateSemester menambahkan semester; rentangnya harus di dalam tahun ajaran dan tidak beririsan
+// dengan semester lain.
+func (h *kalenderAkademikHandler) CreateSemester(c *gin.Context) {
+	h.simpanSemester(c, 0)
+}
+
+func (h *kalenderAkademikHandler) UpdateSemester(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID semester tidak valid."})
+		return
+	}
+	h.simpanSemester(c, id)
+}
+
+func (h *kalenderAkademikHandler) simpanSemester(c *gin.Context, id int) {
+	var req SemesterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Nama ('Ganjil'/'Genap'), tanggal_mulai, dan tanggal_selesai wajib diisi."})
+		return
+	}
+	if id == 0 && req.TahunAjaranID == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "tahun_ajaran_id wajib diisi."})
+		return
+	}
+	mulai, selesai, ok := parseRentangTanggal(c, req.TanggalMulai, req.TanggalSelesai)
+	if !ok {
+		return
+	}
+
+	semester := &models.Semester{ID: id, TahunAjaranID: req.TahunAjaranID, Nama: req.Nama, TanggalMulai: mulai, TanggalSelesai: selesai}
+	if err := h.kalenderService.SimpanSemester(c.Request.Context(), semester); err != nil {
+		label := "Semester"
+		if id == 0 {
+			label = "Tahun ajaran"
+		}
+		writeKalenderAkademikError(c, err, label)
+		return
+	}
+
+	status, pesan := http.StatusCreated, "Semester berhasil dibuat."
+	if id != 0 {
+		status, pesan = http.StatusOK, "Semester berhasil diperbarui."
+	}
+	c.JSON(status, gin.H{"success": true, "message": pesan, "data": toSemesterResponse(*semester)})
+}
+
+func (h *kalenderAkademikHandler) DeleteSemester(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID semester tidak valid."})
+		return
+	}
+
+	if err := h.kalenderRepo.DeleteSemester(c.Request.Context(), id); err != nil {
+		writeKalenderAkademikError(c, err, "Semester")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Semester berhasil dihapus."})
+}
+
+func (h *kalenderAkademikHandler) CreateAgenda(c *gin.Context) {
+	h.simpanAgenda(c, 0)
+}
+
+func (h *kalenderAkademikHandler) UpdateAgenda(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID agenda tidak valid."})
+		return
+	}
+	h.simpanAgenda(c, id)
+}
+
+func (h *kalenderAkademikHandler) simpanAgenda(c *gin.Context, id int) {
+	var req AgendaAkademikRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Jenis harus 'libur_nasional', 'libur_sekolah', atau 'ujian'; nama dan tanggal wajib diisi."})
+		return
+	}
+	mulai, selesai, ok := parseRentangTanggal(c, req.TanggalMulai, req.TanggalSelesai)
+	if !ok {
+		return
+	}
+
+	agenda := &models.AgendaAkademik{
+		ID:             id,
+		Jenis:          req.Jenis,
+		Nama:           req.Nama,
+		TanggalMulai:   mulai,
+		TanggalSelesai: selesai,
+		Keterangan:     req.Keterangan,
+	}
+	if err := h.kalenderService.SimpanAgenda(c.Request.Context(), agenda); err != nil {
+		writeKalenderAkademikError(c, err, "Agenda")
+		return
+	}
+
+	status, pesan := http.StatusCreated, "Agenda akademik berhasil dibuat."
+	if id != 0 {
+		status, pesan = http.StatusOK, "Agenda akademik berhasil diperbarui."
+	}
+	c.JSON(status, gin.H{"success": true, "message": pesan, "data": toAgendaAkademikResponse(*agenda)})
+}
+
+func (h *kalenderAkademikHandler) DeleteAgenda(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID agenda tidak valid."})
+		return
+	}
+
+	if err := h.kalenderRepo.DeleteAgenda(c.Request.Context(), id); err != nil {
+		writeKalenderAkademikError(c, err, "Agenda")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Agenda akademik berhasil dihapus."})
+}
+
+// GetAgenda mengambil agenda yang beririsan dengan ?dari= dan ?sampai= (YYYY-MM-DD, default tahun
+// berjalan). ?jenis= dapat berupa libur_nasional, libur_sekolah, ujian, atau libur (keduanya).
+func (h *kalenderAkademikHandler) GetAgenda(c *gin.Context) {
+	sekarang := time.Now()
+	dari := time.Date(sekarang.Year(), time.January, 1, 0, 0, 0, 0, time.Local)
+	sampai := time.Date(sekarang.Year(), time.December, 31, 0, 0, 0, 0, time.Local)
+	var err error
+	if v := c.Query("dari"); v != "" {
+		if dari, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+			return
+		}
+	}
+	if v := c.Query("sampai"); v != "" {
+		if sampai, err = time.ParseInLocation("2006-01-02", v, time.Local); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+			return
+		}
+	}
+
+	daftar, err := h.kalenderRepo.GetAgenda(c.Request.Context(), dari, sampai, c.Query("jenis"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil agenda akademik."})
+		return
+	}
+
+	response := []AgendaAkademikResponse{}
+	for _, item := range daftar {
+		response = append(response, toAgendaAkademikResponse(item))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil agenda akademik.",
+		"data":    response,
+	})
+}
+
+// CekTanggal menampilkan apakah ?tanggal= (default hari ini) merupakan hari efektif, beserta libur,
+// pekan ujian, dan semester yang memuatnya.
+func (h *kalenderAkademikHandler) CekTanggal(c *gin.Context) {
+	tanggal, ok := parseTanggalAcuan(c)
+	if !ok {
+		return
+	}
+
+	status, err := h.kalenderService.GetStatusTanggal(c.Request.Context(), tanggal)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa kalender akademik."})
+		return
+	}
+
+	libur := []AgendaAkademikResponse{}
+	for _, item := range status.Libur {
+		libur = append(libur, toAgendaAkademikResponse(item))
+	}
+	ujian := []AgendaAkademikResponse{}
+	for _, item := range status.Ujian {
+		ujian = append(ujian, toAgendaAkademikResponse(item))
+	}
+	var semester *SemesterResponse
+	if status.Semester != nil {
+		response := toSemesterResponse(*status.Semester)
+		semester = &response
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil memeriksa kalender akademik.",
+		"data": gin.H{
+			"tanggal":          status.Tanggal.Format("2006-01-02"),
+			"hari":             services.NamaHari(status.Tanggal),
+			"hari_efektif":     status.HariEfektif,
+			"semester":         semester,
+			"di_luar_semester": status.DiLuarSemester,
+			"libur":            libur,
+			"ujian":            ujian,
+		},
+	})
+}
+
+func parseRentangTanggal(c *gin.Context, mulaiStr string, selesaiStr string) (time.Time, time.Time, bool) {
+	mulai, errMulai := time.ParseInLocation("2006-01-02", mulaiStr, time.Local)
+	selesai, errSelesai := time.ParseInLocation("2006-01-02", selesaiStr, time.Local)
+	if errMulai != nil || errSelesai != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format tanggal harus YYYY-MM-DD."})
+		return time.Time{}, time.Time{}, false
+	}
+	return mulai, selesai, true
+}
+
+func writeKalenderAkademikError(c *gin.Context, err error, label string) {
+	var pqErr *pq.Error
+	switch {
+	case err == sql.ErrNoRows:
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": label + " tidak ditemukan."})
+	case errors.Is(err, services.ErrKalenderAkademik):
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": label + " dengan nama tersebut sudah ada."})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Terjadi kesalahan pada server kami."})
+	}
+}
+
+func toTahunAjaranResponse(item models.TahunAjaran) TahunAjaranResponse {
+	return TahunAjaranResponse{
+		ID:             item.ID,
+		Nama:           item.Nama,
+		TanggalMulai:   item.TanggalMulai.Format("2006-01-02"),
+		TanggalSelesai: item.TanggalSelesai.Format("2006-01-02"),
+		Aktif:          item.Aktif,
+		Updated:        item.Updated,
+	}
+}
+
+func toSemesterResponse(item models.Semester) SemesterResponse {
+	return SemesterResponse{
+		ID:             item.ID,
+		TahunAjaranID:  item.TahunAjaranID,
+		Nama:           item.Nama,
+		TanggalMulai:   item.TanggalMulai.Format("2006-01-02"),
+		TanggalSelesai: item.TanggalSelesai.Format("2006-01-02"),
+		Updated:        item.Updated,
+	}
+}
+
+func toAgendaAkademikResponse(item models.AgendaAkademik) AgendaAkademikResponse {
+	return AgendaAkademikResponse{
+		ID:             item.ID,
+		Jenis:          item.Jenis,
+		Nama:           item.Nama,
+		TanggalMulai:   item.TanggalMulai.Format("2006-01-02"),
+		TanggalSelesai: item.TanggalSelesai.Format("2006-01-02"),
+		Keterangan:     item.Keterangan,
+		Updated:        item.Updated,
+	}
+}
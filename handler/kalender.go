@@ -0,0 +1,119 @@
+package handler
+
+import (
+	"be-pui/config"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type kalenderHandler struct {
+	tokenRepo       repositories.TokenKalenderRepository
+	kalenderService *services.KalenderService
+	cfg             *config.Config
+}
+
+func NewKalenderHandler(
+	tokenRepo repositories.TokenKalenderRepository,
+	kalenderService *services.KalenderService,
+	cfg *config.Config,
+) *kalenderHandler {
+	return &kalenderHandler{
+		tokenRepo:       tokenRepo,
+		kalenderService: kalenderService,
+		cfg:             cfg,
+	}
+}
+
+// BuatTokenKalender membuat (atau mengganti) token feed ICS pengguna yang login dan mengembalikan
+// URL langganannya. URL hanya ditampilkan sekali; URL sebelumnya langsung tidak berlaku.
+func (h *kalenderHandler) BuatTokenKalender(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	token, err := h.kalenderService.BuatToken(c.Request.Context(), claims.Role, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuat token kalender."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "URL kalender berhasil dibuat. Simpan URL ini; URL lama tidak berlaku lagi.",
+		"data":    gin.H{"url": fmt.Sprintf("%s/api/v1/kalender/feed/%s.ics", h.cfg.Server.BaseURL, token)},
+	})
+}
+
+// GetTokenKalender menampilkan apakah pengguna memiliki feed aktif. Token tidak dapat ditampilkan
+// ulang karena hanya hash-nya yang disimpan.
+func (h *kalenderHandler) GetTokenKalender(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	token, err := h.tokenRepo.GetByUser(c.Request.Context(), claims.Role, claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusOK, gin.H{"success": true, "message": "Belum ada feed kalender.", "data": gin.H{"aktif": false}})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil status kalender."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Feed kalender aktif.",
+		"data":    gin.H{"aktif": true, "created": token.Created},
+	})
+}
+
+// CabutTokenKalender menonaktifkan feed ICS pengguna yang login.
+func (h *kalenderHandler) CabutTokenKalender(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	if err := h.tokenRepo.Hapus(c.Request.Context(), claims.Role, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tidak ada feed kalender aktif."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mencabut feed kalender."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Feed kalender berhasil dicabut."})
+}
+
+// GetFeedKalender melayani feed ICS tanpa JWT; token pada URL menjadi satu-satunya otorisasi
+// sehingga aplikasi kalender dapat berlangganan.
+func (h *kalenderHandler) GetFeedKalender(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	feed, err := h.kalenderService.GetFeed(c.Request.Context(), token)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Feed kalender tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun feed kalender."})
+		return
+	}
+
+	c.Header("Cache-Control", "private, max-age=900")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", feed)
+}
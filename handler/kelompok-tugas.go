@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"be-pui/repositories"
+	"database/sql"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KelompokInput struct {
+	Nama     string `json:"nama" binding:"required"`
+	SiswaIDs []int  `json:"siswa_ids" binding:"required,min=1"`
+}
+
+// AturKelompokRequest dipakai untuk membagi kelompok secara manual (kelompok) atau acak (acak + jumlah_kelompok).
+type AturKelompokRequest struct {
+	Acak           bool            `json:"acak"`
+	JumlahKelompok int             `json:"jumlah_kelompok"`
+	Kelompok       []KelompokInput `json:"kelompok" binding:"dive"`
+}
+
+type AnggotaKelompokResponse struct {
+	SiswaID   int    `json:"siswa_id"`
+	NamaSiswa string `json:"nama_siswa"`
+}
+
+type KelompokResponse struct {
+	ID      int                       `json:"id"`
+	TugasID int                       `json:"tugas_id"`
+	Nama    string                    `json:"nama"`
+	Anggota []AnggotaKelompokResponse `json:"anggota"`
+}
+
+type kelompokTugasHandler struct {
+	kelompokRepo repositories.KelompokTugasRepository
+	tugasRepo    repositories.TugasRepository
+	siswaRepo    repositories.SiswaRepository
+}
+
+func NewKelompokTugasHandler(
+	kelompokRepo repositories.KelompokTugasRepository,
+	tugasRepo repositories.TugasRepository,
+	siswaRepo repositories.SiswaRepository,
+) *kelompokTugasHandler {
+	return &kelompokTugasHandler{
+		kelompokRepo: kelompokRepo,
+		tugasRepo:    tugasRepo,
+		siswaRepo:    siswaRepo,
+	}
+}
+
+// AturKelompok membagi siswa di kelas tugas ke dalam kelompok, baik manual maupun acak.
+// Pembagian sebelumnya untuk tugas yang sama akan diganti selama belum ada pengumpulan.
+func (h *kelompokTugasHandler) AturKelompok(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	var req AturKelompokRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Request body tidak valid."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+	if !tugas.IsKelompok {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tugas ini bukan tugas kelompok."})
+		return
+	}
+
+	siswaKelas, err := h.siswaRepo.GetAllByKelasID(c.Request.Context(), tugas.KelasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data siswa kelas."})
+		return
+	}
+
+	var kelompok []repositories.KelompokWithAnggota
+	if req.Acak {
+		if req.JumlahKelompok < 1 || req.JumlahKelompok > len(siswaKelas) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Jumlah kelompok harus antara 1 dan jumlah siswa di kelas."})
+			return
+		}
+
+		siswaIDs := make([]int, 0, len(siswaKelas))
+		for _, siswa := range siswaKelas {
+			siswaIDs = append(siswaIDs, siswa.ID)
+		}
+
+		for i, anggotaIDs := range splitKelompokAcak(siswaIDs, req.JumlahKelompok) {
+			item := repositories.KelompokWithAnggota{}
+			item.Nama = fmt.Sprintf("Kelompok %d", i+1)
+			for _, siswaID := range anggotaIDs {
+				item.Anggota = append(item.Anggota, repositories.AnggotaKelompokSiswa{SiswaID: siswaID})
+			}
+			kelompok = append(kelompok, item)
+		}
+	} else {
+		if len(req.Kelompok) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Daftar kelompok wajib diisi jika tidak dibagi acak."})
+			return
+		}
+
+		siswaDiKelas := make(map[int]bool)
+		for _, siswa := range siswaKelas {
+			siswaDiKelas[siswa.ID] = true
+		}
+
+		sudahDipakai := make(map[int]bool)
+		for _, input := range req.Kelompok {
+			item := repositories.KelompokWithAnggota{}
+			item.Nama = input.Nama
+			for _, siswaID := range input.SiswaIDs {
+				if !siswaDiKelas[siswaID] {
+					c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Siswa dengan ID %d tidak terdaftar di kelas tugas ini.", siswaID)})
+					return
+				}
+				if sudahDipakai[siswaID] {
+					c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Siswa dengan ID %d terdaftar di lebih dari satu kelompok.", siswaID)})
+					return
+				}
+				sudahDipakai[siswaID] = true
+				item.Anggota = append(item.Anggota, repositories.AnggotaKelompokSiswa{SiswaID: siswaID})
+			}
+			kelompok = append(kelompok, item)
+		}
+	}
+
+	if err := h.kelompokRepo.ReplaceForTugas(c.Request.Context(), tugasID, kelompok); err != nil {
+		if errors.Is(err, repositories.ErrKelompokSudahDipakai) {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Kelompok tidak dapat diubah karena tugas ini sudah memiliki pengumpulan atau nilai."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan pembagian kelompok."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Kelompok berhasil dibentuk."})
+}
+
+func (h *kelompokTugasHandler) GetKelompokByTugasID(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	kelompok, err := h.kelompokRepo.GetAllByTugasID(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data kelompok."})
+		return
+	}
+
+	var response []KelompokResponse
+	for _, item := range kelompok {
+		kelompokResponse := KelompokResponse{
+			ID:      item.ID,
+			TugasID: item.TugasID,
+			Nama:    item.Nama,
+			Anggota: []AnggotaKelompokResponse{},
+		}
+		for _, anggota := range item.Anggota {
+			kelompokResponse.Anggota = append(kelompokResponse.Anggota, AnggotaKelompokResponse{
+				SiswaID:   anggota.SiswaID,
+				NamaSiswa: anggota.NamaSiswa,
+			})
+		}
+		response = append(response, kelompokResponse)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil data kelompok tugas.",
+		"data":    response,
+	})
+}
+
+// splitKelompokAcak mengacak siswaIDs lalu membaginya secara merata ke dalam jumlah kelompok.
+func splitKelompokAcak(siswaIDs []int, jumlah int) [][]int {
+	acak := make([]int, len(siswaIDs))
+	copy(acak, siswaIDs)
+	rand.Shuffle(len(acak), func(i, j int) { acak[i], acak[j] = acak[j], acak[i] })
+
+	kelompok := make([][]int, jumlah)
+	for i, siswaID := range acak {
+		kelompok[i%jumlah] = append(kelompok[i%jumlah], siswaID)
+	}
+	return kelompok
+}
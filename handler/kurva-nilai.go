@@ -0,0 +1,220 @@
+package handler
+
+import (
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KurvaNilaiRequest struct {
+	Metode    string   `json:"metode" binding:"required,oneof=linear akar minimum"`
+	Parameter *float64 `json:"parameter" binding:"omitempty,gte=0,lte=100"`
+	DryRun    *bool    `json:"dry_run"`
+}
+
+type kurvaNilaiHandler struct {
+	tugasRepo    repositories.TugasRepository
+	quizRepo     repositories.QuizRepository
+	kurvaRepo    repositories.KurvaNilaiRepository
+	kurvaService *services.KurvaNilaiService
+	nilaiService *services.NilaiService
+}
+
+func NewKurvaNilaiHandler(
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+	kurvaRepo repositories.KurvaNilaiRepository,
+	kurvaService *services.KurvaNilaiService,
+	nilaiService *services.NilaiService,
+) *kurvaNilaiHandler {
+	return &kurvaNilaiHandler{
+		tugasRepo:    tugasRepo,
+		quizRepo:     quizRepo,
+		kurvaRepo:    kurvaRepo,
+		kurvaService: kurvaService,
+		nilaiService: nilaiService,
+	}
+}
+
+// TerapkanKurva menghitung kurva untuk seluruh nilai satu tugas/quiz. Secara default hanya
+// menampilkan pratinjau; nilai baru ditulis jika dry_run bernilai false. Nilai mentah disimpan
+// sehingga kurva dapat dibatalkan. Hanya pengampu tugas/quiz yang dapat mengkurva nilainya.
+func (h *kurvaNilaiHandler) TerapkanKurva(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	var req KurvaNilaiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Metode harus 'linear', 'akar', atau 'minimum'; parameter harus 0-100."})
+		return
+	}
+	if req.Metode == services.KurvaAkar {
+		req.Parameter = nil
+	} else if req.Parameter == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Parameter (rata-rata target atau nilai minimum) wajib diisi untuk metode ini."})
+		return
+	}
+	if req.Metode == services.KurvaLinear && *req.Parameter <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Rata-rata target untuk metode linear harus lebih dari 0."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	if !h.pastikanItemAda(c, jenis, id) || !pastikanPengampu(c, h.nilaiService, jenis, id) {
+		return
+	}
+
+	hasil, err := h.kurvaService.Pratinjau(c.Request.Context(), jenis, id, req.Metode, req.Parameter)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrKurvaSudahAda):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Nilai ini sudah dikurva. Batalkan kurva sebelumnya terlebih dahulu."})
+		case errors.Is(err, services.ErrTanpaNilai):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Belum ada nilai yang dapat dikurva."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghitung kurva nilai."})
+		}
+		return
+	}
+
+	if req.DryRun == nil || *req.DryRun {
+		c.JSON(http.StatusOK, gin.H{
+			"success": true,
+			"message": "Pratinjau kurva nilai. Kirim ulang dengan dry_run=false untuk menerapkan.",
+			"data":    hasil,
+		})
+		return
+	}
+
+	if _, err := h.kurvaService.Terapkan(c.Request.Context(), jenis, id, claims.UserID, hasil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menerapkan kurva. Tidak ada nilai yang diubah."})
+		return
+	}
+	h.hitungUlangRekap(c, jenis, id)
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Kurva nilai berhasil diterapkan.",
+		"data":    hasil,
+	})
+}
+
+// GetKurva mengembalikan kurva yang sedang aktif beserta nilai mentah tiap siswa.
+func (h *kurvaNilaiHandler) GetKurva(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	kurva, err := h.kurvaRepo.GetAktif(c.Request.Context(), jenis, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tidak ada kurva aktif pada nilai ini."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil kurva nilai."})
+		return
+	}
+	nilai, err := h.kurvaRepo.GetNilaiMentah(c.Request.Context(), kurva.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil nilai mentah."})
+		return
+	}
+
+	baris := []gin.H{}
+	for _, item := range nilai {
+		baris = append(baris, gin.H{
+			"siswa_id":     item.SiswaID,
+			"nilai_mentah": item.NilaiMentah,
+			"nilai_kurva":  item.NilaiKurva,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil kurva nilai.",
+		"data": gin.H{
+			"metode":    kurva.Metode,
+			"parameter": kurva.Parameter,
+			"guru_id":   kurva.GuruID,
+			"created":   kurva.Created,
+			"baris":     baris,
+		},
+	})
+}
+
+// BatalkanKurva mengembalikan nilai mentah dan membatalkan kurva aktif. Nilai yang sudah diubah
+// setelah kurva diterapkan tidak ditimpa; nilai mentahnya tetap disimpan dan dilaporkan sebagai
+// tidak dipulihkan.
+func (h *kurvaNilaiHandler) BatalkanKurva(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+	if !h.pastikanItemAda(c, jenis, id) || !pastikanPengampu(c, h.nilaiService, jenis, id) {
+		return
+	}
+
+	dipulihkan, tidakDipulihkan, err := h.kurvaService.Kembalikan(c.Request.Context(), jenis, id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tidak ada kurva aktif pada nilai ini."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membatalkan kurva nilai."})
+		return
+	}
+	h.hitungUlangRekap(c, jenis, id)
+
+	baris := []gin.H{}
+	for _, item := range tidakDipulihkan {
+		baris = append(baris, gin.H{
+			"siswa_id":     item.SiswaID,
+			"nilai_mentah": item.NilaiMentah,
+			"nilai_kurva":  item.NilaiKurva,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Kurva nilai berhasil dibatalkan.",
+		"data": gin.H{
+			"jumlah_dipulihkan":       dipulihkan,
+			"jumlah_tidak_dipulihkan": len(tidakDipulihkan),
+			"tidak_dipulihkan":        baris,
+		},
+	})
+}
+
+func (h *kurvaNilaiHandler) pastikanItemAda(c *gin.Context, jenis string, id int) bool {
+	var err error
+	if jenis == "quiz" {
+		_, err = h.quizRepo.GetByID(c.Request.Context(), id)
+	} else {
+		_, err = h.tugasRepo.GetByID(c.Request.Context(), id)
+	}
+	if err != nil {
+		writeItemNotFound(c, err, labelJenis(jenis))
+		return false
+	}
+	return true
+}
+
+func (h *kurvaNilaiHandler) hitungUlangRekap(c *gin.Context, jenis string, id int) {
+	if err := h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), jenis, id); err != nil {
+		log.Printf("Gagal memproses perubahan nilai %s %d: %v", jenis, id, err)
+	}
+}
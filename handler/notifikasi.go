@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"be-pui/repositories"
+	"be-pui/utils"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type NotifikasiResponse struct {
+	ID      int       `json:"id"`
+	Judul   string    `json:"judul"`
+	Pesan   string    `json:"pesan"`
+	Dibaca  bool      `json:"dibaca"`
+	Created time.Time `json:"created"`
+}
+
+type notifikasiHandler struct {
+	notifikasiRepo repositories.NotifikasiRepository
+}
+
+func NewNotifikasiHandler(notifikasiRepo repositories.NotifikasiRepository) *notifikasiHandler {
+	return &notifikasiHandler{notifikasiRepo: notifikasiRepo}
+}
+
+// GetMyNotifikasi mengambil 100 notifikasi terbaru pengguna yang login; ?belum_dibaca=true hanya
+// menampilkan yang belum dibaca.
+func (h *notifikasiHandler) GetMyNotifikasi(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	notifikasi, err := h.notifikasiRepo.GetAllByUser(c.Request.Context(), claims.Role, claims.UserID, c.Query("belum_dibaca") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil notifikasi."})
+		return
+	}
+
+	response := []NotifikasiResponse{}
+	for _, item := range notifikasi {
+		response = append(response, NotifikasiResponse{
+			ID:      item.ID,
+			Judul:   item.Judul,
+			Pesan:   item.Pesan,
+			Dibaca:  item.Dibaca,
+			Created: item.Created,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil notifikasi.",
+		"data":    response,
+	})
+}
+
+func (h *notifikasiHandler) TandaiDibaca(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID notifikasi tidak valid."})
+		return
+	}
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	if err := h.notifikasiRepo.TandaiDibaca(c.Request.Context(), id, claims.Role, claims.UserID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Notifikasi tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui notifikasi."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Notifikasi ditandai sudah dibaca."})
+}
@@ -0,0 +1,193 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type OrangTuaCreateRequest struct {
+	Nama     string `json:"nama" binding:"required"`
+	Email    string `json:"email" binding:"required,email"`
+	Password string `json:"password" binding:"required,min=6"`
+	NoHp     string `json:"no_hp" binding:"required"`
+	SiswaIDs []int  `json:"siswa_ids" binding:"required,min=1"`
+}
+
+type HubungkanSiswaRequest struct {
+	SiswaID int `json:"siswa_id" binding:"required"`
+}
+
+type AnakResponse struct {
+	ID      int    `json:"id"`
+	Nama    string `json:"nama"`
+	KelasID *int   `json:"kelas_id"`
+}
+
+type orangTuaHandler struct {
+	orangTuaRepo repositories.OrangTuaRepository
+	jwtUtil      *utils.JWTUtil
+}
+
+func NewOrangTuaHandler(orangTuaRepo repositories.OrangTuaRepository, jwtUtil *utils.JWTUtil) *orangTuaHandler {
+	return &orangTuaHandler{
+		orangTuaRepo: orangTuaRepo,
+		jwtUtil:      jwtUtil,
+	}
+}
+
+// CreateOrangTua membuat akun orang tua dan menghubungkannya ke siswa yang disebutkan.
+func (h *orangTuaHandler) CreateOrangTua(c *gin.Context) {
+	var req OrangTuaCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "nama, email, password (min. 6 karakter), no_hp, dan siswa_ids wajib diisi."})
+		return
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memproses password."})
+		return
+	}
+
+	orangTua := models.OrangTua{
+		Nama:     req.Nama,
+		Email:    req.Email,
+		Password: string(hashedPassword),
+		NoHp:     req.NoHp,
+	}
+	if err := h.orangTuaRepo.Create(c.Request.Context(), &orangTua, req.SiswaIDs); err != nil {
+		writeOrangTuaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Akun orang tua berhasil dibuat.",
+		"data":    gin.H{"id": orangTua.ID},
+	})
+}
+
+func (h *orangTuaHandler) HubungkanSiswa(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID orang tua tidak valid."})
+		return
+	}
+
+	var req HubungkanSiswaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "siswa_id wajib diisi."})
+		return
+	}
+
+	if err := h.orangTuaRepo.HubungkanSiswa(c.Request.Context(), id, req.SiswaID); err != nil {
+		writeOrangTuaError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Siswa berhasil dihubungkan ke akun orang tua."})
+}
+
+func (h *orangTuaHandler) LoginOrangTua(c *gin.Context) {
+	var req LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Email atau password tidak valid."})
+		return
+	}
+
+	orangTua, err := h.orangTuaRepo.GetByEmail(c.Request.Context(), req.Email)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Kombinasi email dan password salah."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Terjadi kesalahan pada server."})
+		return
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(orangTua.Password), []byte(req.Password)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Kombinasi email dan password salah."})
+		return
+	}
+
+	token, err := h.jwtUtil.GenerateJWTToken(orangTua)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuat token."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Login berhasil",
+		"data": gin.H{
+			"token": token,
+		},
+	})
+}
+
+// GetProfileOrangTua mengambil profil orang tua yang login beserta daftar anaknya.
+func (h *orangTuaHandler) GetProfileOrangTua(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	orangTua, err := h.orangTuaRepo.GetByID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Profil orang tua tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil profil orang tua."})
+		return
+	}
+
+	anak, err := h.orangTuaRepo.GetAnak(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data anak."})
+		return
+	}
+
+	daftarAnak := []AnakResponse{}
+	for _, item := range anak {
+		daftarAnak = append(daftarAnak, AnakResponse{ID: item.ID, Nama: item.Nama, KelasID: item.KelasID})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil profil orang tua.",
+		"data": gin.H{
+			"id":      orangTua.ID,
+			"nama":    orangTua.Nama,
+			"email":   orangTua.Email,
+			"no_hp":   orangTua.NoHp,
+			"anak":    daftarAnak,
+			"created": orangTua.Created,
+		},
+	})
+}
+
+func writeOrangTuaError(c *gin.Context, err error) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Email yang Anda masukkan sudah terdaftar."})
+			return
+		case "23503":
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Siswa atau orang tua dengan ID yang diberikan tidak ditemukan."})
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Terjadi kesalahan pada server kami."})
+}
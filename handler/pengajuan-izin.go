@@ -0,0 +1,353 @@
+package handler
+
+import (
+	"be-pui/config"
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// direktoriSuratDokter adalah tempat surat dokter disimpan. Sengaja di luar ./uploads agar tidak ikut
+// tersaji lewat /static; berkasnya hanya dapat diunduh melalui GetSuratDokter.
+const direktoriSuratDokter = "./berkas/surat_izin/"
+
+// ekstensiSuratDokter adalah format berkas surat dokter yang diterima.
+var ekstensiSuratDokter = map[string]bool{".pdf": true, ".jpg": true, ".jpeg": true, ".png": true}
+
+type ProsesPengajuanIzinRequest struct {
+	Tanggapan *string `json:"tanggapan"`
+}
+
+type PengajuanIzinResponse struct {
+	ID             int        `json:"id"`
+	SiswaID        int        `json:"siswa_id"`
+	NamaSiswa      string     `json:"nama_siswa"`
+	KelasID        *int       `json:"kelas_id"`
+	NamaKelas      *string    `json:"nama_kelas"`
+	Jenis          string     `json:"jenis"`
+	TanggalMulai   string     `json:"tanggal_mulai"`
+	TanggalSelesai string     `json:"tanggal_selesai"`
+	Alasan         string     `json:"alasan"`
+	SuratDokterUrl *string    `json:"surat_dokter_url"`
+	DiajukanRole   string     `json:"diajukan_role"`
+	Status         string     `json:"status"`
+	Tanggapan      *string    `json:"tanggapan,omitempty"`
+	Diproses       *time.Time `json:"diproses,omitempty"`
+	Created        time.Time  `json:"created"`
+}
+
+type pengajuanIzinHandler struct {
+	pengajuanRepo    repositories.PengajuanIzinRepository
+	pengajuanService *services.PengajuanIzinService
+	cfg              *config.Config
+}
+
+func NewPengajuanIzinHandler(
+	pengajuanRepo repositories.PengajuanIzinRepository,
+	pengajuanService *services.PengajuanIzinService,
+	cfg *config.Config,
+) *pengajuanIzinHandler {
+	return &pengajuanIzinHandler{
+		pengajuanRepo:    pengajuanRepo,
+		pengajuanService: pengajuanService,
+		cfg:              cfg,
+	}
+}
+
+// AjukanIzin menerima form multipart berisi jenis (izin/sakit), tanggal_mulai, tanggal_selesai,
+// alasan, dan surat_dokter opsional. Orang tua wajib mengisi siswa_id; siswa selalu mengajukan
+// untuk dirinya sendiri.
+func (h *pengajuanIzinHandler) AjukanIzin(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	siswaID := claims.UserID
+	if claims.Role == "orang tua" {
+		var err error
+		if siswaID, err = strconv.Atoi(c.PostForm("siswa_id")); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "siswa_id wajib diisi."})
+			return
+		}
+	}
+
+	jenis := c.PostForm("jenis")
+	alasan := strings.TrimSpace(c.PostForm("alasan"))
+	if jenis == "" || alasan == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "jenis, tanggal_mulai, tanggal_selesai, dan alasan wajib diisi."})
+		return
+	}
+	mulai, selesai, ok := parseRentangTanggal(c, c.PostForm("tanggal_mulai"), c.PostForm("tanggal_selesai"))
+	if !ok {
+		return
+	}
+
+	pengajuan := models.PengajuanIzin{
+		SiswaID:        siswaID,
+		Jenis:          jenis,
+		TanggalMulai:   mulai,
+		TanggalSelesai: selesai,
+		Alasan:         alasan,
+		DiajukanRole:   claims.Role,
+		DiajukanID:     claims.UserID,
+	}
+
+	var dst string
+	if file, err := c.FormFile("surat_dokter"); err == nil {
+		ext := strings.ToLower(filepath.Ext(file.Filename))
+		if !ekstensiSuratDokter[ext] {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Surat dokter harus berupa PDF, JPG, atau PNG."})
+			return
+		}
+		uniqueFilename := fmt.Sprintf("izin-siswa-%d-%d%s", siswaID, time.Now().UnixNano(), ext)
+		dst = filepath.Join(direktoriSuratDokter, uniqueFilename)
+		if err := c.SaveUploadedFile(file, dst); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan surat dokter."})
+			return
+		}
+		// Hanya nama berkas yang disimpan; URL unduhannya disusun per pengajuan di respons.
+		pengajuan.SuratDokterUrl = &uniqueFilename
+	}
+
+	if err := h.pengajuanService.Ajukan(c.Request.Context(), &pengajuan); err != nil {
+		if dst != "" {
+			os.Remove(dst)
+		}
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Siswa tidak ditemukan."})
+		case errors.Is(err, services.ErrPengajuanIzin):
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": err.Error()})
+		case errors.Is(err, services.ErrAksesPengajuanIzin):
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda tidak dapat mengajukan izin untuk siswa ini."})
+		case errors.Is(err, repositories.ErrPengajuanTumpang):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Sudah ada pengajuan izin yang menunggu atau disetujui pada sebagian tanggal tersebut."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengajukan izin."})
+		}
+		return
+	}
+
+	detail, err := h.pengajuanRepo.GetByID(c.Request.Context(), pengajuan.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Pengajuan tersimpan, tetapi gagal mengambil datanya."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Pengajuan izin berhasil dikirim ke wali kelas.",
+		"data":    toPengajuanIzinResponse(*detail, h.cfg.Server.BaseURL),
+	})
+}
+
+// GetDaftarPengajuan mengambil pengajuan milik siswa yang login, anak-anak orang tua yang login,
+// atau siswa di kelas perwalian guru yang login (dapat disaring dengan ?status=).
+func (h *pengajuanIzinHandler) GetDaftarPengajuan(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	var daftar []repositories.PengajuanIzinDetail
+	var err error
+	switch claims.Role {
+	case "siswa":
+		daftar, err = h.pengajuanRepo.GetAllBySiswaID(c.Request.Context(), claims.UserID)
+	case "orang tua":
+		daftar, err = h.pengajuanRepo.GetAllByOrangTuaID(c.Request.Context(), claims.UserID)
+	default:
+		daftar, err = h.pengajuanRepo.GetAllByWaliKelas(c.Request.Context(), claims.UserID, c.Query("status"))
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar pengajuan izin."})
+		return
+	}
+
+	response := []PengajuanIzinResponse{}
+	for _, item := range daftar {
+		response = append(response, toPengajuanIzinResponse(item, h.cfg.Server.BaseURL))
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil daftar pengajuan izin.",
+		"data":    response,
+	})
+}
+
+func (h *pengajuanIzinHandler) GetPengajuan(c *gin.Context) {
+	pengajuan, ok := h.getPengajuanTerlihat(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil pengajuan izin.",
+		"data":    toPengajuanIzinResponse(*pengajuan, h.cfg.Server.BaseURL),
+	})
+}
+
+// GetSuratDokter mengirim surat dokter suatu pengajuan kepada pengguna yang boleh melihat
+// pengajuan tersebut.
+func (h *pengajuanIzinHandler) GetSuratDokter(c *gin.Context) {
+	pengajuan, ok := h.getPengajuanTerlihat(c)
+	if !ok {
+		return
+	}
+	if pengajuan.SuratDokterUrl == nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pengajuan ini tidak memiliki surat dokter."})
+		return
+	}
+
+	namaFile := filepath.Base(*pengajuan.SuratDokterUrl)
+	path := filepath.Join(direktoriSuratDokter, namaFile)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "File surat dokter tidak ditemukan."})
+		return
+	}
+	c.FileAttachment(path, fmt.Sprintf("surat-dokter-%d%s", pengajuan.ID, filepath.Ext(namaFile)))
+}
+
+// getPengajuanTerlihat mengambil pengajuan dari parameter :id dan memastikan pengguna boleh
+// melihatnya. Jika tidak, respons galat sudah ditulis dan ok bernilai false.
+func (h *pengajuanIzinHandler) getPengajuanTerlihat(c *gin.Context) (*repositories.PengajuanIzinDetail, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID pengajuan tidak valid."})
+		return nil, false
+	}
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return nil, false
+	}
+
+	pengajuan, err := h.pengajuanRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pengajuan izin tidak ditemukan."})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil pengajuan izin."})
+		return nil, false
+	}
+
+	boleh, err := h.pengajuanService.BolehMelihat(c.Request.Context(), pengajuan, claims.Role, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa akses pengajuan izin."})
+		return nil, false
+	}
+	if !boleh {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pengajuan izin tidak ditemukan."})
+		return nil, false
+	}
+	return pengajuan, true
+}
+
+// SetujuiPengajuan menyetujui pengajuan; absensi siswa pada rentang tersebut langsung diubah menjadi
+// izin/sakit, kecuali yang sudah tercatat hadir.
+func (h *pengajuanIzinHandler) SetujuiPengajuan(c *gin.Context) {
+	h.prosesPengajuan(c, true)
+}
+
+func (h *pengajuanIzinHandler) TolakPengajuan(c *gin.Context) {
+	h.prosesPengajuan(c, false)
+}
+
+func (h *pengajuanIzinHandler) prosesPengajuan(c *gin.Context, setuju bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID pengajuan tidak valid."})
+		return
+	}
+
+	var req ProsesPengajuanIzinRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format request tidak valid."})
+			return
+		}
+	}
+	if !setuju && (req.Tanggapan == nil || strings.TrimSpace(*req.Tanggapan) == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tanggapan wajib diisi saat menolak pengajuan."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	pengajuan, diterapkan, err := h.pengajuanService.Proses(c.Request.Context(), id, claims.UserID, setuju, req.Tanggapan)
+	if err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Pengajuan izin tidak ditemukan."})
+		case errors.Is(err, services.ErrAksesPengajuanIzin):
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Hanya wali kelas siswa yang dapat memproses pengajuan ini."})
+		case errors.Is(err, repositories.ErrPengajuanSudahDiproses):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Pengajuan ini sudah diproses."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memproses pengajuan izin."})
+		}
+		return
+	}
+
+	pesan := "Pengajuan izin ditolak."
+	if setuju {
+		pesan = "Pengajuan izin disetujui."
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": pesan,
+		"data": gin.H{
+			"pengajuan":          toPengajuanIzinResponse(*pengajuan, h.cfg.Server.BaseURL),
+			"absensi_diperbarui": diterapkan,
+		},
+	})
+}
+
+// toPengajuanIzinResponse menyusun respons pengajuan; surat dokter hanya diberikan sebagai URL
+// GetSuratDokter yang memeriksa hak akses.
+func toPengajuanIzinResponse(item repositories.PengajuanIzinDetail, baseURL string) PengajuanIzinResponse {
+	var suratDokterURL *string
+	if item.SuratDokterUrl != nil {
+		fileURL := fmt.Sprintf("%s/api/v1/pengajuan-izin/%d/surat-dokter", baseURL, item.ID)
+		suratDokterURL = &fileURL
+	}
+	return PengajuanIzinResponse{
+		ID:             item.ID,
+		SiswaID:        item.SiswaID,
+		NamaSiswa:      item.NamaSiswa,
+		KelasID:        item.KelasID,
+		NamaKelas:      item.NamaKelas,
+		Jenis:          item.Jenis,
+		TanggalMulai:   item.TanggalMulai.Format("2006-01-02"),
+		TanggalSelesai: item.TanggalSelesai.Format("2006-01-02"),
+		Alasan:         item.Alasan,
+		SuratDokterUrl: suratDokterURL,
+		DiajukanRole:   item.DiajukanRole,
+		Status:         item.Status,
+		Tanggapan:      item.Tanggapan,
+		Diproses:       item.Diproses,
+		Created:        item.Created,
+	}
+}
@@ -0,0 +1,634 @@
+package handler
+
+import (
+	"be-pui/config"
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type RubrikInput struct {
+	Kriteria             string  `json:"kriteria" binding:"required"`
+	Deskripsi            string  `json:"deskripsi"`
+	Bobot                float64 `json:"bobot" binding:"required,gt=0"`
+	TujuanPembelajaranID *int    `json:"tujuan_pembelajaran_id"`
+}
+
+type SimpanRubrikRequest struct {
+	Rubrik []RubrikInput `json:"rubrik" binding:"required,min=1,dive"`
+}
+
+type AturPeerReviewRequest struct {
+	Aktif         bool    `json:"aktif"`
+	JumlahPenilai int     `json:"jumlah_penilai" binding:"gte=0"`
+	Bobot         float64 `json:"bobot" binding:"gte=0,lte=100"`
+}
+
+type SkorRubrikInput struct {
+	RubrikID int     `json:"rubrik_id" binding:"required"`
+	Skor     float64 `json:"skor" binding:"gte=0,lte=100"`
+}
+
+type SubmitPeerReviewRequest struct {
+	Skor     []SkorRubrikInput `json:"skor" binding:"required,min=1,dive"`
+	Komentar *string           `json:"komentar"`
+}
+
+type RubrikResponse struct {
+	ID                   int     `json:"id"`
+	TugasID              int     `json:"tugas_id"`
+	Kriteria             string  `json:"kriteria"`
+	Deskripsi            string  `json:"deskripsi,omitempty"`
+	Bobot                float64 `json:"bobot"`
+	TujuanPembelajaranID *int    `json:"tujuan_pembelajaran_id,omitempty"`
+}
+
+type PeerReviewSiswaResponse struct {
+	ID             int       `json:"id"`
+	TugasID        int       `json:"tugas_id"`
+	JudulTugas     string    `json:"judul_tugas"`
+	FileJawabanUrl *string   `json:"file_jawaban_url,omitempty"`
+	Nilai          *float64  `json:"nilai,omitempty"`
+	Komentar       *string   `json:"komentar,omitempty"`
+	Status         string    `json:"status"`
+	Updated        time.Time `json:"updated"`
+}
+
+type KomentarSejawatResponse struct {
+	PenilaiID   int      `json:"penilai_id"`
+	NamaPenilai string   `json:"nama_penilai"`
+	Nilai       *float64 `json:"nilai,omitempty"`
+	Komentar    *string  `json:"komentar,omitempty"`
+	Status      string   `json:"status"`
+}
+
+type RekapPeerReviewResponse struct {
+	HasilTugasID    int                       `json:"hasil_tugas_id"`
+	SiswaID         int                       `json:"siswa_id"`
+	NamaSiswa       string                    `json:"nama_siswa"`
+	NilaiGuru       *float64                  `json:"nilai_guru,omitempty"`
+	RataRataSejawat *float64                  `json:"rata_rata_sejawat,omitempty"`
+	NilaiGabungan   *float64                  `json:"nilai_gabungan,omitempty"`
+	JumlahSelesai   int                       `json:"jumlah_selesai"`
+	JumlahPenilai   int                       `json:"jumlah_penilai"`
+	Penilaian       []KomentarSejawatResponse `json:"penilaian"`
+}
+
+type penilaianSejawatHandler struct {
+	penilaianRepo  repositories.PenilaianSejawatRepository
+	tugasRepo      repositories.TugasRepository
+	hasilTugasRepo repositories.HasilTugasRepository
+	nilaiService   *services.NilaiService
+	cfg            *config.Config
+}
+
+func NewPenilaianSejawatHandler(
+	penilaianRepo repositories.PenilaianSejawatRepository,
+	tugasRepo repositories.TugasRepository,
+	hasilTugasRepo repositories.HasilTugasRepository,
+	nilaiService *services.NilaiService,
+	cfg *config.Config,
+) *penilaianSejawatHandler {
+	return &penilaianSejawatHandler{
+		penilaianRepo:  penilaianRepo,
+		tugasRepo:      tugasRepo,
+		hasilTugasRepo: hasilTugasRepo,
+		nilaiService:   nilaiService,
+		cfg:            cfg,
+	}
+}
+
+func (h *penilaianSejawatHandler) SimpanRubrik(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	var req SimpanRubrikRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Rubrik wajib berisi minimal satu kriteria dengan bobot lebih dari 0."})
+		return
+	}
+
+	if _, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+
+	var rubrik []models.RubrikTugas
+	for _, input := range req.Rubrik {
+		rubrik = append(rubrik, models.RubrikTugas{
+			Kriteria:             input.Kriteria,
+			Deskripsi:            input.Deskripsi,
+			Bobot:                input.Bobot,
+			TujuanPembelajaranID: input.TujuanPembelajaranID,
+		})
+	}
+
+	if err := h.penilaianRepo.ReplaceRubrik(c.Request.Context(), tugasID, rubrik); err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tujuan pembelajaran dengan ID yang diberikan tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan rubrik."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Rubrik tugas berhasil disimpan."})
+}
+
+func (h *penilaianSejawatHandler) GetRubrik(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	rubrik, err := h.penilaianRepo.GetRubrikByTugasID(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rubrik tugas."})
+		return
+	}
+
+	var response []RubrikResponse
+	for _, item := range rubrik {
+		response = append(response, RubrikResponse{
+			ID:                   item.ID,
+			TugasID:              item.TugasID,
+			Kriteria:             item.Kriteria,
+			Deskripsi:            item.Deskripsi,
+			Bobot:                item.Bobot,
+			TujuanPembelajaranID: item.TujuanPembelajaranID,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil rubrik tugas.",
+		"data":    response,
+	})
+}
+
+// AturPeerReview mengaktifkan atau menonaktifkan penilaian sejawat pada tugas.
+func (h *penilaianSejawatHandler) AturPeerReview(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	var req AturPeerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Request body tidak valid."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+
+	if req.Aktif {
+		if tugas.IsKelompok {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Penilaian sejawat belum didukung untuk tugas kelompok."})
+			return
+		}
+		if req.JumlahPenilai < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Jumlah penilai minimal 1."})
+			return
+		}
+
+		rubrik, err := h.penilaianRepo.GetRubrikByTugasID(c.Request.Context(), tugasID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rubrik tugas."})
+			return
+		}
+		if len(rubrik) == 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tugas harus memiliki rubrik sebelum penilaian sejawat diaktifkan."})
+			return
+		}
+	}
+
+	tugas.PeerReview = req.Aktif
+	tugas.JumlahPenilai = req.JumlahPenilai
+	tugas.BobotPeerReview = req.Bobot
+
+	if err := h.tugasRepo.Update(c.Request.Context(), tugas); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan pengaturan penilaian sejawat."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Pengaturan penilaian sejawat berhasil disimpan."})
+}
+
+// DistribusiPeerReview membagikan setiap jawaban secara anonim kepada N teman sekelas setelah deadline.
+func (h *penilaianSejawatHandler) DistribusiPeerReview(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+
+	if !tugas.PeerReview {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Penilaian sejawat belum diaktifkan untuk tugas ini."})
+		return
+	}
+	if time.Now().Before(tugas.Deadline) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Penilaian sejawat baru dapat dibagikan setelah deadline."})
+		return
+	}
+
+	jumlah, err := h.penilaianRepo.CountByTugasID(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa distribusi penilaian sejawat."})
+		return
+	}
+	if jumlah > 0 {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Penilaian sejawat untuk tugas ini sudah dibagikan."})
+		return
+	}
+
+	hasilTugas, err := h.hasilTugasRepo.GetAllByTugasID(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil hasil tugas."})
+		return
+	}
+	if len(hasilTugas) <= tugas.JumlahPenilai {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"message": fmt.Sprintf("Jumlah pengumpulan (%d) harus lebih banyak dari jumlah penilai per jawaban (%d).", len(hasilTugas), tugas.JumlahPenilai),
+		})
+		return
+	}
+
+	var pengumpulan []models.HasilTugas
+	for _, hasil := range hasilTugas {
+		pengumpulan = append(pengumpulan, hasil.HasilTugas)
+	}
+
+	penilaian := distribusiPenilaiSejawat(pengumpulan, tugas.JumlahPenilai)
+	if err := h.penilaianRepo.CreateBatch(c.Request.Context(), penilaian); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan distribusi penilaian sejawat."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Penilaian sejawat berhasil dibagikan.",
+		"data":    gin.H{"jumlah_penilaian": len(penilaian)},
+	})
+}
+
+func (h *penilaianSejawatHandler) GetRekapPeerReview(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+
+	rekap, err := h.penilaianRepo.GetRekapByTugasID(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rekap penilaian sejawat."})
+		return
+	}
+
+	detail, err := h.penilaianRepo.GetAllByTugasID(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil detail penilaian sejawat."})
+		return
+	}
+
+	detailMap := make(map[int][]KomentarSejawatResponse)
+	for _, item := range detail {
+		detailMap[item.HasilTugasID] = append(detailMap[item.HasilTugasID], KomentarSejawatResponse{
+			PenilaiID:   item.PenilaiID,
+			NamaPenilai: item.NamaPenilai,
+			Nilai:       item.Nilai,
+			Komentar:    item.Komentar,
+			Status:      item.Status,
+		})
+	}
+
+	var response []RekapPeerReviewResponse
+	for _, item := range rekap {
+		rekapItem := RekapPeerReviewResponse{
+			HasilTugasID:    item.HasilTugasID,
+			SiswaID:         item.SiswaID,
+			NamaSiswa:       item.NamaSiswa,
+			NilaiGuru:       item.NilaiGuru,
+			RataRataSejawat: item.RataRataSejawat,
+			JumlahSelesai:   item.JumlahSelesai,
+			JumlahPenilai:   item.JumlahPenilai,
+			Penilaian:       detailMap[item.HasilTugasID],
+		}
+		if item.NilaiGuru != nil && item.RataRataSejawat != nil && !tugas.PeerReviewDiterapkan {
+			gabungan := (1-tugas.BobotPeerReview/100)*(*item.NilaiGuru) + (tugas.BobotPeerReview/100)*(*item.RataRataSejawat)
+			rekapItem.NilaiGabungan = &gabungan
+		}
+		response = append(response, rekapItem)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil rekap penilaian sejawat.",
+		"data":    response,
+	})
+}
+
+// TerapkanPeerReview memasukkan rata-rata nilai sejawat ke Nilai hasil tugas sesuai bobot tugas.
+func (h *penilaianSejawatHandler) TerapkanPeerReview(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+
+	if !tugas.PeerReview {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Penilaian sejawat belum diaktifkan untuk tugas ini."})
+		return
+	}
+	if tugas.PeerReviewDiterapkan {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Nilai sejawat sudah diterapkan untuk tugas ini."})
+		return
+	}
+
+	if err := h.penilaianRepo.ApplyToNilai(c.Request.Context(), tugasID, tugas.BobotPeerReview); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menerapkan nilai sejawat."})
+		return
+	}
+
+	if err := h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), "tugas", tugasID); err != nil {
+		log.Printf("Gagal memproses perubahan nilai tugas %d: %v", tugasID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Nilai sejawat berhasil diterapkan ke nilai tugas."})
+}
+
+// BatalkanPeerReview mengembalikan nilai guru sebelum nilai sejawat diterapkan dan membuka kembali
+// penilaian sejawat tugas tersebut.
+func (h *penilaianSejawatHandler) BatalkanPeerReview(c *gin.Context) {
+	tugasID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+	if !tugas.PeerReviewDiterapkan {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Nilai sejawat belum diterapkan untuk tugas ini."})
+		return
+	}
+
+	dikembalikan, err := h.penilaianRepo.BatalkanNilai(c.Request.Context(), tugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membatalkan nilai sejawat."})
+		return
+	}
+
+	if err := h.nilaiService.SetelahNilaiBerubah(c.Request.Context(), "tugas", tugasID); err != nil {
+		log.Printf("Gagal memproses perubahan nilai tugas %d: %v", tugasID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Nilai sejawat berhasil dibatalkan.",
+		"data":    gin.H{"jumlah_dikembalikan": dikembalikan},
+	})
+}
+
+func (h *penilaianSejawatHandler) GetMyPeerReview(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	penilaian, err := h.penilaianRepo.GetAllByPenilaiID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar penilaian sejawat."})
+		return
+	}
+
+	var response []PeerReviewSiswaResponse
+	for _, item := range penilaian {
+		reviewResponse := PeerReviewSiswaResponse{
+			ID:         item.ID,
+			TugasID:    item.TugasID,
+			JudulTugas: item.JudulTugas,
+			Nilai:      item.Nilai,
+			Komentar:   item.Komentar,
+			Status:     item.Status,
+			Updated:    item.Updated,
+		}
+		if item.FileJawabanUrl != nil {
+			// URL asli memuat ID siswa pemilik jawaban, jadi penilai hanya diberi URL per penugasan.
+			fileURL := fmt.Sprintf("%s/api/v1/siswas/peer-review/%d/file", h.cfg.Server.BaseURL, item.ID)
+			reviewResponse.FileJawabanUrl = &fileURL
+		}
+		response = append(response, reviewResponse)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil daftar penilaian sejawat.",
+		"data":    response,
+	})
+}
+
+// GetFilePeerReview mengirim file jawaban yang harus dinilai kepada siswa penilainya dengan nama
+// file netral, sehingga identitas pemilik jawaban tidak terlihat.
+func (h *penilaianSejawatHandler) GetFilePeerReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID penilaian tidak valid."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	penilaian, err := h.penilaianRepo.GetByID(c.Request.Context(), id)
+	if err != nil || penilaian.PenilaiID != claims.UserID {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Penilaian sejawat tidak ditemukan."})
+		return
+	}
+
+	hasil, err := h.hasilTugasRepo.GetByID(c.Request.Context(), penilaian.HasilTugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil hasil tugas."})
+		return
+	}
+	if hasil.FileJawabanUrl == nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Jawaban ini tidak memiliki file."})
+		return
+	}
+
+	namaFile := filepath.Base(*hasil.FileJawabanUrl)
+	path := filepath.Join("./uploads/jawaban_tugas/", namaFile)
+	if _, err := os.Stat(path); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "File jawaban tidak ditemukan."})
+		return
+	}
+	c.FileAttachment(path, fmt.Sprintf("jawaban-%d%s", penilaian.ID, filepath.Ext(namaFile)))
+}
+
+// SubmitPeerReview menyimpan skor rubrik dan komentar dari siswa penilai.
+func (h *penilaianSejawatHandler) SubmitPeerReview(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID penilaian tidak valid."})
+		return
+	}
+
+	var req SubmitPeerReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Skor rubrik wajib diisi dengan angka 0 sampai 100."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	penilaian, err := h.penilaianRepo.GetByID(c.Request.Context(), id)
+	if err != nil || penilaian.PenilaiID != claims.UserID {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Penilaian sejawat tidak ditemukan."})
+		return
+	}
+
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), penilaian.TugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
+		return
+	}
+	if tugas.PeerReviewDiterapkan {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Penilaian sejawat untuk tugas ini sudah ditutup."})
+		return
+	}
+
+	rubrik, err := h.penilaianRepo.GetRubrikByTugasID(c.Request.Context(), penilaian.TugasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rubrik tugas."})
+		return
+	}
+
+	skorMap := make(map[int]float64)
+	for _, input := range req.Skor {
+		skorMap[input.RubrikID] = input.Skor
+	}
+
+	var skor []models.SkorRubrikSejawat
+	var totalSkor, totalBobot float64
+	for _, kriteria := range rubrik {
+		nilaiKriteria, found := skorMap[kriteria.ID]
+		if !found {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Skor untuk kriteria '%s' wajib diisi.", kriteria.Kriteria)})
+			return
+		}
+		skor = append(skor, models.SkorRubrikSejawat{PenilaianID: id, RubrikID: kriteria.ID, Skor: nilaiKriteria})
+		totalSkor += nilaiKriteria * kriteria.Bobot
+		totalBobot += kriteria.Bobot
+	}
+	if totalBobot == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tugas ini tidak memiliki rubrik."})
+		return
+	}
+
+	if err := h.penilaianRepo.Submit(c.Request.Context(), id, totalSkor/totalBobot, req.Komentar, skor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan penilaian sejawat."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Penilaian sejawat berhasil disimpan."})
+}
+
+// distribusiPenilaiSejawat mengacak urutan pengumpulan lalu menugaskan setiap siswa menilai
+// n pengumpulan berikutnya secara melingkar. Karena n < jumlah pengumpulan, tidak ada siswa
+// yang menilai jawabannya sendiri, dan setiap siswa maupun jawaban mendapat tepat n penilaian.
+func distribusiPenilaiSejawat(pengumpulan []models.HasilTugas, n int) []models.PenilaianSejawat {
+	acak := make([]models.HasilTugas, len(pengumpulan))
+	copy(acak, pengumpulan)
+	rand.Shuffle(len(acak), func(i, j int) { acak[i], acak[j] = acak[j], acak[i] })
+
+	var penilaian []models.PenilaianSejawat
+	for i, penilai := range acak {
+		for k := 1; k <= n; k++ {
+			dinilai := acak[(i+k)%len(acak)]
+			penilaian = append(penilaian, models.PenilaianSejawat{
+				TugasID:      dinilai.TugasID,
+				HasilTugasID: dinilai.ID,
+				PenilaiID:    penilai.SiswaID,
+				Status:       "menunggu",
+			})
+		}
+	}
+	return penilaian
+}
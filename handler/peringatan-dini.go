@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"be-pui/services"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type peringatanDiniHandler struct {
+	peringatanService *services.PeringatanDiniService
+}
+
+func NewPeringatanDiniHandler(peringatanService *services.PeringatanDiniService) *peringatanDiniHandler {
+	return &peringatanDiniHandler{peringatanService: peringatanService}
+}
+
+// GetSiswaBerisiko mengembalikan daftar siswa terurut dari skor risiko tertinggi.
+// Query kelas_id (opsional) membatasi ke satu kelas, query level (opsional) menyaring tinggi/sedang/rendah.
+func (h *peringatanDiniHandler) GetSiswaBerisiko(c *gin.Context) {
+	kelasID := 0
+	if value := c.Query("kelas_id"); value != "" {
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'kelas_id' tidak valid."})
+			return
+		}
+		kelasID = id
+	}
+
+	level := c.Query("level")
+	if level != "" && level != "tinggi" && level != "sedang" && level != "rendah" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Level harus 'tinggi', 'sedang', atau 'rendah'."})
+		return
+	}
+
+	daftar, err := h.peringatanService.GetDaftarRisiko(c.Request.Context(), kelasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun laporan siswa berisiko."})
+		return
+	}
+
+	response := []services.RisikoSiswa{}
+	for _, item := range daftar {
+		if level == "" || item.Level == level {
+			response = append(response, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil laporan siswa berisiko.",
+		"data":    response,
+	})
+}
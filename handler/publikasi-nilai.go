@@ -0,0 +1,285 @@
+package handler
+
+import (
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TerbitkanNilaiRequest struct {
+	PerluReview bool `json:"perlu_review"`
+}
+
+type KembalikanNilaiRequest struct {
+	Catatan string `json:"catatan" binding:"required"`
+}
+
+type NilaiMenungguReviewResponse struct {
+	Jenis           string    `json:"jenis"`
+	ID              int       `json:"id"`
+	Judul           string    `json:"judul"`
+	KelasID         int       `json:"kelas_id"`
+	NamaKelas       string    `json:"nama_kelas"`
+	MataPelajaranID int       `json:"mata_pelajaran_id"`
+	Updated         time.Time `json:"updated"`
+}
+
+type publikasiNilaiHandler struct {
+	publikasiRepo repositories.PublikasiNilaiRepository
+	tugasRepo     repositories.TugasRepository
+	quizRepo      repositories.QuizRepository
+	kelasRepo     repositories.KelasRepository
+	rekapService  *services.RekapNilaiService
+}
+
+func NewPublikasiNilaiHandler(
+	publikasiRepo repositories.PublikasiNilaiRepository,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+	kelasRepo repositories.KelasRepository,
+	rekapService *services.RekapNilaiService,
+) *publikasiNilaiHandler {
+	return &publikasiNilaiHandler{
+		publikasiRepo: publikasiRepo,
+		tugasRepo:     tugasRepo,
+		quizRepo:      quizRepo,
+		kelasRepo:     kelasRepo,
+		rekapService:  rekapService,
+	}
+}
+
+// TerbitkanNilai merilis nilai tugas/quiz ke siswa, atau mengirimkannya dulu ke wali kelas jika perlu_review.
+// Body boleh kosong; tanpa body nilai langsung diterbitkan.
+func (h *publikasiNilaiHandler) TerbitkanNilai(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	var req TerbitkanNilaiRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Request body tidak valid."})
+			return
+		}
+	}
+
+	_, status, ok := h.getKelasAndStatus(c, jenis, id)
+	if !ok {
+		return
+	}
+	if status != "" && status != "draft" {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Nilai sudah diterbitkan atau sedang direview wali kelas."})
+		return
+	}
+
+	statusBaru := "terbit"
+	message := "Nilai berhasil diterbitkan ke siswa."
+	if req.PerluReview {
+		statusBaru = "review"
+		message = "Nilai dikirim ke wali kelas untuk direview."
+	}
+
+	if err := h.publikasiRepo.UpdateStatus(c.Request.Context(), jenis, id, statusBaru, nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui status nilai."})
+		return
+	}
+	if statusBaru == "terbit" {
+		h.hitungUlangRekap(c, jenis, id)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": message})
+}
+
+// SetujuiNilai dipakai wali kelas untuk menerbitkan nilai yang sedang direview.
+func (h *publikasiNilaiHandler) SetujuiNilai(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	if !h.checkWaliKelasReview(c, jenis, id) {
+		return
+	}
+
+	if err := h.publikasiRepo.UpdateStatus(c.Request.Context(), jenis, id, "terbit", nil); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui status nilai."})
+		return
+	}
+	h.hitungUlangRekap(c, jenis, id)
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Nilai disetujui dan diterbitkan ke siswa."})
+}
+
+// KembalikanNilai dipakai wali kelas untuk mengembalikan nilai ke guru mapel beserta catatan.
+func (h *publikasiNilaiHandler) KembalikanNilai(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	var req KembalikanNilaiRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Catatan wajib diisi saat mengembalikan nilai."})
+		return
+	}
+
+	if !h.checkWaliKelasReview(c, jenis, id) {
+		return
+	}
+
+	if err := h.publikasiRepo.UpdateStatus(c.Request.Context(), jenis, id, "draft", &req.Catatan); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memperbarui status nilai."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Nilai dikembalikan ke guru mata pelajaran."})
+}
+
+func (h *publikasiNilaiHandler) GetMenungguReview(c *gin.Context) {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	items, err := h.publikasiRepo.GetMenungguByWaliKelasID(c.Request.Context(), claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar nilai yang menunggu review."})
+		return
+	}
+
+	var response []NilaiMenungguReviewResponse
+	for _, item := range items {
+		response = append(response, NilaiMenungguReviewResponse{
+			Jenis:           item.Jenis,
+			ID:              item.ID,
+			Judul:           item.Judul,
+			KelasID:         item.KelasID,
+			NamaKelas:       item.NamaKelas,
+			MataPelajaranID: item.MataPelajaranID,
+			Updated:         item.Updated,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil daftar nilai yang menunggu review.",
+		"data":    response,
+	})
+}
+
+// checkWaliKelasReview memastikan nilai sedang direview dan user adalah wali kelas terkait.
+func (h *publikasiNilaiHandler) checkWaliKelasReview(c *gin.Context, jenis string, id int) bool {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return false
+	}
+
+	kelasID, status, ok := h.getKelasAndStatus(c, jenis, id)
+	if !ok {
+		return false
+	}
+	if status != "review" {
+		c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Nilai ini tidak sedang menunggu review."})
+		return false
+	}
+
+	kelas, err := h.kelasRepo.GetByID(c.Request.Context(), kelasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data kelas."})
+		return false
+	}
+	if kelas.GuruID != claims.UserID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Hanya wali kelas yang dapat memproses review nilai ini."})
+		return false
+	}
+	return true
+}
+
+// hitungUlangRekap memasukkan nilai tugas/quiz yang baru terbit ke rekap nilai.
+func (h *publikasiNilaiHandler) hitungUlangRekap(c *gin.Context, jenis string, id int) {
+	var err error
+	if jenis == "tugas" {
+		err = h.rekapService.HitungUntukTugas(c.Request.Context(), id)
+	} else {
+		err = h.rekapService.HitungUntukQuiz(c.Request.Context(), id)
+	}
+	if err != nil {
+		log.Printf("Gagal menghitung ulang rekap nilai %s %d: %v", jenis, id, err)
+	}
+}
+
+// getKelasAndStatus mengambil kelas dan status publikasi nilai dari tugas atau quiz.
+func (h *publikasiNilaiHandler) getKelasAndStatus(c *gin.Context, jenis string, id int) (int, string, bool) {
+	var kelasID int
+	var status string
+	var err error
+
+	if jenis == "tugas" {
+		tugas, getErr := h.tugasRepo.GetByID(c.Request.Context(), id)
+		if getErr == nil {
+			kelasID, status = tugas.KelasID, tugas.StatusNilai
+		}
+		err = getErr
+	} else {
+		quiz, getErr := h.quizRepo.GetByID(c.Request.Context(), id)
+		if getErr == nil {
+			kelasID, status = quiz.KelasID, quiz.StatusNilai
+		}
+		err = getErr
+	}
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas atau quiz tidak ditemukan."})
+			return 0, "", false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas atau quiz."})
+		return 0, "", false
+	}
+	return kelasID, status, true
+}
+
+// pastikanPengampu memastikan guru yang login mengampu tugas/quiz sebelum nilainya ditulis. Jika
+// tidak, respons galat sudah ditulis dan hasilnya false.
+func pastikanPengampu(c *gin.Context, nilaiService *services.NilaiService, jenis string, id int) bool {
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return false
+	}
+	boleh, err := nilaiService.BolehMenilai(c.Request.Context(), jenis, id, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa akses nilai."})
+		return false
+	}
+	if !boleh {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda tidak mengampu " + strings.ToLower(labelJenis(jenis)) + " ini."})
+		return false
+	}
+	return true
+}
+
+func parseJenisNilaiParams(c *gin.Context) (string, int, bool) {
+	jenis := c.Param("jenis")
+	if jenis != "tugas" && jenis != "quiz" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Jenis harus 'tugas' atau 'quiz'."})
+		return "", 0, false
+	}
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tidak valid."})
+		return "", 0, false
+	}
+	return jenis, id, true
+}
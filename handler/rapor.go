@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"archive/zip"
+	"be-pui/services"
+	"be-pui/utils"
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RaporQuery struct {
+	Semester    int    `form:"semester" binding:"required,oneof=1 2"`
+	TahunAjaran string `form:"tahun_ajaran" binding:"required"`
+}
+
+type raporHandler struct {
+	raporService *services.RaporService
+}
+
+func NewRaporHandler(raporService *services.RaporService) *raporHandler {
+	return &raporHandler{raporService: raporService}
+}
+
+// GetRaporSiswa mengunduh rapor PDF satu siswa. Siswa hanya dapat mengunduh rapornya sendiri.
+func (h *raporHandler) GetRaporSiswa(c *gin.Context) {
+	siswaID, err := strconv.Atoi(c.Param("siswa_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID siswa tidak valid."})
+		return
+	}
+
+	var query RaporQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'semester' (1 atau 2) dan 'tahun_ajaran' wajib diisi."})
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+	if claims.Role == "siswa" && claims.UserID != siswaID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda hanya dapat mengunduh rapor Anda sendiri."})
+		return
+	}
+
+	rapor, err := h.raporService.BuatRapor(c.Request.Context(), siswaID, query.Semester, query.TahunAjaran)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Siswa tidak ditemukan atau belum terdaftar di kelas."})
+			return
+		}
+		if errors.Is(err, services.ErrSemesterRapor) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Semester dan tahun ajaran tersebut belum diatur di kalender akademik."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun data rapor."})
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := h.raporService.RenderPDF(&buf, rapor); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal membuat file PDF rapor."})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, namaFileRapor(rapor)))
+	c.Data(http.StatusOK, "application/pdf", buf.Bytes())
+}
+
+// GetRaporKelas mengunduh rapor seluruh siswa di satu kelas dalam satu file ZIP.
+func (h *raporHandler) GetRaporKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+
+	var query RaporQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'semester' (1 atau 2) dan 'tahun_ajaran' wajib diisi."})
+		return
+	}
+
+	daftarRapor, err := h.raporService.BuatRaporKelas(c.Request.Context(), kelasID, query.Semester, query.TahunAjaran)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelas tidak ditemukan."})
+			return
+		}
+		if errors.Is(err, services.ErrSemesterRapor) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Semester dan tahun ajaran tersebut belum diatur di kalender akademik."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun data rapor kelas."})
+		return
+	}
+	if len(daftarRapor) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tidak ada siswa di kelas ini."})
+		return
+	}
+
+	namaKelas := strings.Trim(karakterTidakAman.ReplaceAllString(daftarRapor[0].Kelas.Name, "-"), "-")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="rapor-kelas-%s-semester-%d.zip"`, namaKelas, query.Semester))
+	c.Header("Content-Type", "application/zip")
+	c.Status(http.StatusOK)
+
+	// Respons sudah mulai dikirim, sehingga kegagalan di tengah proses hanya bisa dicatat ke log.
+	zw := zip.NewWriter(c.Writer)
+	for i := range daftarRapor {
+		f, err := zw.Create(namaFileRapor(&daftarRapor[i]))
+		if err != nil {
+			log.Printf("Gagal menambahkan rapor siswa %d ke ZIP: %v", daftarRapor[i].Siswa.ID, err)
+			break
+		}
+		if err := h.raporService.RenderPDF(f, &daftarRapor[i]); err != nil {
+			log.Printf("Gagal membuat PDF rapor siswa %d: %v", daftarRapor[i].Siswa.ID, err)
+			break
+		}
+	}
+	if err := zw.Close(); err != nil {
+		log.Printf("Gagal menutup file ZIP rapor kelas %d: %v", kelasID, err)
+	}
+}
+
+var karakterTidakAman = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+func namaFileRapor(rapor *services.RaporSiswa) string {
+	nama := strings.Trim(karakterTidakAman.ReplaceAllString(rapor.Siswa.Nama, "-"), "-")
+	return fmt.Sprintf("rapor-%d-%s-semester-%d.pdf", rapor.Siswa.ID, strings.ToLower(nama), rapor.Semester)
+}
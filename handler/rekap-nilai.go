@@ -0,0 +1,314 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type BobotKategoriInput struct {
+	Kategori string  `json:"kategori" binding:"required"`
+	Bobot    float64 `json:"bobot" binding:"gt=0"`
+}
+
+type SimpanBobotRequest struct {
+	BobotTugas   float64              `json:"bobot_tugas" binding:"gte=0"`
+	BobotQuiz    float64              `json:"bobot_quiz" binding:"gte=0"`
+	TugasDibuang int                  `json:"tugas_dibuang" binding:"gte=0"`
+	QuizDibuang  int                  `json:"quiz_dibuang" binding:"gte=0"`
+	Kategori     []BobotKategoriInput `json:"kategori" binding:"dive"`
+}
+
+type HitungRekapRequest struct {
+	KelasID int `json:"kelas_id" binding:"required"`
+	MapelID int `json:"mapel_id" binding:"required"`
+}
+
+// StatistikKelasQuery membatasi statistik ke satu semester; keduanya kosong berarti seluruh rekap.
+type StatistikKelasQuery struct {
+	Semester    int    `form:"semester" binding:"omitempty,oneof=1 2"`
+	TahunAjaran string `form:"tahun_ajaran"`
+}
+
+type BobotResponse struct {
+	MataPelajaranID int                  `json:"mata_pelajaran_id"`
+	BobotTugas      float64              `json:"bobot_tugas"`
+	BobotQuiz       float64              `json:"bobot_quiz"`
+	TugasDibuang    int                  `json:"tugas_dibuang"`
+	QuizDibuang     int                  `json:"quiz_dibuang"`
+	Kategori        []BobotKategoriInput `json:"kategori"`
+}
+
+type RekapNilaiResponse struct {
+	ID              int       `json:"id"`
+	SiswaID         int       `json:"siswa_id"`
+	NamaSiswa       string    `json:"nama_siswa"`
+	MataPelajaranID int       `json:"mata_pelajaran_id"`
+	KelasID         int       `json:"kelas_id"`
+	NilaiTugas      float64   `json:"nilai_tugas"`
+	NilaiQuiz       float64   `json:"nilai_quiz"`
+	NilaiAkhir      float64   `json:"nilai_akhir"`
+	Updated         time.Time `json:"updated"`
+}
+
+type rekapNilaiHandler struct {
+	rekapRepo        repositories.RekapNilaiRepository
+	bobotRepo        repositories.BobotNilaiRepository
+	rekapService     *services.RekapNilaiService
+	gradebookService *services.GradebookService
+	statistikService *services.StatistikKelasService
+}
+
+func NewRekapNilaiHandler(
+	rekapRepo repositories.RekapNilaiRepository,
+	bobotRepo repositories.BobotNilaiRepository,
+	rekapService *services.RekapNilaiService,
+	gradebookService *services.GradebookService,
+	statistikService *services.StatistikKelasService,
+) *rekapNilaiHandler {
+	return &rekapNilaiHandler{
+		rekapRepo:        rekapRepo,
+		bobotRepo:        bobotRepo,
+		rekapService:     rekapService,
+		gradebookService: gradebookService,
+		statistikService: statistikService,
+	}
+}
+
+// SimpanBobot menyimpan bobot tugas/quiz, aturan buang nilai terendah, dan bobot kategori tugas untuk satu mapel,
+// lalu menghitung ulang rekap mapel tersebut di semua kelas.
+func (h *rekapNilaiHandler) SimpanBobot(c *gin.Context) {
+	mapelID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID mata pelajaran tidak valid."})
+		return
+	}
+
+	var req SimpanBobotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Konfigurasi bobot tidak valid."})
+		return
+	}
+	if req.BobotTugas+req.BobotQuiz == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Bobot tugas dan bobot quiz tidak boleh keduanya 0."})
+		return
+	}
+
+	bobot := models.BobotNilai{
+		MataPelajaranID: mapelID,
+		BobotTugas:      req.BobotTugas,
+		BobotQuiz:       req.BobotQuiz,
+		TugasDibuang:    req.TugasDibuang,
+		QuizDibuang:     req.QuizDibuang,
+	}
+
+	var kategori []models.BobotKategori
+	for _, item := range req.Kategori {
+		kategori = append(kategori, models.BobotKategori{
+			MataPelajaranID: mapelID,
+			Kategori:        item.Kategori,
+			Bobot:           item.Bobot,
+		})
+	}
+
+	if err := h.bobotRepo.Save(c.Request.Context(), &bobot, kategori); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan konfigurasi bobot."})
+		return
+	}
+
+	if err := h.rekapService.HitungMapel(c.Request.Context(), mapelID); err != nil {
+		log.Printf("Gagal menghitung ulang rekap nilai mapel %d: %v", mapelID, err)
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Konfigurasi bobot nilai berhasil disimpan."})
+}
+
+func (h *rekapNilaiHandler) GetBobot(c *gin.Context) {
+	mapelID, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID mata pelajaran tidak valid."})
+		return
+	}
+
+	response := BobotResponse{
+		MataPelajaranID: mapelID,
+		BobotTugas:      services.DefaultBobotTugas,
+		BobotQuiz:       services.DefaultBobotQuiz,
+		Kategori:        []BobotKategoriInput{},
+	}
+
+	bobot, err := h.bobotRepo.GetByMapelID(c.Request.Context(), mapelID)
+	if err != nil && err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil konfigurasi bobot."})
+		return
+	}
+	if bobot != nil {
+		response.BobotTugas = bobot.BobotTugas
+		response.BobotQuiz = bobot.BobotQuiz
+		response.TugasDibuang = bobot.TugasDibuang
+		response.QuizDibuang = bobot.QuizDibuang
+	}
+
+	kategori, err := h.bobotRepo.GetKategoriByMapelID(c.Request.Context(), mapelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil bobot kategori."})
+		return
+	}
+	for _, item := range kategori {
+		response.Kategori = append(response.Kategori, BobotKategoriInput{Kategori: item.Kategori, Bobot: item.Bobot})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil konfigurasi bobot nilai.",
+		"data":    response,
+	})
+}
+
+// HitungUlangRekap memicu perhitungan ulang rekap nilai satu kelas dan mapel secara manual.
+func (h *rekapNilaiHandler) HitungUlangRekap(c *gin.Context) {
+	var req HitungRekapRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "kelas_id dan mapel_id wajib diisi."})
+		return
+	}
+
+	if err := h.rekapService.HitungKelasMapel(c.Request.Context(), req.KelasID, req.MapelID); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghitung rekap nilai."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Rekap nilai berhasil dihitung ulang."})
+}
+
+func (h *rekapNilaiHandler) GetRekapKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+
+	mapelID, err := strconv.Atoi(c.Query("mapel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'mapel_id' wajib diisi."})
+		return
+	}
+
+	rekap, err := h.rekapRepo.GetAllByKelasAndMapelID(c.Request.Context(), kelasID, mapelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil rekap nilai."})
+		return
+	}
+
+	var response []RekapNilaiResponse
+	for _, item := range rekap {
+		response = append(response, RekapNilaiResponse{
+			ID:              item.ID,
+			SiswaID:         item.SiswaID,
+			NamaSiswa:       item.NamaSiswa,
+			MataPelajaranID: item.MataPelajaranID,
+			KelasID:         item.KelasID,
+			NilaiTugas:      item.NilaiTugas,
+			NilaiQuiz:       item.NilaiQuiz,
+			NilaiAkhir:      item.NilaiAkhir,
+			Updated:         item.Updated,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil rekap nilai kelas.",
+		"data":    response,
+	})
+}
+
+// ExportGradebook mengunduh gradebook satu kelas dan mapel dalam format CSV atau XLSX (query format, default xlsx).
+// Isi file ditulis langsung ke respons sambil membaca data dari database.
+func (h *rekapNilaiHandler) ExportGradebook(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+
+	mapelID, err := strconv.Atoi(c.Query("mapel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'mapel_id' wajib diisi."})
+		return
+	}
+
+	format := c.DefaultQuery("format", "xlsx")
+	if format != "csv" && format != "xlsx" {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Format harus 'csv' atau 'xlsx'."})
+		return
+	}
+
+	kolom, err := h.gradebookService.GetKolom(c.Request.Context(), kelasID, mapelID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar tugas dan quiz."})
+		return
+	}
+
+	namaFile := fmt.Sprintf("gradebook-kelas-%d-mapel-%d.%s", kelasID, mapelID, format)
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, namaFile))
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+	} else {
+		c.Header("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+	}
+	c.Status(http.StatusOK)
+
+	if format == "csv" {
+		err = h.gradebookService.TulisCSV(c.Request.Context(), c.Writer, kolom, kelasID, mapelID)
+	} else {
+		err = h.gradebookService.TulisXLSX(c.Request.Context(), c.Writer, kolom, kelasID, mapelID)
+	}
+	if err != nil {
+		log.Printf("Gagal mengekspor gradebook kelas %d mapel %d: %v", kelasID, mapelID, err)
+	}
+}
+
+// GetStatistikKelas mengembalikan peringkat siswa, statistik per mapel, histogram nilai, dan ketuntasan KKM satu kelas,
+// untuk seluruh rekap atau satu semester.
+func (h *rekapNilaiHandler) GetStatistikKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+
+	var query StatistikKelasQuery
+	if err := c.ShouldBindQuery(&query); err != nil || (query.Semester == 0) != (query.TahunAjaran == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'semester' (1 atau 2) dan 'tahun_ajaran' harus diisi bersamaan."})
+		return
+	}
+
+	statistik, err := h.statistikService.GetStatistik(c.Request.Context(), kelasID, query.Semester, query.TahunAjaran)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelas tidak ditemukan."})
+			return
+		}
+		if errors.Is(err, services.ErrSemesterRapor) {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Semester dan tahun ajaran tersebut belum diatur di kalender akademik."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghitung statistik kelas."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil statistik nilai kelas.",
+		"data":    statistik,
+	})
+}
@@ -0,0 +1,370 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type KKMRequest struct {
+	MataPelajaranID int      `json:"mata_pelajaran_id" binding:"required"`
+	Tingkat         int      `json:"tingkat" binding:"required,oneof=4 5 6"`
+	Nilai           *float64 `json:"nilai" binding:"required,gte=0,lte=100"`
+}
+
+type RemedialCreateRequest struct {
+	Jenis     string `json:"jenis" binding:"required,oneof=tugas quiz"`
+	AsalID    int    `json:"asal_id" binding:"required"`
+	ItemID    int    `json:"item_id" binding:"required"`
+	Kebijakan string `json:"kebijakan" binding:"required,oneof=ganti maksimum batas_kkm"`
+	SiswaIDs  []int  `json:"siswa_ids"`
+}
+
+type KKMResponse struct {
+	ID              int       `json:"id"`
+	MataPelajaranID int       `json:"mata_pelajaran_id"`
+	Tingkat         int       `json:"tingkat"`
+	Nilai           float64   `json:"nilai"`
+	Updated         time.Time `json:"updated"`
+}
+
+type NilaiDiBawahKKMResponse struct {
+	Jenis    string  `json:"jenis"`
+	SumberID int     `json:"sumber_id"`
+	Judul    string  `json:"judul"`
+	Nilai    float64 `json:"nilai"`
+}
+
+type SiswaRemedialResponse struct {
+	SiswaID   int                       `json:"siswa_id"`
+	NamaSiswa string                    `json:"nama_siswa"`
+	Nilai     []NilaiDiBawahKKMResponse `json:"nilai"`
+}
+
+type PesertaRemedialResponse struct {
+	SiswaID       int        `json:"siswa_id"`
+	NamaSiswa     string     `json:"nama_siswa"`
+	NilaiAwal     *float64   `json:"nilai_awal,omitempty"`
+	NilaiRemedial *float64   `json:"nilai_remedial,omitempty"`
+	NilaiAkhir    *float64   `json:"nilai_akhir,omitempty"`
+	Diterapkan    *time.Time `json:"diterapkan,omitempty"`
+}
+
+type RemedialResponse struct {
+	ID        int                       `json:"id"`
+	Jenis     string                    `json:"jenis"`
+	AsalID    int                       `json:"asal_id"`
+	ItemID    int                       `json:"item_id"`
+	Kebijakan string                    `json:"kebijakan"`
+	Peserta   []PesertaRemedialResponse `json:"peserta"`
+}
+
+type remedialHandler struct {
+	kkmRepo      repositories.KKMRepository
+	remedialRepo repositories.RemedialRepository
+	kelasRepo    repositories.KelasRepository
+	siswaRepo    repositories.SiswaRepository
+	tugasRepo    repositories.TugasRepository
+	quizRepo     repositories.QuizRepository
+}
+
+func NewRemedialHandler(
+	kkmRepo repositories.KKMRepository,
+	remedialRepo repositories.RemedialRepository,
+	kelasRepo repositories.KelasRepository,
+	siswaRepo repositories.SiswaRepository,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+) *remedialHandler {
+	return &remedialHandler{
+		kkmRepo:      kkmRepo,
+		remedialRepo: remedialRepo,
+		kelasRepo:    kelasRepo,
+		siswaRepo:    siswaRepo,
+		tugasRepo:    tugasRepo,
+		quizRepo:     quizRepo,
+	}
+}
+
+func (h *remedialHandler) SimpanKKM(c *gin.Context) {
+	var req KKMRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Mata pelajaran, tingkat (4-6), dan nilai KKM (0-100) wajib diisi."})
+		return
+	}
+
+	kkm := models.KKM{
+		MataPelajaranID: req.MataPelajaranID,
+		Tingkat:         req.Tingkat,
+		Nilai:           *req.Nilai,
+	}
+
+	if err := h.kkmRepo.Upsert(c.Request.Context(), &kkm); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan KKM."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "KKM berhasil disimpan."})
+}
+
+func (h *remedialHandler) GetAllKKM(c *gin.Context) {
+	kkms, err := h.kkmRepo.GetAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data KKM."})
+		return
+	}
+
+	var response []KKMResponse
+	for _, kkm := range kkms {
+		response = append(response, KKMResponse{
+			ID:              kkm.ID,
+			MataPelajaranID: kkm.MataPelajaranID,
+			Tingkat:         kkm.Tingkat,
+			Nilai:           kkm.Nilai,
+			Updated:         kkm.Updated,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil data KKM.",
+		"data":    response,
+	})
+}
+
+// GetSiswaRemedial menandai siswa yang nilai akhir, tugas, atau quiz-nya di bawah KKM untuk satu kelas dan mapel.
+func (h *remedialHandler) GetSiswaRemedial(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Query("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'kelas_id' wajib diisi."})
+		return
+	}
+	mapelID, err := strconv.Atoi(c.Query("mapel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'mapel_id' wajib diisi."})
+		return
+	}
+
+	kkm, ok := h.getKKM(c, kelasID, mapelID)
+	if !ok {
+		return
+	}
+
+	items, err := h.kkmRepo.GetSiswaDiBawahKKM(c.Request.Context(), kelasID, mapelID, kkm.Nilai)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data siswa di bawah KKM."})
+		return
+	}
+
+	var response []SiswaRemedialResponse
+	indexSiswa := make(map[int]int)
+	for _, item := range items {
+		idx, found := indexSiswa[item.SiswaID]
+		if !found {
+			response = append(response, SiswaRemedialResponse{SiswaID: item.SiswaID, NamaSiswa: item.NamaSiswa})
+			idx = len(response) - 1
+			indexSiswa[item.SiswaID] = idx
+		}
+		response[idx].Nilai = append(response[idx].Nilai, NilaiDiBawahKKMResponse{
+			Jenis:    item.Jenis,
+			SumberID: item.SumberID,
+			Judul:    item.Judul,
+			Nilai:    item.Nilai,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil daftar siswa yang perlu remedial.",
+		"data":    gin.H{"kkm": kkm.Nilai, "siswa": response},
+	})
+}
+
+// BuatRemedial menjadikan tugas/quiz (item_id) sebagai remedial dari tugas/quiz asal (asal_id).
+// Jika siswa_ids kosong, peserta diambil dari siswa yang nilai asalnya di bawah KKM atau belum ada.
+func (h *remedialHandler) BuatRemedial(c *gin.Context) {
+	var req RemedialCreateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Jenis, asal_id, item_id, dan kebijakan (ganti, maksimum, batas_kkm) wajib diisi."})
+		return
+	}
+	if req.AsalID == req.ItemID {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tugas/quiz remedial harus berbeda dari tugas/quiz asal."})
+		return
+	}
+
+	asalKelasID, asalMapelID, err := h.getKelasMapel(c, req.Jenis, req.AsalID)
+	if err != nil {
+		h.writeItemError(c, err)
+		return
+	}
+	itemKelasID, itemMapelID, err := h.getKelasMapel(c, req.Jenis, req.ItemID)
+	if err != nil {
+		h.writeItemError(c, err)
+		return
+	}
+	if asalKelasID != itemKelasID || asalMapelID != itemMapelID {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tugas/quiz remedial harus berada di kelas dan mata pelajaran yang sama dengan asalnya."})
+		return
+	}
+
+	siswaKelas, err := h.siswaRepo.GetAllByKelasID(c.Request.Context(), asalKelasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data siswa kelas."})
+		return
+	}
+	siswaDiKelas := make(map[int]bool)
+	for _, siswa := range siswaKelas {
+		siswaDiKelas[siswa.ID] = true
+	}
+
+	var kkm *models.KKM
+	siswaIDs := req.SiswaIDs
+	if len(siswaIDs) == 0 {
+		var ok bool
+		if kkm, ok = h.getKKM(c, asalKelasID, asalMapelID); !ok {
+			return
+		}
+		for _, siswa := range siswaKelas {
+			siswaIDs = append(siswaIDs, siswa.ID)
+		}
+	}
+
+	var peserta []models.PesertaRemedial
+	for _, siswaID := range siswaIDs {
+		if !siswaDiKelas[siswaID] {
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Terdapat siswa yang tidak terdaftar di kelas ini."})
+			return
+		}
+
+		nilaiAwal, err := h.remedialRepo.GetNilaiAsal(c.Request.Context(), req.Jenis, req.AsalID, siswaID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil nilai asal siswa."})
+			return
+		}
+		if kkm != nil && nilaiAwal != nil && *nilaiAwal >= kkm.Nilai {
+			continue
+		}
+		peserta = append(peserta, models.PesertaRemedial{SiswaID: siswaID, NilaiAwal: nilaiAwal})
+	}
+
+	if len(peserta) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Tidak ada siswa yang perlu mengikuti remedial."})
+		return
+	}
+
+	remedial := models.Remedial{
+		Jenis:     req.Jenis,
+		AsalID:    req.AsalID,
+		ItemID:    req.ItemID,
+		Kebijakan: req.Kebijakan,
+	}
+
+	if err := h.remedialRepo.Create(c.Request.Context(), &remedial, peserta); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan data remedial."})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Remedial berhasil dibuat.",
+		"data":    gin.H{"id": remedial.ID, "jumlah_peserta": len(peserta)},
+	})
+}
+
+func (h *remedialHandler) GetRemedialByID(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID remedial tidak valid."})
+		return
+	}
+
+	remedial, err := h.remedialRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Remedial tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data remedial."})
+		return
+	}
+
+	peserta, err := h.remedialRepo.GetPeserta(c.Request.Context(), id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil peserta remedial."})
+		return
+	}
+
+	response := RemedialResponse{
+		ID:        remedial.ID,
+		Jenis:     remedial.Jenis,
+		AsalID:    remedial.AsalID,
+		ItemID:    remedial.ItemID,
+		Kebijakan: remedial.Kebijakan,
+		Peserta:   []PesertaRemedialResponse{},
+	}
+	for _, item := range peserta {
+		response.Peserta = append(response.Peserta, PesertaRemedialResponse{
+			SiswaID:       item.SiswaID,
+			NamaSiswa:     item.NamaSiswa,
+			NilaiAwal:     item.NilaiAwal,
+			NilaiRemedial: item.NilaiRemedial,
+			NilaiAkhir:    item.NilaiAkhir,
+			Diterapkan:    item.Diterapkan,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Remedial ditemukan.", "data": response})
+}
+
+// getKKM mengambil KKM mapel untuk tingkat kelas; response error sudah ditulis jika ok bernilai false.
+func (h *remedialHandler) getKKM(c *gin.Context, kelasID int, mapelID int) (*models.KKM, bool) {
+	kelas, err := h.kelasRepo.GetByID(c.Request.Context(), kelasID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelas tidak ditemukan."})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data kelas."})
+		return nil, false
+	}
+
+	kkm, err := h.kkmRepo.GetByMapelAndTingkat(c.Request.Context(), mapelID, kelas.Tingkat)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "KKM untuk mata pelajaran dan tingkat ini belum diatur."})
+			return nil, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data KKM."})
+		return nil, false
+	}
+	return kkm, true
+}
+
+func (h *remedialHandler) getKelasMapel(c *gin.Context, jenis string, id int) (int, int, error) {
+	if jenis == "quiz" {
+		quiz, err := h.quizRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return 0, 0, err
+		}
+		return quiz.KelasID, quiz.MataPelajaranID, nil
+	}
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		return 0, 0, err
+	}
+	return tugas.KelasID, tugas.MataPelajaranID, nil
+}
+
+func (h *remedialHandler) writeItemError(c *gin.Context, err error) {
+	if err == sql.ErrNoRows {
+		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas atau quiz tidak ditemukan."})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas atau quiz."})
+}
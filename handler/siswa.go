@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"os"
 	"path/filepath"
 	"strconv"
 	"time"
@@ -61,6 +62,8 @@ type siswaHandler struct {
 	siswaRepo      repositories.SiswaRepository
 	tugasRepo      repositories.TugasRepository
 	hasilTugasRepo repositories.HasilTugasRepository
+	kelompokRepo   repositories.KelompokTugasRepository
+	remedialRepo   repositories.RemedialRepository
 	jwtUtil        *utils.JWTUtil
 	cfg            *config.Config
 }
@@ -69,6 +72,8 @@ func NewSiswaHandler(
 	siswaRepo repositories.SiswaRepository,
 	tugasRepo repositories.TugasRepository,
 	hasilTugasRepo repositories.HasilTugasRepository,
+	kelompokRepo repositories.KelompokTugasRepository,
+	remedialRepo repositories.RemedialRepository,
 	jwtUtil *utils.JWTUtil,
 	cfg *config.Config,
 ) *siswaHandler {
@@ -76,6 +81,8 @@ func NewSiswaHandler(
 		siswaRepo:      siswaRepo,
 		tugasRepo:      tugasRepo,
 		hasilTugasRepo: hasilTugasRepo,
+		kelompokRepo:   kelompokRepo,
+		remedialRepo:   remedialRepo,
 		jwtUtil:        jwtUtil,
 		cfg:            cfg,
 	}
@@ -104,7 +111,7 @@ func (h *siswaHandler) CheckTugasCompletion(c *gin.Context) {
 		return
 	}
 
-	tugasKelas, err := h.tugasRepo.GetAllByKelasID(c.Request.Context(), *siswa.KelasID)
+	tugasKelas, err := h.tugasRepo.GetPublishedByKelasID(c.Request.Context(), *siswa.KelasID, claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil daftar tugas kelas."})
 		return
@@ -134,6 +141,10 @@ func (h *siswaHandler) CheckTugasCompletion(c *gin.Context) {
 		}
 
 		if hasil, found := hasilMap[tugas.ID]; found {
+			if !tugas.NilaiTerbit() {
+				hasil.Nilai = nil
+				hasil.Feedback = nil
+			}
 			tugasItem.IsCompleted = true
 			tugasItem.HasilTugas = &hasil
 		}
@@ -182,7 +193,7 @@ func (h *siswaHandler) SubmitTugas(c *gin.Context) {
 	}
 
 	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), tugasID)
-	if err != nil {
+	if err != nil || !tugas.IsPublished(time.Now()) {
 		c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
 		return
 	}
@@ -198,6 +209,42 @@ func (h *siswaHandler) SubmitTugas(c *gin.Context) {
 		return
 	}
 
+	if _, err := h.remedialRepo.GetByItemID(c.Request.Context(), "tugas", tugasID); err == nil {
+		isPeserta, err := h.remedialRepo.IsPeserta(c.Request.Context(), "tugas", tugasID, claims.UserID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa peserta remedial."})
+			return
+		}
+		if !isPeserta {
+			c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda bukan peserta remedial untuk tugas ini."})
+			return
+		}
+	} else if err != sql.ErrNoRows {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa data remedial."})
+		return
+	}
+
+	var kelompokID *int
+	var anggotaIDs []int
+	if tugas.IsKelompok {
+		kelompok, err := h.kelompokRepo.GetByTugasAndSiswaID(c.Request.Context(), tugasID, claims.UserID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda belum tergabung dalam kelompok untuk tugas ini."})
+				return
+			}
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data kelompok."})
+			return
+		}
+
+		anggotaIDs, err = h.kelompokRepo.GetAnggotaIDs(c.Request.Context(), kelompok.ID)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil anggota kelompok."})
+			return
+		}
+		kelompokID = &kelompok.ID
+	}
+
 	ext := filepath.Ext(file.Filename)
 	uniqueFilename := fmt.Sprintf("tugas-%d-siswa-%d-%d%s", tugasID, claims.UserID, time.Now().Unix(), ext)
 	dst := filepath.Join("./uploads/jawaban_tugas/", uniqueFilename)
@@ -221,9 +268,20 @@ func (h *siswaHandler) SubmitTugas(c *gin.Context) {
 		TanggalPengumpulan: time.Now(),
 		Status:             status,
 		FileJawabanUrl:     &fileURL,
+		KelompokID:         kelompokID,
 	}
 
-	if err := h.hasilTugasRepo.Create(c.Request.Context(), &hasilTugasModel); err != nil {
+	if tugas.IsKelompok {
+		err = h.hasilTugasRepo.CreateForKelompok(c.Request.Context(), &hasilTugasModel, anggotaIDs)
+	} else {
+		err = h.hasilTugasRepo.Create(c.Request.Context(), &hasilTugasModel)
+	}
+	if err != nil {
+		os.Remove(dst)
+		if errors.Is(err, repositories.ErrKelompokSudahMengumpulkan) {
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Kelompok Anda sudah mengumpulkan tugas ini."})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan pengumpulan tugas."})
 		return
 	}
@@ -264,7 +322,7 @@ func (h *siswaHandler) GetMyTugas(c *gin.Context) {
 		return
 	}
 
-	tugases, err := h.tugasRepo.GetAllByKelasAndMapelID(c.Request.Context(), *profile.KelasID, mapelID)
+	tugases, err := h.tugasRepo.GetPublishedByKelasAndMapelID(c.Request.Context(), *profile.KelasID, mapelID, claims.UserID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
 		return
@@ -279,6 +337,11 @@ func (h *siswaHandler) GetMyTugas(c *gin.Context) {
 			MataPelajaranID: tugas.MataPelajaranID,
 			KelasID:         tugas.KelasID,
 			Deadline:        tugas.Deadline,
+			Kategori:        tugas.Kategori,
+			IsDraft:         tugas.IsDraft,
+			PublishAt:       tugas.PublishAt,
+			IsKelompok:      tugas.IsKelompok,
+			PeerReview:      tugas.PeerReview,
 			Created:         tugas.Created,
 			Updated:         tugas.Updated,
 		})
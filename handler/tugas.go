@@ -3,7 +3,11 @@ package handler
 import (
 	"be-pui/models"
 	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
 	"errors"
+	"log"
 	"net/http"
 	"strconv"
 	"time"
@@ -14,30 +18,40 @@ import (
 )
 
 type TugasCreateRequest struct {
-	Judul           string    `json:"judul" binding:"required"`
-	Deskripsi       string    `json:"deskripsi"`
-	MataPelajaranID int       `json:"mata_pelajaran_id" binding:"required"`
-	KelasID         int       `json:"kelas_id" binding:"required"`
-	Deadline        time.Time `json:"deadline" binding:"required"`
+	Judul           string     `json:"judul" binding:"required"`
+	Deskripsi       string     `json:"deskripsi"`
+	MataPelajaranID int        `json:"mata_pelajaran_id" binding:"required"`
+	KelasID         int        `json:"kelas_id" binding:"required"`
+	Deadline        time.Time  `json:"deadline" binding:"required"`
+	Kategori        *string    `json:"kategori"`
+	IsDraft         bool       `json:"is_draft"`
+	PublishAt       *time.Time `json:"publish_at"`
+	IsKelompok      bool       `json:"is_kelompok"`
 }
 
 type TugasResponse struct {
-	ID              int       `json:"id"`
-	Judul           string    `json:"judul"`
-	Deskripsi       string    `json:"deskripsi"`
-	MataPelajaranID int       `json:"mata_pelajaran_id"`
-	KelasID         int       `json:"kelas_id"`
-	Deadline        time.Time `json:"deadline"`
-	Created         time.Time `json:"created"`
-	Updated         time.Time `json:"updated"`
+	ID              int        `json:"id"`
+	Judul           string     `json:"judul"`
+	Deskripsi       string     `json:"deskripsi"`
+	MataPelajaranID int        `json:"mata_pelajaran_id"`
+	KelasID         int        `json:"kelas_id"`
+	Deadline        time.Time  `json:"deadline"`
+	Kategori        *string    `json:"kategori,omitempty"`
+	IsDraft         bool       `json:"is_draft"`
+	PublishAt       *time.Time `json:"publish_at,omitempty"`
+	IsKelompok      bool       `json:"is_kelompok"`
+	PeerReview      bool       `json:"peer_review"`
+	Created         time.Time  `json:"created"`
+	Updated         time.Time  `json:"updated"`
 }
 
 type tugasHandler struct {
-	tugasRepo repositories.TugasRepository
+	tugasRepo       repositories.TugasRepository
+	kalenderService *services.KalenderAkademikService
 }
 
-func NewTugasHandler(tugasRepo repositories.TugasRepository) *tugasHandler {
-	return &tugasHandler{tugasRepo: tugasRepo}
+func NewTugasHandler(tugasRepo repositories.TugasRepository, kalenderService *services.KalenderAkademikService) *tugasHandler {
+	return &tugasHandler{tugasRepo: tugasRepo, kalenderService: kalenderService}
 }
 
 func (h *tugasHandler) CreateTugas(c *gin.Context) {
@@ -62,6 +76,10 @@ func (h *tugasHandler) CreateTugas(c *gin.Context) {
 		MataPelajaranID: req.MataPelajaranID,
 		KelasID:         req.KelasID,
 		Deadline:        req.Deadline,
+		Kategori:        req.Kategori,
+		IsDraft:         req.IsDraft,
+		PublishAt:       req.PublishAt,
+		IsKelompok:      req.IsKelompok,
 	}
 
 	if err := h.tugasRepo.Create(c.Request.Context(), &tugasModel); err != nil {
@@ -74,9 +92,62 @@ func (h *tugasHandler) CreateTugas(c *gin.Context) {
 		return
 	}
 
+	// Deadline di hari libur, pekan ujian, atau di luar semester tetap disimpan, tetapi guru diberi peringatan.
+	peringatan, err := h.kalenderService.PeriksaDeadline(c.Request.Context(), req.Deadline)
+	if err != nil {
+		log.Printf("Gagal memeriksa kalender akademik untuk tugas %d: %v", tugasModel.ID, err)
+	}
+	if len(peringatan) > 0 {
+		c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Tugas berhasil dibuat.", "peringatan": peringatan})
+		return
+	}
+
 	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Tugas berhasil dibuat."})
 }
 
+// PublishTugas menerbitkan tugas draft (atau tugas terjadwal) saat ini juga. Hanya pengampu tugas yang
+// dapat menerbitkannya, dan tugas yang sudah terbit tidak diubah waktu terbitnya.
+func (h *tugasHandler) PublishTugas(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tugas tidak valid."})
+		return
+	}
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+
+	if _, err := h.tugasRepo.GetByID(c.Request.Context(), id); err != nil {
+		writeItemNotFound(c, err, "Tugas")
+		return
+	}
+	diampu, err := h.tugasRepo.DiampuGuru(c.Request.Context(), id, claims.UserID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal memeriksa akses tugas."})
+		return
+	}
+	if !diampu {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda tidak mengampu tugas ini."})
+		return
+	}
+
+	if err := h.tugasRepo.Publish(c.Request.Context(), id); err != nil {
+		switch {
+		case err == sql.ErrNoRows:
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tugas tidak ditemukan."})
+		case errors.Is(err, repositories.ErrTugasSudahTerbit):
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Tugas sudah terbit."})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menerbitkan tugas."})
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Tugas berhasil diterbitkan."})
+}
+
 func (h *tugasHandler) GetAllTugasByKelasID(c *gin.Context) {
 	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
 	if err != nil {
@@ -84,7 +155,12 @@ func (h *tugasHandler) GetAllTugasByKelasID(c *gin.Context) {
 		return
 	}
 
-	tugases, err := h.tugasRepo.GetAllByKelasID(c.Request.Context(), kelasID)
+	var tugases []models.Tugas
+	if claims, ok := utils.GetCurrentUserClaims(c); ok && claims.Role == "siswa" {
+		tugases, err = h.tugasRepo.GetPublishedByKelasID(c.Request.Context(), kelasID, claims.UserID)
+	} else {
+		tugases, err = h.tugasRepo.GetAllByKelasID(c.Request.Context(), kelasID)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
 		return
@@ -99,6 +175,11 @@ func (h *tugasHandler) GetAllTugasByKelasID(c *gin.Context) {
 			MataPelajaranID: tugas.MataPelajaranID,
 			KelasID:         tugas.KelasID,
 			Deadline:        tugas.Deadline,
+			Kategori:        tugas.Kategori,
+			IsDraft:         tugas.IsDraft,
+			PublishAt:       tugas.PublishAt,
+			IsKelompok:      tugas.IsKelompok,
+			PeerReview:      tugas.PeerReview,
 			Created:         tugas.Created,
 			Updated:         tugas.Updated,
 		})
@@ -118,7 +199,12 @@ func (h *tugasHandler) GetAllTugasByMapelID(c *gin.Context) {
 		return
 	}
 
-	tugases, err := h.tugasRepo.GetAllByMapelID(c.Request.Context(), mapelID)
+	var tugases []models.Tugas
+	if claims, ok := utils.GetCurrentUserClaims(c); ok && claims.Role == "siswa" {
+		tugases, err = h.tugasRepo.GetPublishedByMapelID(c.Request.Context(), mapelID, claims.UserID)
+	} else {
+		tugases, err = h.tugasRepo.GetAllByMapelID(c.Request.Context(), mapelID)
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data tugas."})
 		return
@@ -133,6 +219,11 @@ func (h *tugasHandler) GetAllTugasByMapelID(c *gin.Context) {
 			MataPelajaranID: tugas.MataPelajaranID,
 			KelasID:         tugas.KelasID,
 			Deadline:        tugas.Deadline,
+			Kategori:        tugas.Kategori,
+			IsDraft:         tugas.IsDraft,
+			PublishAt:       tugas.PublishAt,
+			IsKelompok:      tugas.IsKelompok,
+			PeerReview:      tugas.PeerReview,
 			Created:         tugas.Created,
 			Updated:         tugas.Updated,
 		})
@@ -0,0 +1,383 @@
+package handler
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"be-pui/services"
+	"be-pui/utils"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/lib/pq"
+)
+
+type TujuanPembelajaranRequest struct {
+	MataPelajaranID     int    `json:"mata_pelajaran_id" binding:"required"`
+	Tingkat             int    `json:"tingkat" binding:"required,gte=1"`
+	Kode                string `json:"kode" binding:"required"`
+	CapaianPembelajaran string `json:"capaian_pembelajaran"`
+	Deskripsi           string `json:"deskripsi" binding:"required"`
+}
+
+type TagTujuanRequest struct {
+	TujuanIDs []int `json:"tujuan_ids" binding:"required"`
+}
+
+type TujuanPembelajaranResponse struct {
+	ID                  int       `json:"id"`
+	MataPelajaranID     int       `json:"mata_pelajaran_id"`
+	Tingkat             int       `json:"tingkat"`
+	Kode                string    `json:"kode"`
+	CapaianPembelajaran string    `json:"capaian_pembelajaran"`
+	Deskripsi           string    `json:"deskripsi"`
+	Updated             time.Time `json:"updated"`
+}
+
+type tujuanPembelajaranHandler struct {
+	tujuanRepo    repositories.TujuanPembelajaranRepository
+	tugasRepo     repositories.TugasRepository
+	quizRepo      repositories.QuizRepository
+	kelasRepo     repositories.KelasRepository
+	tujuanService *services.TujuanPembelajaranService
+}
+
+func NewTujuanPembelajaranHandler(
+	tujuanRepo repositories.TujuanPembelajaranRepository,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+	kelasRepo repositories.KelasRepository,
+	tujuanService *services.TujuanPembelajaranService,
+) *tujuanPembelajaranHandler {
+	return &tujuanPembelajaranHandler{
+		tujuanRepo:    tujuanRepo,
+		tugasRepo:     tugasRepo,
+		quizRepo:      quizRepo,
+		kelasRepo:     kelasRepo,
+		tujuanService: tujuanService,
+	}
+}
+
+func (h *tujuanPembelajaranHandler) CreateTujuan(c *gin.Context) {
+	var req TujuanPembelajaranRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "mata_pelajaran_id, tingkat, kode, dan deskripsi wajib diisi."})
+		return
+	}
+
+	tujuan := &models.TujuanPembelajaran{
+		MataPelajaranID:     req.MataPelajaranID,
+		Tingkat:             req.Tingkat,
+		Kode:                req.Kode,
+		CapaianPembelajaran: req.CapaianPembelajaran,
+		Deskripsi:           req.Deskripsi,
+	}
+	if err := h.tujuanRepo.Create(c.Request.Context(), tujuan); err != nil {
+		writeTujuanSimpanError(c, err, "Gagal menyimpan tujuan pembelajaran.")
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"success": true, "message": "Tujuan pembelajaran berhasil dibuat."})
+}
+
+func (h *tujuanPembelajaranHandler) UpdateTujuan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tujuan pembelajaran tidak valid."})
+		return
+	}
+
+	var req TujuanPembelajaranRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "mata_pelajaran_id, tingkat, kode, dan deskripsi wajib diisi."})
+		return
+	}
+
+	tujuan := &models.TujuanPembelajaran{
+		ID:                  id,
+		MataPelajaranID:     req.MataPelajaranID,
+		Tingkat:             req.Tingkat,
+		Kode:                req.Kode,
+		CapaianPembelajaran: req.CapaianPembelajaran,
+		Deskripsi:           req.Deskripsi,
+	}
+	if err := h.tujuanRepo.Update(c.Request.Context(), tujuan); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tujuan pembelajaran tidak ditemukan."})
+			return
+		}
+		writeTujuanSimpanError(c, err, "Gagal memperbarui tujuan pembelajaran.")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Tujuan pembelajaran berhasil diperbarui."})
+}
+
+func (h *tujuanPembelajaranHandler) DeleteTujuan(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID tujuan pembelajaran tidak valid."})
+		return
+	}
+
+	if err := h.tujuanRepo.Delete(c.Request.Context(), id); err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Tujuan pembelajaran tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menghapus tujuan pembelajaran."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Tujuan pembelajaran berhasil dihapus."})
+}
+
+// GetAllTujuan mengambil tujuan pembelajaran, dapat disaring dengan query mapel_id dan tingkat.
+func (h *tujuanPembelajaranHandler) GetAllTujuan(c *gin.Context) {
+	mapelID, ok := parseQueryIntOpsional(c, "mapel_id")
+	if !ok {
+		return
+	}
+	tingkat, ok := parseQueryIntOpsional(c, "tingkat")
+	if !ok {
+		return
+	}
+
+	tujuan, err := h.tujuanRepo.GetAll(c.Request.Context(), mapelID, tingkat)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil tujuan pembelajaran."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil tujuan pembelajaran.",
+		"data":    toTujuanResponse(tujuan),
+	})
+}
+
+// TandaiTujuan mengganti tujuan pembelajaran yang dinilai oleh satu tugas atau quiz. Quiz ditandai
+// secara utuh. Tujuan harus milik mapel yang sama dan tingkat kelas tugas/quiz tersebut.
+func (h *tujuanPembelajaranHandler) TandaiTujuan(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	var req TagTujuanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "tujuan_ids wajib diisi (boleh kosong untuk menghapus tanda)."})
+		return
+	}
+
+	kelasID, mapelID, err := h.getKelasMapel(c, jenis, id)
+	if err != nil {
+		writeItemNotFound(c, err, labelJenis(jenis))
+		return
+	}
+	kelas, err := h.kelasRepo.GetByID(c.Request.Context(), kelasID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil data kelas."})
+		return
+	}
+
+	unik := make(map[int]bool)
+	var tujuanIDs []int
+	for _, tujuanID := range req.TujuanIDs {
+		if !unik[tujuanID] {
+			unik[tujuanID] = true
+			tujuanIDs = append(tujuanIDs, tujuanID)
+		}
+	}
+
+	tujuan, err := h.tujuanRepo.GetByIDs(c.Request.Context(), tujuanIDs)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil tujuan pembelajaran."})
+		return
+	}
+	if len(tujuan) != len(tujuanIDs) {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Terdapat tujuan pembelajaran yang tidak ditemukan."})
+		return
+	}
+	for _, item := range tujuan {
+		if item.MataPelajaranID != mapelID || item.Tingkat != kelas.Tingkat {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"message": fmt.Sprintf("Tujuan pembelajaran %s bukan milik mapel dan tingkat %s ini.", item.Kode, jenis),
+			})
+			return
+		}
+	}
+
+	if err := h.tujuanRepo.ReplaceTag(c.Request.Context(), jenis, id, tujuanIDs); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyimpan tujuan pembelajaran."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Tujuan pembelajaran berhasil ditandai.",
+		"data":    toTujuanResponse(tujuan),
+	})
+}
+
+func (h *tujuanPembelajaranHandler) GetTagTujuan(c *gin.Context) {
+	jenis, id, ok := parseJenisNilaiParams(c)
+	if !ok {
+		return
+	}
+
+	tujuan, err := h.tujuanRepo.GetTagByItem(c.Request.Context(), jenis, id)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal mengambil tujuan pembelajaran."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil tujuan pembelajaran.",
+		"data":    toTujuanResponse(tujuan),
+	})
+}
+
+// GetKetercapaianKelas mengembalikan tingkat ketercapaian tiap tujuan pembelajaran untuk seluruh
+// siswa di satu kelas dan mapel.
+func (h *tujuanPembelajaranHandler) GetKetercapaianKelas(c *gin.Context) {
+	kelasID, err := strconv.Atoi(c.Param("kelas_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID kelas tidak valid."})
+		return
+	}
+	mapelID, err := strconv.Atoi(c.Query("mapel_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Query parameter 'mapel_id' wajib diisi."})
+		return
+	}
+
+	laporan, err := h.tujuanService.GetKetercapaianKelas(c.Request.Context(), kelasID, mapelID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Kelas tidak ditemukan."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun laporan ketercapaian."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil laporan ketercapaian tujuan pembelajaran.",
+		"data":    laporan,
+	})
+}
+
+// GetKetercapaianSiswa mengembalikan laporan ketercapaian satu siswa. Query mapel_id opsional.
+// Siswa hanya dapat melihat laporannya sendiri dan hanya dari nilai yang sudah terbit.
+func (h *tujuanPembelajaranHandler) GetKetercapaianSiswa(c *gin.Context) {
+	siswaID, err := strconv.Atoi(c.Param("siswa_id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "ID siswa tidak valid."})
+		return
+	}
+	mapelID, ok := parseQueryIntOpsional(c, "mapel_id")
+	if !ok {
+		return
+	}
+
+	claims, ok := utils.GetCurrentUserClaims(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"success": false, "message": "Konteks user tidak ditemukan."})
+		return
+	}
+	if claims.Role == "siswa" && claims.UserID != siswaID {
+		c.JSON(http.StatusForbidden, gin.H{"success": false, "message": "Anda hanya dapat melihat laporan Anda sendiri."})
+		return
+	}
+
+	laporan, err := h.tujuanService.GetKetercapaianSiswa(c.Request.Context(), siswaID, mapelID, claims.Role == "siswa")
+	if err != nil {
+		if err == sql.ErrNoRows {
+			c.JSON(http.StatusNotFound, gin.H{"success": false, "message": "Siswa tidak ditemukan atau belum terdaftar di kelas."})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": "Gagal menyusun laporan ketercapaian."})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Berhasil mengambil laporan ketercapaian tujuan pembelajaran.",
+		"data":    laporan,
+	})
+}
+
+func (h *tujuanPembelajaranHandler) getKelasMapel(c *gin.Context, jenis string, id int) (int, int, error) {
+	if jenis == "quiz" {
+		quiz, err := h.quizRepo.GetByID(c.Request.Context(), id)
+		if err != nil {
+			return 0, 0, err
+		}
+		return quiz.KelasID, quiz.MataPelajaranID, nil
+	}
+	tugas, err := h.tugasRepo.GetByID(c.Request.Context(), id)
+	if err != nil {
+		return 0, 0, err
+	}
+	return tugas.KelasID, tugas.MataPelajaranID, nil
+}
+
+func labelJenis(jenis string) string {
+	if jenis == "quiz" {
+		return "Quiz"
+	}
+	return "Tugas"
+}
+
+// parseQueryIntOpsional membaca query parameter bilangan bulat; parameter kosong bernilai 0.
+func parseQueryIntOpsional(c *gin.Context, nama string) (int, bool) {
+	value := c.Query(nama)
+	if value == "" {
+		return 0, true
+	}
+	parsed, err := strconv.Atoi(value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": fmt.Sprintf("Query parameter '%s' tidak valid.", nama)})
+		return 0, false
+	}
+	return parsed, true
+}
+
+func writeTujuanSimpanError(c *gin.Context, err error, pesan string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23505":
+			c.JSON(http.StatusConflict, gin.H{"success": false, "message": "Kode tujuan pembelajaran sudah digunakan untuk mapel dan tingkat ini."})
+			return
+		case "23503":
+			c.JSON(http.StatusBadRequest, gin.H{"success": false, "message": "Mata pelajaran dengan ID yang diberikan tidak ditemukan."})
+			return
+		}
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"success": false, "message": pesan})
+}
+
+func toTujuanResponse(tujuan []models.TujuanPembelajaran) []TujuanPembelajaranResponse {
+	response := []TujuanPembelajaranResponse{}
+	for _, item := range tujuan {
+		response = append(response, TujuanPembelajaranResponse{
+			ID:                  item.ID,
+			MataPelajaranID:     item.MataPelajaranID,
+			Tingkat:             item.Tingkat,
+			Kode:                item.Kode,
+			CapaianPembelajaran: item.CapaianPembelajaran,
+			Deskripsi:           item.Deskripsi,
+			Updated:             item.Updated,
+		})
+	}
+	return response
+}
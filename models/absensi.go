@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Status kehadiran siswa pada satu pertemuan.
+const (
+	AbsensiHadir = "hadir"
+	AbsensiIzin  = "izin"
+	AbsensiSakit = "sakit"
+	AbsensiAlpa  = "alpa"
+)
+
+// SesiAbsensi adalah satu pertemuan (slot jadwal pada satu tanggal) yang kehadirannya dicatat.
+// Kelas, mapel, dan jam slot disalin saat sesi dibuka sehingga riwayat kehadiran tidak berubah jika
+// slot jadwal kemudian diubah atau dihapus; JadwalID kosong jika slotnya sudah dihapus.
+// GuruID adalah guru yang terakhir mengisi; kosong jika diisi admin. Selama check-in QR dibuka,
+// KodeCheckIn berisi kunci rahasia penanda token QR dan CheckInSampai batas waktunya.
+type SesiAbsensi struct {
+	ID              int        `db:"id"`
+	JadwalID        *int       `db:"jadwal_id"`
+	Tanggal         time.Time  `db:"tanggal"`
+	KelasID         int        `db:"kelas_id"`
+	MataPelajaranID int        `db:"mata_pelajaran_id"`
+	JamMulai        time.Time  `db:"jam_mulai"`
+	JamSelesai      time.Time  `db:"jam_selesai"`
+	GuruID          *int       `db:"guru_id"`
+	KodeCheckIn     *string    `db:"kode_check_in"`
+	CheckInSampai   *time.Time `db:"check_in_sampai"`
+	Created         time.Time  `db:"created"`
+	Updated         time.Time  `db:"updated"`
+}
+
+// Absensi adalah status kehadiran satu siswa pada satu sesi absensi.
+type Absensi struct {
+	SesiID  int       `db:"sesi_id"`
+	SiswaID int       `db:"siswa_id"`
+	Status  string    `db:"status"`
+	Catatan *string   `db:"catatan"`
+	Updated time.Time `db:"updated"`
+}
+
+// StatusAbsensiValid memeriksa apakah status termasuk hadir, izin, sakit, atau alpa.
+func StatusAbsensiValid(status string) bool {
+	switch status {
+	case AbsensiHadir, AbsensiIzin, AbsensiSakit, AbsensiAlpa:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+type BandingNilai struct {
+	ID           int        `db:"id"`
+	SiswaID      int        `db:"siswa_id"`
+	Jenis        string     `db:"jenis"`
+	HasilTugasID *int       `db:"hasil_tugas_id"`
+	HasilQuizID  *int       `db:"hasil_quiz_id"`
+	NilaiLama    *float64   `db:"nilai_lama"`
+	NilaiBaru    *float64   `db:"nilai_baru"`
+	Alasan       string     `db:"alasan"`
+	Tanggapan    *string    `db:"tanggapan"`
+	Status       string     `db:"status"`
+	GuruID       *int       `db:"guru_id"`
+	Diproses     *time.Time `db:"diproses"`
+	Created      time.Time  `db:"created"`
+	Updated      time.Time  `db:"updated"`
+}
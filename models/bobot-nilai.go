@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// BobotNilai menyimpan konfigurasi perhitungan rekap nilai untuk satu mata pelajaran.
+type BobotNilai struct {
+	ID              int       `db:"id"`
+	MataPelajaranID int       `db:"mata_pelajaran_id"`
+	BobotTugas      float64   `db:"bobot_tugas"`
+	BobotQuiz       float64   `db:"bobot_quiz"`
+	TugasDibuang    int       `db:"tugas_dibuang"`
+	QuizDibuang     int       `db:"quiz_dibuang"`
+	Created         time.Time `db:"created"`
+	Updated         time.Time `db:"updated"`
+}
+
+type BobotKategori struct {
+	MataPelajaranID int     `db:"mata_pelajaran_id"`
+	Kategori        string  `db:"kategori"`
+	Bobot           float64 `db:"bobot"`
+}
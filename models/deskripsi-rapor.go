@@ -0,0 +1,29 @@
+package models
+
+import "time"
+
+// TemplateDeskripsi adalah pola kalimat deskripsi capaian untuk rentang nilai tertentu.
+// MataPelajaranID dan Kompetensi yang kosong berarti template berlaku umum.
+type TemplateDeskripsi struct {
+	ID              int       `db:"id"`
+	MataPelajaranID *int      `db:"mata_pelajaran_id"`
+	Kompetensi      *string   `db:"kompetensi"`
+	NilaiMin        float64   `db:"nilai_min"`
+	NilaiMax        float64   `db:"nilai_max"`
+	Template        string    `db:"template"`
+	Created         time.Time `db:"created"`
+	Updated         time.Time `db:"updated"`
+}
+
+// DeskripsiRapor adalah deskripsi capaian satu siswa untuk satu mapel yang dicetak di rapor.
+type DeskripsiRapor struct {
+	ID              int        `db:"id"`
+	SiswaID         int        `db:"siswa_id"`
+	MataPelajaranID int        `db:"mata_pelajaran_id"`
+	KelasID         int        `db:"kelas_id"`
+	Deskripsi       string     `db:"deskripsi"`
+	IsFinal         bool       `db:"is_final"`
+	Difinalkan      *time.Time `db:"difinalkan"`
+	Created         time.Time  `db:"created"`
+	Updated         time.Time  `db:"updated"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// KetidakhadiranGuru mencatat guru yang berhalangan mengajar pada rentang tanggal (inklusif).
+type KetidakhadiranGuru struct {
+	ID             int       `db:"id"`
+	GuruID         int       `db:"guru_id"`
+	TanggalMulai   time.Time `db:"tanggal_mulai"`
+	TanggalSelesai time.Time `db:"tanggal_selesai"`
+	Alasan         *string   `db:"alasan"`
+	Created        time.Time `db:"created"`
+}
+
+// GuruPengganti adalah satu pertemuan (slot jadwal pada satu tanggal) yang ditinggalkan guru yang
+// berhalangan. GuruPenggantiID kosong berarti pengganti belum ditetapkan.
+type GuruPengganti struct {
+	ID               int       `db:"id"`
+	KetidakhadiranID int       `db:"ketidakhadiran_id"`
+	JadwalID         int       `db:"jadwal_id"`
+	Tanggal          time.Time `db:"tanggal"`
+	GuruPenggantiID  *int      `db:"guru_pengganti_id"`
+	Updated          time.Time `db:"updated"`
+}
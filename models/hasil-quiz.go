@@ -3,12 +3,13 @@ package models
 import "time"
 
 type HasilQuiz struct {
-	ID                int       `json:"id"`
-	QuizID            int       `json:"quiz_id"`
-	SiswaID           int       `json:"siswa_id"`
-	Nilai             float64   `json:"nilai"`
-	TanggalPengerjaan time.Time `json:"tanggal_pengerjaan"`
-	Status            string    `json:"status"`
-	Created           time.Time `json:"created"`
-	Updated           time.Time `json:"updated"`
+	ID                int       `db:"id"`
+	QuizID            int       `db:"quiz_id"`
+	SiswaID           int       `db:"siswa_id"`
+	Nilai             float64   `db:"nilai"`
+	TanggalPengerjaan time.Time `db:"tanggal_pengerjaan"`
+	Status            string    `db:"status"`
+	KurvaID           *int      `db:"kurva_id"`
+	Created           time.Time `db:"created"`
+	Updated           time.Time `db:"updated"`
 }
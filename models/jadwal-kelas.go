@@ -2,13 +2,17 @@ package models
 
 import "time"
 
+// JadwalKelas adalah satu slot jadwal pelajaran mingguan sebuah kelas beserta guru pengajar dan
+// ruangnya. JamMulai dan JamSelesai hanya memakai bagian jam dan menit.
 type JadwalKelas struct {
-	ID              int       `json:"id"`
-	KelasID         int       `json:"kelas_id"`
-	MataPelajaranID int       `json:"mata_pelajaran_id"`
-	Hari            string    `json:"hari"`
-	JamMulai        time.Time `json:"jam_mulai"`
-	JamSelesai      time.Time `json:"jam_selesai"`
-	Created         time.Time `json:"created"`
-	Updated         time.Time `json:"updated"`
+	ID              int       `db:"id"`
+	KelasID         int       `db:"kelas_id"`
+	MataPelajaranID int       `db:"mata_pelajaran_id"`
+	Hari            string    `db:"hari"`
+	JamMulai        time.Time `db:"jam_mulai"`
+	JamSelesai      time.Time `db:"jam_selesai"`
+	GuruID          *int      `db:"guru_id"`
+	Ruang           *string   `db:"ruang"`
+	Created         time.Time `db:"created"`
+	Updated         time.Time `db:"updated"`
 }
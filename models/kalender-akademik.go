@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// Jenis agenda akademik.
+const (
+	AgendaLiburNasional = "libur_nasional"
+	AgendaLiburSekolah  = "libur_sekolah"
+	AgendaUjian         = "ujian"
+)
+
+// TahunAjaran adalah satu tahun pelajaran, misalnya "2026/2027". Hanya satu yang Aktif.
+type TahunAjaran struct {
+	ID             int       `db:"id"`
+	Nama           string    `db:"nama"`
+	TanggalMulai   time.Time `db:"tanggal_mulai"`
+	TanggalSelesai time.Time `db:"tanggal_selesai"`
+	Aktif          bool      `db:"aktif"`
+	Created        time.Time `db:"created"`
+	Updated        time.Time `db:"updated"`
+}
+
+// Semester adalah bagian tahun ajaran ("Ganjil"/"Genap") yang rentangnya berada di dalam tahun ajaran.
+type Semester struct {
+	ID             int       `db:"id"`
+	TahunAjaranID  int       `db:"tahun_ajaran_id"`
+	Nama           string    `db:"nama"`
+	TanggalMulai   time.Time `db:"tanggal_mulai"`
+	TanggalSelesai time.Time `db:"tanggal_selesai"`
+	Created        time.Time `db:"created"`
+	Updated        time.Time `db:"updated"`
+}
+
+// AgendaAkademik adalah libur nasional, libur sekolah, atau pekan ujian pada rentang tanggal (inklusif).
+type AgendaAkademik struct {
+	ID             int       `db:"id"`
+	Jenis          string    `db:"jenis"`
+	Nama           string    `db:"nama"`
+	TanggalMulai   time.Time `db:"tanggal_mulai"`
+	TanggalSelesai time.Time `db:"tanggal_selesai"`
+	Keterangan     *string   `db:"keterangan"`
+	Created        time.Time `db:"created"`
+	Updated        time.Time `db:"updated"`
+}
+
+// Libur menandai agenda yang meniadakan kegiatan belajar.
+func (a *AgendaAkademik) Libur() bool {
+	return a.Jenis == AgendaLiburNasional || a.Jenis == AgendaLiburSekolah
+}
@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type KelompokTugas struct {
+	ID      int       `db:"id"`
+	TugasID int       `db:"tugas_id"`
+	Nama    string    `db:"nama"`
+	Created time.Time `db:"created"`
+	Updated time.Time `db:"updated"`
+}
+
+type AnggotaKelompok struct {
+	KelompokID int `db:"kelompok_id"`
+	SiswaID    int `db:"siswa_id"`
+}
@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// KKM adalah Kriteria Ketuntasan Minimal untuk satu mata pelajaran di satu tingkat.
+type KKM struct {
+	ID              int       `db:"id"`
+	MataPelajaranID int       `db:"mata_pelajaran_id"`
+	Tingkat         int       `db:"tingkat"`
+	Nilai           float64   `db:"nilai"`
+	Created         time.Time `db:"created"`
+	Updated         time.Time `db:"updated"`
+}
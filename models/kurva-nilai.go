@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// KurvaNilai adalah kurva yang diterapkan pada nilai satu tugas/quiz (Jenis "tugas"/"quiz").
+// Metode bernilai "linear" (skala ke rata-rata target), "akar" (10 x akar nilai), atau "minimum".
+// Kurva yang dibatalkan tetap tersimpan bersama nilai mentah yang tidak dapat dipulihkan.
+type KurvaNilai struct {
+	ID         int        `db:"id"`
+	Jenis      string     `db:"jenis"`
+	ItemID     int        `db:"item_id"`
+	Metode     string     `db:"metode"`
+	Parameter  *float64   `db:"parameter"`
+	GuruID     int        `db:"guru_id"`
+	Dibatalkan *time.Time `db:"dibatalkan"`
+	Created    time.Time  `db:"created"`
+}
+
+// NilaiMentahKurva menyimpan nilai asli siswa sebelum kurva diterapkan agar kurva dapat dibatalkan.
+type NilaiMentahKurva struct {
+	KurvaID     int     `db:"kurva_id"`
+	SiswaID     int     `db:"siswa_id"`
+	NilaiMentah float64 `db:"nilai_mentah"`
+	NilaiKurva  float64 `db:"nilai_kurva"`
+}
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// Notifikasi adalah pemberitahuan untuk satu pengguna, dikenali dari Role dan UserID karena admin,
+// guru, dan siswa disimpan di tabel yang berbeda.
+type Notifikasi struct {
+	ID      int       `db:"id"`
+	Role    string    `db:"role"`
+	UserID  int       `db:"user_id"`
+	Judul   string    `db:"judul"`
+	Pesan   string    `db:"pesan"`
+	Dibaca  bool      `db:"dibaca"`
+	Created time.Time `db:"created"`
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// OrangTua adalah akun wali murid yang terhubung ke satu atau beberapa siswa.
+type OrangTua struct {
+	ID       int       `db:"id"`
+	Nama     string    `db:"nama"`
+	Email    string    `db:"email"`
+	Password string    `db:"password"`
+	NoHp     string    `db:"no_hp"`
+	Created  time.Time `db:"created"`
+	Updated  time.Time `db:"updated"`
+}
+
+func (o *OrangTua) GetID() int {
+	return o.ID
+}
+
+func (o *OrangTua) GetEmail() string {
+	return o.Email
+}
+
+func (o *OrangTua) GetRole() string {
+	return "orang tua"
+}
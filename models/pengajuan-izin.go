@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// PengajuanIzin adalah permohonan izin atau sakit seorang siswa untuk rentang tanggal (inklusif).
+// Diajukan oleh siswa sendiri atau orang tuanya (DiajukanRole/DiajukanID), lalu diproses wali kelas.
+type PengajuanIzin struct {
+	ID             int        `db:"id"`
+	SiswaID        int        `db:"siswa_id"`
+	Jenis          string     `db:"jenis"`
+	TanggalMulai   time.Time  `db:"tanggal_mulai"`
+	TanggalSelesai time.Time  `db:"tanggal_selesai"`
+	Alasan         string     `db:"alasan"`
+	SuratDokterUrl *string    `db:"surat_dokter_url"`
+	DiajukanRole   string     `db:"diajukan_role"`
+	DiajukanID     int        `db:"diajukan_id"`
+	Status         string     `db:"status"`
+	Tanggapan      *string    `db:"tanggapan"`
+	GuruID         *int       `db:"guru_id"`
+	Diproses       *time.Time `db:"diproses"`
+	Created        time.Time  `db:"created"`
+	Updated        time.Time  `db:"updated"`
+}
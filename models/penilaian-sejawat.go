@@ -0,0 +1,32 @@
+package models
+
+import "time"
+
+type RubrikTugas struct {
+	ID                   int       `db:"id"`
+	TugasID              int       `db:"tugas_id"`
+	Kriteria             string    `db:"kriteria"`
+	Deskripsi            string    `db:"deskripsi"`
+	Bobot                float64   `db:"bobot"`
+	TujuanPembelajaranID *int      `db:"tujuan_pembelajaran_id"`
+	Created              time.Time `db:"created"`
+	Updated              time.Time `db:"updated"`
+}
+
+type PenilaianSejawat struct {
+	ID           int       `db:"id"`
+	TugasID      int       `db:"tugas_id"`
+	HasilTugasID int       `db:"hasil_tugas_id"`
+	PenilaiID    int       `db:"penilai_id"`
+	Nilai        *float64  `db:"nilai"`
+	Komentar     *string   `db:"komentar"`
+	Status       string    `db:"status"`
+	Created      time.Time `db:"created"`
+	Updated      time.Time `db:"updated"`
+}
+
+type SkorRubrikSejawat struct {
+	PenilaianID int     `db:"penilaian_id"`
+	RubrikID    int     `db:"rubrik_id"`
+	Skor        float64 `db:"skor"`
+}
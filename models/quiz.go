@@ -3,11 +3,20 @@ package models
 import "time"
 
 type Quiz struct {
-	ID              int       `json:"id"`
-	Judul           string    `json:"judul"`
-	Deskripsi       string    `json:"deskripsi"`
-	MataPelajaranID int       `json:"mata_pelajaran_id"`
-	KelasID         int       `json:"kelas_id"`
-	Created         time.Time `json:"created"`
-	Updated         time.Time `json:"updated"`
+	ID              int        `db:"id"`
+	Judul           string     `db:"judul"`
+	Deskripsi       string     `db:"deskripsi"`
+	MataPelajaranID int        `db:"mata_pelajaran_id"`
+	KelasID         int        `db:"kelas_id"`
+	WaktuMulai      *time.Time `db:"waktu_mulai"`
+	WaktuSelesai    *time.Time `db:"waktu_selesai"`
+	StatusNilai     string     `db:"status_nilai"`
+	CatatanModerasi *string    `db:"catatan_moderasi"`
+	Created         time.Time  `db:"created"`
+	Updated         time.Time  `db:"updated"`
+}
+
+// NilaiTerbit menentukan apakah nilai quiz ini sudah boleh dilihat siswa.
+func (q *Quiz) NilaiTerbit() bool {
+	return q.StatusNilai == "terbit"
 }
@@ -3,13 +3,13 @@ package models
 import "time"
 
 type RekapNilai struct {
-	ID              int       `json:"id"`
-	SiswaID         int       `json:"siswa_id"`
-	MataPelajaranID int       `json:"mata_pelajaran_id"`
-	KelasID         int       `json:"kelas_id"`
-	NilaiQuiz       float64   `json:"nilai_quiz"`
-	NilaiTugas      float64   `json:"nilai_tugas"`
-	NilaiAkhir      float64   `json:"nilai_akhir"`
-	Created         time.Time `json:"created"`
-	Updated         time.Time `json:"updated"`
+	ID              int       `db:"id"`
+	SiswaID         int       `db:"siswa_id"`
+	MataPelajaranID int       `db:"mata_pelajaran_id"`
+	KelasID         int       `db:"kelas_id"`
+	NilaiQuiz       float64   `db:"nilai_quiz"`
+	NilaiTugas      float64   `db:"nilai_tugas"`
+	NilaiAkhir      float64   `db:"nilai_akhir"`
+	Created         time.Time `db:"created"`
+	Updated         time.Time `db:"updated"`
 }
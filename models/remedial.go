@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Remedial menghubungkan tugas/quiz remedial (ItemID) dengan tugas/quiz asal (AsalID)
+// beserta kebijakan penggantian nilainya: "ganti", "maksimum", atau "batas_kkm".
+type Remedial struct {
+	ID        int       `db:"id"`
+	Jenis     string    `db:"jenis"`
+	AsalID    int       `db:"asal_id"`
+	ItemID    int       `db:"item_id"`
+	Kebijakan string    `db:"kebijakan"`
+	Created   time.Time `db:"created"`
+	Updated   time.Time `db:"updated"`
+}
+
+type PesertaRemedial struct {
+	RemedialID    int        `db:"remedial_id"`
+	SiswaID       int        `db:"siswa_id"`
+	NilaiAwal     *float64   `db:"nilai_awal"`
+	NilaiRemedial *float64   `db:"nilai_remedial"`
+	NilaiAkhir    *float64   `db:"nilai_akhir"`
+	Diterapkan    *time.Time `db:"diterapkan"`
+}
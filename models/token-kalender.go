@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// TokenKalender adalah token rahasia feed ICS milik satu pengguna (Role "guru"/"siswa"). Hanya
+// hash SHA-256 token yang disimpan; token asli hanya ditampilkan saat dibuat.
+type TokenKalender struct {
+	ID        int       `db:"id"`
+	Role      string    `db:"role"`
+	UserID    int       `db:"user_id"`
+	TokenHash string    `db:"token_hash"`
+	Created   time.Time `db:"created"`
+}
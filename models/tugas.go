@@ -3,12 +3,35 @@ package models
 import "time"
 
 type Tugas struct {
-	ID              int       `db:"id"`
-	Judul           string    `db:"judul"`
-	Deskripsi       string    `db:"deskripsi"`
-	MataPelajaranID int       `db:"mata_pelajaran_id"`
-	KelasID         int       `db:"kelas_id"`
-	Deadline        time.Time `db:"deadline"`
-	Created         time.Time `db:"created"`
-	Updated         time.Time `db:"updated"`
+	ID                   int        `db:"id"`
+	Judul                string     `db:"judul"`
+	Deskripsi            string     `db:"deskripsi"`
+	MataPelajaranID      int        `db:"mata_pelajaran_id"`
+	KelasID              int        `db:"kelas_id"`
+	Deadline             time.Time  `db:"deadline"`
+	Kategori             *string    `db:"kategori"`
+	IsDraft              bool       `db:"is_draft"`
+	PublishAt            *time.Time `db:"publish_at"`
+	IsKelompok           bool       `db:"is_kelompok"`
+	PeerReview           bool       `db:"peer_review"`
+	JumlahPenilai        int        `db:"jumlah_penilai"`
+	BobotPeerReview      float64    `db:"bobot_peer_review"`
+	PeerReviewDiterapkan bool       `db:"peer_review_diterapkan"`
+	StatusNilai          string     `db:"status_nilai"`
+	CatatanModerasi      *string    `db:"catatan_moderasi"`
+	Created              time.Time  `db:"created"`
+	Updated              time.Time  `db:"updated"`
+}
+
+// IsPublished menentukan apakah tugas sudah boleh dilihat siswa pada waktu now.
+func (t *Tugas) IsPublished(now time.Time) bool {
+	if t.IsDraft {
+		return false
+	}
+	return t.PublishAt == nil || !t.PublishAt.After(now)
+}
+
+// NilaiTerbit menentukan apakah nilai dan feedback tugas ini sudah boleh dilihat siswa.
+func (t *Tugas) NilaiTerbit() bool {
+	return t.StatusNilai == "terbit"
 }
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+// TujuanPembelajaran adalah tujuan pembelajaran (TP) Kurikulum Merdeka untuk satu mapel dan tingkat,
+// beserta capaian pembelajaran (CP) yang menaunginya.
+type TujuanPembelajaran struct {
+	ID                  int       `db:"id"`
+	MataPelajaranID     int       `db:"mata_pelajaran_id"`
+	Tingkat             int       `db:"tingkat"`
+	Kode                string    `db:"kode"`
+	CapaianPembelajaran string    `db:"capaian_pembelajaran"`
+	Deskripsi           string    `db:"deskripsi"`
+	Created             time.Time `db:"created"`
+	Updated             time.Time `db:"updated"`
+}
+
+// TagTujuanPembelajaran menautkan satu tugas atau quiz (Jenis "tugas"/"quiz") ke tujuan pembelajaran.
+type TagTujuanPembelajaran struct {
+	Jenis    string `db:"jenis"`
+	ItemID   int    `db:"item_id"`
+	TujuanID int    `db:"tujuan_id"`
+}
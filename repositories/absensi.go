@@ -0,0 +1,323 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrSiswaBukanAnggotaKelas dikembalikan saat absensi diisi untuk siswa yang tidak terdaftar di
+	// kelas pada sesi tersebut.
+	ErrSiswaBukanAnggotaKelas = errors.New("siswa bukan anggota kelas sesi absensi")
+	// ErrSudahAbsen dikembalikan saat siswa check-in pada sesi yang kehadirannya sudah tercatat.
+	ErrSudahAbsen = errors.New("kehadiran siswa sudah tercatat")
+)
+
+// SesiAbsensiDetail adalah sesi absensi beserta nama kelas dan mapelnya.
+type SesiAbsensiDetail struct {
+	models.SesiAbsensi
+	NamaKelas string `db:"nama_kelas"`
+	NamaMapel string `db:"nama_mapel"`
+}
+
+// AbsensiSiswa adalah satu baris daftar hadir sesi. Status kosong berarti siswa belum diabsen.
+type AbsensiSiswa struct {
+	SiswaID int     `db:"siswa_id"`
+	Nama    string  `db:"nama"`
+	Status  *string `db:"status"`
+	Catatan *string `db:"catatan"`
+}
+
+// RekapAbsensi adalah jumlah pertemuan per status kehadiran seorang siswa.
+type RekapAbsensi struct {
+	SiswaID int    `db:"siswa_id"`
+	Nama    string `db:"nama"`
+	Hadir   int    `db:"hadir"`
+	Izin    int    `db:"izin"`
+	Sakit   int    `db:"sakit"`
+	Alpa    int    `db:"alpa"`
+}
+
+// RiwayatAbsensi adalah status kehadiran siswa pada satu pertemuan.
+type RiwayatAbsensi struct {
+	SesiID          int       `db:"sesi_id"`
+	Tanggal         time.Time `db:"tanggal"`
+	KelasID         int       `db:"kelas_id"`
+	MataPelajaranID int       `db:"mata_pelajaran_id"`
+	NamaMapel       string    `db:"nama_mapel"`
+	JamMulai        time.Time `db:"jam_mulai"`
+	JamSelesai      time.Time `db:"jam_selesai"`
+	Status          string    `db:"status"`
+	Catatan         *string   `db:"catatan"`
+}
+
+type AbsensiRepository interface {
+	BukaSesi(ctx context.Context, sesi *models.SesiAbsensi) error
+	GetSesiByID(ctx context.Context, id int) (*SesiAbsensiDetail, error)
+	GetDaftarHadir(ctx context.Context, sesiID int) ([]AbsensiSiswa, error)
+	SimpanAbsensi(ctx context.Context, sesiID int, guruID *int, absensi []models.Absensi) error
+	SetCheckIn(ctx context.Context, sesiID int, kode *string, sampai *time.Time) error
+	CheckIn(ctx context.Context, sesiID int, siswaID int) error
+	BolehMengisi(ctx context.Context, guruID int, jadwalID int, tanggal time.Time) (bool, error)
+	GetRekapKelas(ctx context.Context, kelasID int, mulai time.Time, selesai time.Time) ([]RekapAbsensi, error)
+	GetRiwayatSiswa(ctx context.Context, siswaID int, mulai time.Time, selesai time.Time) ([]RiwayatAbsensi, error)
+}
+
+type absensiRepository struct {
+	db *sqlx.DB
+}
+
+func NewAbsensiRepository(db *sqlx.DB) AbsensiRepository {
+	return &absensiRepository{db: db}
+}
+
+// BukaSesi membuat sesi absensi untuk slot dan tanggal tersebut dengan menyalin kelas, mapel, dan jam
+// slotnya, atau mengambil sesi yang sudah ada sehingga dua guru yang membuka pertemuan yang sama
+// mendapat sesi yang sama. Pengajuan izin yang sudah disetujui pada tanggal itu hanya diisikan ke
+// absensi saat sesi baru dibuat, agar koreksi guru pada sesi yang sudah ada tidak tertimpa.
+// Mengembalikan sql.ErrNoRows jika slot tidak ada.
+func (r *absensiRepository) BukaSesi(ctx context.Context, sesi *models.SesiAbsensi) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO sesi_absensi (jadwal_id, tanggal, guru_id, kelas_id, mata_pelajaran_id, jam_mulai, jam_selesai)
+        SELECT id, $2, $3, kelas_id, mata_pelajaran_id, jam_mulai, jam_selesai
+        FROM jadwal_kelas WHERE id = $1
+        ON CONFLICT (jadwal_id, tanggal) DO UPDATE SET jadwal_id = EXCLUDED.jadwal_id
+        RETURNING id, guru_id, kelas_id, mata_pelajaran_id, jam_mulai, jam_selesai, created, updated, (xmax = 0) AS baru
+    `
+	var baru bool
+	err = tx.QueryRowxContext(ctx, query, sesi.JadwalID, formatTanggal(sesi.Tanggal), sesi.GuruID).
+		Scan(&sesi.ID, &sesi.GuruID, &sesi.KelasID, &sesi.MataPelajaranID, &sesi.JamMulai, &sesi.JamSelesai, &sesi.Created, &sesi.Updated, &baru)
+	if err != nil {
+		return err
+	}
+
+	if baru {
+		if _, err := terapkanPengajuanIzin(ctx, tx, 0, sesi.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *absensiRepository) GetSesiByID(ctx context.Context, id int) (*SesiAbsensiDetail, error) {
+	var sesi SesiAbsensiDetail
+	query := `
+		SELECT sa.*, k.name AS nama_kelas, mp.nama AS nama_mapel
+		FROM sesi_absensi sa
+		JOIN kelas k ON sa.kelas_id = k.id
+		JOIN mata_pelajaran mp ON sa.mata_pelajaran_id = mp.id
+		WHERE sa.id = $1
+	`
+	err := r.db.GetContext(ctx, &sesi, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &sesi, nil
+}
+
+// GetDaftarHadir mengambil seluruh siswa kelas pada sesi tersebut beserta status kehadirannya.
+func (r *absensiRepository) GetDaftarHadir(ctx context.Context, sesiID int) ([]AbsensiSiswa, error) {
+	var results []AbsensiSiswa
+	query := `
+		SELECT s.id AS siswa_id, s.nama, a.status, a.catatan
+		FROM sesi_absensi sa
+		JOIN siswa s ON s.kelas_id = sa.kelas_id
+		LEFT JOIN absensi a ON a.sesi_id = sa.id AND a.siswa_id = s.id
+		WHERE sa.id = $1
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, sesiID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// SimpanAbsensi menyimpan status kehadiran banyak siswa sekaligus dalam satu transaksi. Status siswa
+// yang sudah tercatat ditimpa. Jika salah satu siswa bukan anggota kelas sesi, seluruh perubahan
+// dibatalkan dengan ErrSiswaBukanAnggotaKelas.
+func (r *absensiRepository) SimpanAbsensi(ctx context.Context, sesiID int, guruID *int, absensi []models.Absensi) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, "UPDATE sesi_absensi SET guru_id = $1, updated = NOW() WHERE id = $2", guruID, sesiID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	for _, item := range absensi {
+		query := `
+            INSERT INTO absensi (sesi_id, siswa_id, status, catatan)
+            SELECT sa.id, s.id, $3, $4
+            FROM sesi_absensi sa
+            JOIN siswa s ON s.kelas_id = sa.kelas_id
+            WHERE sa.id = $1 AND s.id = $2
+            ON CONFLICT (sesi_id, siswa_id) DO UPDATE SET
+                status = EXCLUDED.status,
+                catatan = EXCLUDED.catatan,
+                updated = NOW()
+        `
+		result, err := tx.ExecContext(ctx, query, sesiID, item.SiswaID, item.Status, item.Catatan)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			return ErrSiswaBukanAnggotaKelas
+		}
+	}
+
+	return tx.Commit()
+}
+
+// SetCheckIn membuka check-in QR dengan kunci dan batas waktunya, atau menutupnya jika keduanya nil.
+func (r *absensiRepository) SetCheckIn(ctx context.Context, sesiID int, kode *string, sampai *time.Time) error {
+	query := "UPDATE sesi_absensi SET kode_check_in = $1, check_in_sampai = $2, updated = NOW() WHERE id = $3"
+	result, err := r.db.ExecContext(ctx, query, kode, sampai, sesiID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// CheckIn mencatat siswa hadir melalui check-in QR. Status yang sudah diisi guru tidak ditimpa;
+// siswa yang sudah tercatat mendapat ErrSudahAbsen.
+func (r *absensiRepository) CheckIn(ctx context.Context, sesiID int, siswaID int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var anggota bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM sesi_absensi sa
+			JOIN siswa s ON s.kelas_id = sa.kelas_id
+			WHERE sa.id = $1 AND s.id = $2
+		)
+	`
+	if err := tx.GetContext(ctx, &anggota, query, sesiID, siswaID); err != nil {
+		return err
+	}
+	if !anggota {
+		return ErrSiswaBukanAnggotaKelas
+	}
+
+	query = `
+        INSERT INTO absensi (sesi_id, siswa_id, status)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (sesi_id, siswa_id) DO NOTHING
+    `
+	result, err := tx.ExecContext(ctx, query, sesiID, siswaID, models.AbsensiHadir)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrSudahAbsen
+	}
+
+	return tx.Commit()
+}
+
+// BolehMengisi memeriksa apakah guru mengajar slot tersebut atau menjadi pengganti pada tanggalnya.
+func (r *absensiRepository) BolehMengisi(ctx context.Context, guruID int, jadwalID int, tanggal time.Time) (bool, error) {
+	var boleh bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM jadwal_kelas WHERE id = $2 AND guru_id = $1
+		) OR EXISTS (
+			SELECT 1 FROM guru_pengganti WHERE jadwal_id = $2 AND tanggal = $3::date AND guru_pengganti_id = $1
+		)
+	`
+	err := r.db.GetContext(ctx, &boleh, query, guruID, jadwalID, formatTanggal(tanggal))
+	if err != nil {
+		return false, err
+	}
+	return boleh, nil
+}
+
+// GetRekapKelas menghitung status kehadiran setiap siswa kelas pada sesi absensi kelas itu dalam
+// rentang [mulai, selesai]. Siswa yang belum pernah diabsen tetap tercantum dengan jumlah nol.
+func (r *absensiRepository) GetRekapKelas(ctx context.Context, kelasID int, mulai time.Time, selesai time.Time) ([]RekapAbsensi, error) {
+	var results []RekapAbsensi
+	query := `
+		SELECT
+			s.id AS siswa_id, s.nama,
+			COUNT(*) FILTER (WHERE a.status = 'hadir') AS hadir,
+			COUNT(*) FILTER (WHERE a.status = 'izin') AS izin,
+			COUNT(*) FILTER (WHERE a.status = 'sakit') AS sakit,
+			COUNT(*) FILTER (WHERE a.status = 'alpa') AS alpa
+		FROM siswa s
+		LEFT JOIN (
+			SELECT a.siswa_id, a.status
+			FROM absensi a
+			JOIN sesi_absensi sa ON a.sesi_id = sa.id
+			WHERE sa.kelas_id = $1 AND sa.tanggal BETWEEN $2::date AND $3::date
+		) a ON a.siswa_id = s.id
+		WHERE s.kelas_id = $1
+		GROUP BY s.id, s.nama
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, formatTanggal(mulai), formatTanggal(selesai))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetRiwayatSiswa mengambil status kehadiran siswa per pertemuan dalam rentang [mulai, selesai].
+func (r *absensiRepository) GetRiwayatSiswa(ctx context.Context, siswaID int, mulai time.Time, selesai time.Time) ([]RiwayatAbsensi, error) {
+	var results []RiwayatAbsensi
+	query := `
+		SELECT
+			sa.id AS sesi_id, sa.tanggal, sa.kelas_id, sa.mata_pelajaran_id, mp.nama AS nama_mapel,
+			sa.jam_mulai, sa.jam_selesai, a.status, a.catatan
+		FROM absensi a
+		JOIN sesi_absensi sa ON a.sesi_id = sa.id
+		JOIN mata_pelajaran mp ON sa.mata_pelajaran_id = mp.id
+		WHERE a.siswa_id = $1 AND sa.tanggal BETWEEN $2::date AND $3::date
+		ORDER BY sa.tanggal ASC, sa.jam_mulai ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, siswaID, formatTanggal(mulai), formatTanggal(selesai))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,204 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrBandingSudahDiproses dikembalikan saat banding yang akan diputuskan tidak lagi menunggu,
+// misalnya karena sudah diputuskan lebih dulu oleh guru lain.
+var ErrBandingSudahDiproses = errors.New("banding sudah diproses")
+
+type BandingNilaiSiswa struct {
+	models.BandingNilai
+	NamaSiswa string `db:"nama_siswa"`
+	Judul     string `db:"judul"`
+}
+
+type BandingNilaiRepository interface {
+	Create(ctx context.Context, banding *models.BandingNilai) error
+	GetByID(ctx context.Context, id int) (*models.BandingNilai, error)
+	HasPending(ctx context.Context, jenis string, hasilID int) (bool, error)
+	GetAllBySiswaID(ctx context.Context, siswaID int) ([]BandingNilaiSiswa, error)
+	GetAllByGuruID(ctx context.Context, guruID int, status string) ([]BandingNilaiSiswa, error)
+	BolehMemproses(ctx context.Context, id int, guruID int) (bool, error)
+	Terima(ctx context.Context, banding *models.BandingNilai, guruID int, nilaiBaru float64, tanggapan *string) error
+	Tolak(ctx context.Context, id int, guruID int, tanggapan string) error
+}
+
+type bandingNilaiRepository struct {
+	db *sqlx.DB
+}
+
+func NewBandingNilaiRepository(db *sqlx.DB) BandingNilaiRepository {
+	return &bandingNilaiRepository{db: db}
+}
+
+// bandingNilaiSelect menggabungkan banding dengan nama siswa dan judul tugas/quiz yang dibanding.
+const bandingNilaiSelect = `
+	SELECT
+		b.*,
+		s.nama AS nama_siswa,
+		COALESCE(t.judul, q.judul, '') AS judul
+	FROM banding_nilai b
+	JOIN siswa s ON b.siswa_id = s.id
+	LEFT JOIN hasil_tugas ht ON b.hasil_tugas_id = ht.id
+	LEFT JOIN tugas t ON ht.tugas_id = t.id
+	LEFT JOIN hasil_quiz hq ON b.hasil_quiz_id = hq.id
+	LEFT JOIN quiz q ON hq.quiz_id = q.id
+`
+
+// bandingPemrosesFilter membatasi banding ke tugas/quiz yang boleh diputuskan guru $1, yaitu
+// pengampu tugas/quiz tersebut. Dipakai setelah bandingNilaiSelect.
+var bandingPemrosesFilter = pengampuFilter("$1", "COALESCE(t.kelas_id, q.kelas_id)", "COALESCE(t.mata_pelajaran_id, q.mata_pelajaran_id)")
+
+func (r *bandingNilaiRepository) Create(ctx context.Context, banding *models.BandingNilai) error {
+	query := `
+        INSERT INTO banding_nilai (siswa_id, jenis, hasil_tugas_id, hasil_quiz_id, nilai_lama, alasan, status)
+        VALUES (:siswa_id, :jenis, :hasil_tugas_id, :hasil_quiz_id, :nilai_lama, :alasan, :status)
+    `
+	_, err := r.db.NamedExecContext(ctx, query, banding)
+	return err
+}
+
+func (r *bandingNilaiRepository) GetByID(ctx context.Context, id int) (*models.BandingNilai, error) {
+	var banding models.BandingNilai
+	query := "SELECT * FROM banding_nilai WHERE id = $1"
+	err := r.db.GetContext(ctx, &banding, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &banding, nil
+}
+
+// HasPending memeriksa apakah masih ada banding yang belum diproses untuk hasil tugas/quiz tertentu.
+func (r *bandingNilaiRepository) HasPending(ctx context.Context, jenis string, hasilID int) (bool, error) {
+	var exists bool
+	column := "hasil_tugas_id"
+	if jenis == "quiz" {
+		column = "hasil_quiz_id"
+	}
+	query := "SELECT EXISTS (SELECT 1 FROM banding_nilai WHERE " + column + " = $1 AND status = 'menunggu')"
+	err := r.db.GetContext(ctx, &exists, query, hasilID)
+	return exists, err
+}
+
+// GetAllBySiswaID mengambil seluruh riwayat banding seorang siswa.
+func (r *bandingNilaiRepository) GetAllBySiswaID(ctx context.Context, siswaID int) ([]BandingNilaiSiswa, error) {
+	var results []BandingNilaiSiswa
+	query := bandingNilaiSelect + " WHERE b.siswa_id = $1 ORDER BY b.created DESC"
+	err := r.db.SelectContext(ctx, &results, query, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAllByGuruID mengambil banding yang boleh diputuskan guru, yaitu banding tugas/quiz di kelas
+// perwaliannya atau di mapel yang diajarnya. Status kosong berarti semua status.
+func (r *bandingNilaiRepository) GetAllByGuruID(ctx context.Context, guruID int, status string) ([]BandingNilaiSiswa, error) {
+	var results []BandingNilaiSiswa
+	query := bandingNilaiSelect + `
+		WHERE ` + bandingPemrosesFilter + ` AND ($2 = '' OR b.status = $2)
+		ORDER BY b.created DESC
+	`
+	err := r.db.SelectContext(ctx, &results, query, guruID, status)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// BolehMemproses memeriksa apakah guru boleh menerima atau menolak banding: wali kelas tugas/quiz
+// yang dibanding atau guru yang mengajar mapelnya di kelas tersebut.
+func (r *bandingNilaiRepository) BolehMemproses(ctx context.Context, id int, guruID int) (bool, error) {
+	var boleh bool
+	query := "SELECT EXISTS (" + bandingNilaiSelect + `
+		WHERE b.id = $2 AND ` + bandingPemrosesFilter + ")"
+	err := r.db.GetContext(ctx, &boleh, query, guruID, id)
+	return boleh, err
+}
+
+// Terima menyetujui banding yang masih menunggu dan memperbarui nilai tugas/quiz terkait dalam satu
+// transaksi. ErrBandingSudahDiproses dikembalikan jika banding sudah diputuskan lebih dulu.
+func (r *bandingNilaiRepository) Terima(ctx context.Context, banding *models.BandingNilai, guruID int, nilaiBaru float64, tanggapan *string) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		UPDATE banding_nilai SET
+			status = 'diterima',
+			nilai_baru = $1,
+			tanggapan = $2,
+			guru_id = $3,
+			diproses = $4,
+			updated = $4
+		WHERE id = $5 AND status = 'menunggu'
+	`
+	result, err := tx.ExecContext(ctx, query, nilaiBaru, tanggapan, guruID, now, banding.ID)
+	if err != nil {
+		return err
+	}
+	if err := pastikanBandingDiproses(result); err != nil {
+		return err
+	}
+
+	if banding.HasilTugasID != nil {
+		query = `
+			UPDATE hasil_tugas SET
+				nilai = $1, nilai_individu = kelompok_id IS NOT NULL, nilai_sebelum_sejawat = NULL, kurva_id = NULL, updated = $2
+			WHERE id = $3
+		`
+		if _, err := tx.ExecContext(ctx, query, nilaiBaru, now, *banding.HasilTugasID); err != nil {
+			return err
+		}
+	}
+	if banding.HasilQuizID != nil {
+		query = "UPDATE hasil_quiz SET nilai = $1, kurva_id = NULL, updated = $2 WHERE id = $3"
+		if _, err := tx.ExecContext(ctx, query, nilaiBaru, now, *banding.HasilQuizID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Tolak menolak banding yang masih menunggu. ErrBandingSudahDiproses dikembalikan jika banding sudah
+// diputuskan lebih dulu.
+func (r *bandingNilaiRepository) Tolak(ctx context.Context, id int, guruID int, tanggapan string) error {
+	now := time.Now()
+	query := `
+		UPDATE banding_nilai SET
+			status = 'ditolak',
+			tanggapan = $1,
+			guru_id = $2,
+			diproses = $3,
+			updated = $3
+		WHERE id = $4 AND status = 'menunggu'
+	`
+	result, err := r.db.ExecContext(ctx, query, tanggapan, guruID, now, id)
+	if err != nil {
+		return err
+	}
+	return pastikanBandingDiproses(result)
+}
+
+func pastikanBandingDiproses(result sql.Result) error {
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return ErrBandingSudahDiproses
+	}
+	return nil
+}
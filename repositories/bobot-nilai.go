@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type BobotNilaiRepository interface {
+	GetByMapelID(ctx context.Context, mapelID int) (*models.BobotNilai, error)
+	GetKategoriByMapelID(ctx context.Context, mapelID int) ([]models.BobotKategori, error)
+	Save(ctx context.Context, bobot *models.BobotNilai, kategori []models.BobotKategori) error
+}
+
+type bobotNilaiRepository struct {
+	db *sqlx.DB
+}
+
+func NewBobotNilaiRepository(db *sqlx.DB) BobotNilaiRepository {
+	return &bobotNilaiRepository{db: db}
+}
+
+func (r *bobotNilaiRepository) GetByMapelID(ctx context.Context, mapelID int) (*models.BobotNilai, error) {
+	var bobot models.BobotNilai
+	query := "SELECT * FROM bobot_nilai WHERE mata_pelajaran_id = $1"
+	err := r.db.GetContext(ctx, &bobot, query, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return &bobot, nil
+}
+
+func (r *bobotNilaiRepository) GetKategoriByMapelID(ctx context.Context, mapelID int) ([]models.BobotKategori, error) {
+	var kategori []models.BobotKategori
+	query := "SELECT * FROM bobot_kategori WHERE mata_pelajaran_id = $1 ORDER BY kategori ASC"
+	err := r.db.SelectContext(ctx, &kategori, query, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return kategori, nil
+}
+
+// Save menyimpan (insert atau update) bobot mapel dan mengganti seluruh bobot kategorinya dalam satu transaksi.
+func (r *bobotNilaiRepository) Save(ctx context.Context, bobot *models.BobotNilai, kategori []models.BobotKategori) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO bobot_nilai (mata_pelajaran_id, bobot_tugas, bobot_quiz, tugas_dibuang, quiz_dibuang)
+        VALUES (:mata_pelajaran_id, :bobot_tugas, :bobot_quiz, :tugas_dibuang, :quiz_dibuang)
+        ON CONFLICT (mata_pelajaran_id) DO UPDATE SET
+            bobot_tugas = EXCLUDED.bobot_tugas,
+            bobot_quiz = EXCLUDED.bobot_quiz,
+            tugas_dibuang = EXCLUDED.tugas_dibuang,
+            quiz_dibuang = EXCLUDED.quiz_dibuang,
+            updated = NOW()
+    `
+	if _, err := tx.NamedExecContext(ctx, query, bobot); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM bobot_kategori WHERE mata_pelajaran_id = $1", bobot.MataPelajaranID); err != nil {
+		return err
+	}
+	for _, item := range kategori {
+		query := "INSERT INTO bobot_kategori (mata_pelajaran_id, kategori, bobot) VALUES ($1, $2, $3)"
+		if _, err := tx.ExecContext(ctx, query, bobot.MataPelajaranID, item.Kategori, item.Bobot); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
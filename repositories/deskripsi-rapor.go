@@ -0,0 +1,237 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NilaiTopik adalah rata-rata nilai siswa pada satu topik (kategori tugas atau judul tugas/quiz).
+type NilaiTopik struct {
+	Topik string  `db:"topik"`
+	Nilai float64 `db:"nilai"`
+}
+
+type DeskripsiRaporSiswa struct {
+	models.DeskripsiRapor
+	NamaSiswa string `db:"nama_siswa"`
+}
+
+type DeskripsiRaporRepository interface {
+	CreateTemplate(ctx context.Context, template *models.TemplateDeskripsi) error
+	UpdateTemplate(ctx context.Context, template *models.TemplateDeskripsi) error
+	DeleteTemplate(ctx context.Context, id int) error
+	GetAllTemplate(ctx context.Context) ([]models.TemplateDeskripsi, error)
+	FindTemplate(ctx context.Context, mapelID int, kompetensi string, nilai float64) (*models.TemplateDeskripsi, error)
+	GetNilaiPerTopik(ctx context.Context, siswaID int, kelasID int, mapelID int) ([]NilaiTopik, error)
+	Upsert(ctx context.Context, deskripsi *models.DeskripsiRapor) error
+	GetByID(ctx context.Context, id int) (*models.DeskripsiRapor, error)
+	GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]DeskripsiRaporSiswa, error)
+	GetFinalBySiswaAndKelasID(ctx context.Context, siswaID int, kelasID int) ([]models.DeskripsiRapor, error)
+	UpdateDeskripsi(ctx context.Context, id int, deskripsi string) error
+	Finalkan(ctx context.Context, id int) error
+}
+
+type deskripsiRaporRepository struct {
+	db *sqlx.DB
+}
+
+func NewDeskripsiRaporRepository(db *sqlx.DB) DeskripsiRaporRepository {
+	return &deskripsiRaporRepository{db: db}
+}
+
+func (r *deskripsiRaporRepository) CreateTemplate(ctx context.Context, template *models.TemplateDeskripsi) error {
+	query := `
+        INSERT INTO template_deskripsi (mata_pelajaran_id, kompetensi, nilai_min, nilai_max, template)
+        VALUES (:mata_pelajaran_id, :kompetensi, :nilai_min, :nilai_max, :template)
+    `
+	_, err := r.db.NamedExecContext(ctx, query, template)
+	return err
+}
+
+func (r *deskripsiRaporRepository) UpdateTemplate(ctx context.Context, template *models.TemplateDeskripsi) error {
+	query := `
+        UPDATE template_deskripsi SET
+            mata_pelajaran_id = :mata_pelajaran_id,
+            kompetensi = :kompetensi,
+            nilai_min = :nilai_min,
+            nilai_max = :nilai_max,
+            template = :template,
+            updated = NOW()
+        WHERE id = :id
+    `
+	result, err := r.db.NamedExecContext(ctx, query, template)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *deskripsiRaporRepository) DeleteTemplate(ctx context.Context, id int) error {
+	query := "DELETE FROM template_deskripsi WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *deskripsiRaporRepository) GetAllTemplate(ctx context.Context) ([]models.TemplateDeskripsi, error) {
+	var templates []models.TemplateDeskripsi
+	query := "SELECT * FROM template_deskripsi ORDER BY mata_pelajaran_id NULLS FIRST, nilai_min DESC"
+	err := r.db.SelectContext(ctx, &templates, query)
+	if err != nil {
+		return nil, err
+	}
+	return templates, nil
+}
+
+// FindTemplate mencari template yang rentang nilainya mencakup nilai siswa. Template khusus mapel
+// didahulukan dari template umum, dan template khusus kompetensi didahulukan dari yang tidak.
+func (r *deskripsiRaporRepository) FindTemplate(ctx context.Context, mapelID int, kompetensi string, nilai float64) (*models.TemplateDeskripsi, error) {
+	var template models.TemplateDeskripsi
+	query := `
+		SELECT *
+		FROM template_deskripsi
+		WHERE (mata_pelajaran_id = $1 OR mata_pelajaran_id IS NULL)
+			AND (LOWER(kompetensi) = LOWER($2) OR kompetensi IS NULL)
+			AND $3 BETWEEN nilai_min AND nilai_max
+		ORDER BY mata_pelajaran_id IS NULL, kompetensi IS NULL, id
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &template, query, mapelID, kompetensi, nilai)
+	if err != nil {
+		return nil, err
+	}
+	return &template, nil
+}
+
+// GetNilaiPerTopik merata-ratakan nilai tugas dan quiz siswa per topik. Topik tugas diambil dari
+// kategorinya (atau judul jika kategori kosong), sedangkan topik quiz diambil dari judulnya.
+func (r *deskripsiRaporRepository) GetNilaiPerTopik(ctx context.Context, siswaID int, kelasID int, mapelID int) ([]NilaiTopik, error) {
+	var results []NilaiTopik
+	query := `
+		SELECT topik, AVG(nilai) AS nilai
+		FROM (
+			SELECT COALESCE(NULLIF(t.kategori, ''), t.judul) AS topik, ht.nilai
+			FROM hasil_tugas ht
+			JOIN tugas t ON ht.tugas_id = t.id
+			WHERE ht.siswa_id = $1 AND t.kelas_id = $2 AND t.mata_pelajaran_id = $3 AND ht.nilai IS NOT NULL
+				AND t.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas')
+			UNION ALL
+			SELECT q.judul AS topik, hq.nilai
+			FROM hasil_quiz hq
+			JOIN quiz q ON hq.quiz_id = q.id
+			WHERE hq.siswa_id = $1 AND q.kelas_id = $2 AND q.mata_pelajaran_id = $3 AND hq.nilai IS NOT NULL
+				AND q.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'quiz')
+		) nilai_topik
+		GROUP BY topik
+		ORDER BY nilai DESC, topik ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, siswaID, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Upsert menyimpan deskripsi hasil generate. Deskripsi yang sudah difinalkan tidak ditimpa.
+func (r *deskripsiRaporRepository) Upsert(ctx context.Context, deskripsi *models.DeskripsiRapor) error {
+	query := `
+        INSERT INTO deskripsi_rapor (siswa_id, mata_pelajaran_id, kelas_id, deskripsi)
+        VALUES (:siswa_id, :mata_pelajaran_id, :kelas_id, :deskripsi)
+        ON CONFLICT (siswa_id, mata_pelajaran_id, kelas_id) DO UPDATE SET
+            deskripsi = EXCLUDED.deskripsi,
+            updated = NOW()
+        WHERE deskripsi_rapor.is_final = FALSE
+    `
+	_, err := r.db.NamedExecContext(ctx, query, deskripsi)
+	return err
+}
+
+func (r *deskripsiRaporRepository) GetByID(ctx context.Context, id int) (*models.DeskripsiRapor, error) {
+	var deskripsi models.DeskripsiRapor
+	query := "SELECT * FROM deskripsi_rapor WHERE id = $1"
+	err := r.db.GetContext(ctx, &deskripsi, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &deskripsi, nil
+}
+
+func (r *deskripsiRaporRepository) GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]DeskripsiRaporSiswa, error) {
+	var results []DeskripsiRaporSiswa
+	query := `
+		SELECT
+			dr.*,
+			s.nama AS nama_siswa
+		FROM deskripsi_rapor dr
+		JOIN siswa s ON dr.siswa_id = s.id
+		WHERE dr.kelas_id = $1 AND dr.mata_pelajaran_id = $2
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *deskripsiRaporRepository) GetFinalBySiswaAndKelasID(ctx context.Context, siswaID int, kelasID int) ([]models.DeskripsiRapor, error) {
+	var results []models.DeskripsiRapor
+	query := "SELECT * FROM deskripsi_rapor WHERE siswa_id = $1 AND kelas_id = $2 AND is_final = TRUE"
+	err := r.db.SelectContext(ctx, &results, query, siswaID, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// UpdateDeskripsi menyimpan hasil suntingan guru. Deskripsi yang sudah final tidak dapat diubah.
+func (r *deskripsiRaporRepository) UpdateDeskripsi(ctx context.Context, id int, deskripsi string) error {
+	query := "UPDATE deskripsi_rapor SET deskripsi = $1, updated = NOW() WHERE id = $2 AND is_final = FALSE"
+	result, err := r.db.ExecContext(ctx, query, deskripsi, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *deskripsiRaporRepository) Finalkan(ctx context.Context, id int) error {
+	query := "UPDATE deskripsi_rapor SET is_final = TRUE, difinalkan = NOW(), updated = NOW() WHERE id = $1 AND is_final = FALSE"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,358 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrKetidakhadiranTumpang dikembalikan saat guru sudah tercatat berhalangan pada sebagian rentang tanggal.
+	ErrKetidakhadiranTumpang = errors.New("ketidakhadiran guru beririsan dengan catatan lain")
+	// ErrGuruTidakTersedia dikembalikan saat calon pengganti sedang mengajar, berhalangan, atau
+	// sudah menggantikan di jam yang sama.
+	ErrGuruTidakTersedia = errors.New("guru tidak tersedia pada jam tersebut")
+)
+
+// SesiPengganti adalah pertemuan yang perlu digantikan beserta data slot, guru yang berhalangan,
+// dan guru penggantinya.
+type SesiPengganti struct {
+	ID                int       `db:"id"`
+	KetidakhadiranID  int       `db:"ketidakhadiran_id"`
+	JadwalID          int       `db:"jadwal_id"`
+	Tanggal           time.Time `db:"tanggal"`
+	GuruPenggantiID   *int      `db:"guru_pengganti_id"`
+	NamaGuruPengganti *string   `db:"nama_guru_pengganti"`
+	GuruID            int       `db:"guru_id"`
+	NamaGuru          string    `db:"nama_guru"`
+	KelasID           int       `db:"kelas_id"`
+	NamaKelas         string    `db:"nama_kelas"`
+	MataPelajaranID   int       `db:"mata_pelajaran_id"`
+	NamaMapel         string    `db:"nama_mapel"`
+	Hari              string    `db:"hari"`
+	JamMulai          time.Time `db:"jam_mulai"`
+	JamSelesai        time.Time `db:"jam_selesai"`
+	Ruang             *string   `db:"ruang"`
+}
+
+// GuruTersedia adalah calon pengganti yang bebas pada jam pertemuan. MengajarMapel menandai guru
+// yang juga mengajar mapel tersebut; BebanHari adalah jumlah jam mengajarnya pada hari itu.
+type GuruTersedia struct {
+	ID            int    `db:"id"`
+	Nama          string `db:"nama"`
+	MengajarMapel bool   `db:"mengajar_mapel"`
+	BebanHari     int    `db:"beban_hari"`
+}
+
+// NotifikasiKelas adalah notifikasi yang dikirim ke seluruh siswa satu kelas.
+type NotifikasiKelas struct {
+	KelasID int
+	Judul   string
+	Pesan   string
+}
+
+// penggantiHariIniFilter memberi guru pengganti akses sementara ke kelas dan mapel yang ia gantikan,
+// hanya pada tanggal pertemuannya. guruParam adalah placeholder guru_id, kelasKolom dan mapelKolom
+// kolom kelas_id dan mata_pelajaran_id pada query.
+func penggantiHariIniFilter(guruParam string, kelasKolom string, mapelKolom string) string {
+	return `EXISTS (
+		SELECT 1 FROM guru_pengganti gpa
+		JOIN jadwal_kelas jka ON gpa.jadwal_id = jka.id
+		WHERE gpa.guru_pengganti_id = ` + guruParam + ` AND jka.kelas_id = ` + kelasKolom + `
+			AND jka.mata_pelajaran_id = ` + mapelKolom + ` AND gpa.tanggal = CURRENT_DATE)`
+}
+
+type GuruPenggantiRepository interface {
+	CreateKetidakhadiran(ctx context.Context, ketidakhadiran *models.KetidakhadiranGuru, sesi []models.GuruPengganti) (int, error)
+	DeleteKetidakhadiran(ctx context.Context, id int) error
+	GetKetidakhadiranByID(ctx context.Context, id int) (*models.KetidakhadiranGuru, error)
+	GetAllKetidakhadiran(ctx context.Context, mulai time.Time, selesai time.Time) ([]models.KetidakhadiranGuru, error)
+	GetSesiByID(ctx context.Context, id int) (*SesiPengganti, error)
+	GetSesiByKetidakhadiranID(ctx context.Context, ketidakhadiranID int) ([]SesiPengganti, error)
+	GetSesiByGuruPengganti(ctx context.Context, guruID int, mulai time.Time) ([]SesiPengganti, error)
+	GetGuruTersedia(ctx context.Context, sesiID int) ([]GuruTersedia, error)
+	Tetapkan(ctx context.Context, sesiID int, guruID *int, notifikasi []models.Notifikasi, untukKelas *NotifikasiKelas) error
+}
+
+type guruPenggantiRepository struct {
+	db *sqlx.DB
+}
+
+func NewGuruPenggantiRepository(db *sqlx.DB) GuruPenggantiRepository {
+	return &guruPenggantiRepository{db: db}
+}
+
+const sesiPenggantiSelect = `
+	SELECT
+		gp.id, gp.ketidakhadiran_id, gp.jadwal_id, gp.tanggal, gp.guru_pengganti_id,
+		pg.nama AS nama_guru_pengganti,
+		kg.guru_id, g.nama AS nama_guru,
+		jk.kelas_id, k.name AS nama_kelas, jk.mata_pelajaran_id, mp.nama AS nama_mapel,
+		jk.hari, jk.jam_mulai, jk.jam_selesai, jk.ruang
+	FROM guru_pengganti gp
+	JOIN ketidakhadiran_guru kg ON gp.ketidakhadiran_id = kg.id
+	JOIN guru g ON kg.guru_id = g.id
+	JOIN jadwal_kelas jk ON gp.jadwal_id = jk.id
+	JOIN kelas k ON jk.kelas_id = k.id
+	JOIN mata_pelajaran mp ON jk.mata_pelajaran_id = mp.id
+	LEFT JOIN guru pg ON gp.guru_pengganti_id = pg.id
+`
+
+// guruTersediaQuery mencari guru yang bebas pada jam pertemuan $1: bukan guru yang berhalangan,
+// tidak mengajar slot lain yang beririsan di hari itu, tidak sedang berhalangan, dan belum
+// menggantikan pertemuan lain yang beririsan di tanggal yang sama. $2 bukan nol membatasi ke satu guru.
+const guruTersediaQuery = `
+	WITH sesi AS (
+		SELECT gp.id, gp.tanggal, jk.hari, jk.jam_mulai, jk.jam_selesai, jk.mata_pelajaran_id, kg.guru_id AS guru_absen
+		FROM guru_pengganti gp
+		JOIN jadwal_kelas jk ON gp.jadwal_id = jk.id
+		JOIN ketidakhadiran_guru kg ON gp.ketidakhadiran_id = kg.id
+		WHERE gp.id = $1
+	)
+	SELECT
+		g.id, g.nama,
+		EXISTS (
+			SELECT 1 FROM jadwal_kelas j WHERE j.guru_id = g.id AND j.mata_pelajaran_id = sesi.mata_pelajaran_id
+		) AS mengajar_mapel,
+		(SELECT COUNT(*) FROM jadwal_kelas j WHERE j.guru_id = g.id AND j.hari = sesi.hari)
+			+ (SELECT COUNT(*) FROM guru_pengganti p WHERE p.guru_pengganti_id = g.id AND p.tanggal = sesi.tanggal) AS beban_hari
+	FROM guru g
+	CROSS JOIN sesi
+	WHERE g.id <> sesi.guru_absen
+		AND ($2 = 0 OR g.id = $2)
+		AND NOT EXISTS (
+			SELECT 1 FROM jadwal_kelas j
+			WHERE j.guru_id = g.id AND j.hari = sesi.hari
+				AND j.jam_mulai < sesi.jam_selesai AND j.jam_selesai > sesi.jam_mulai
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM ketidakhadiran_guru k
+			WHERE k.guru_id = g.id AND sesi.tanggal BETWEEN k.tanggal_mulai AND k.tanggal_selesai
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM guru_pengganti p
+			JOIN jadwal_kelas j ON p.jadwal_id = j.id
+			WHERE p.guru_pengganti_id = g.id AND p.tanggal = sesi.tanggal AND p.id <> sesi.id
+				AND j.jam_mulai < sesi.jam_selesai AND j.jam_selesai > sesi.jam_mulai
+		)
+	ORDER BY mengajar_mapel DESC, beban_hari ASC, g.nama ASC
+`
+
+// CreateKetidakhadiran mencatat ketidakhadiran beserta pertemuan yang perlu digantikan dalam satu
+// transaksi. Penugasan guru tersebut sebagai pengganti di rentang yang sama dilepas; jumlahnya
+// dikembalikan agar admin dapat menetapkan ulang.
+func (r *guruPenggantiRepository) CreateKetidakhadiran(ctx context.Context, ketidakhadiran *models.KetidakhadiranGuru, sesi []models.GuruPengganti) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", kunciJadwal); err != nil {
+		return 0, err
+	}
+
+	mulai := ketidakhadiran.TanggalMulai.Format("2006-01-02")
+	selesai := ketidakhadiran.TanggalSelesai.Format("2006-01-02")
+
+	var tumpang bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM ketidakhadiran_guru
+			WHERE guru_id = $1 AND tanggal_mulai <= $3::date AND tanggal_selesai >= $2::date
+		)
+	`
+	if err := tx.GetContext(ctx, &tumpang, query, ketidakhadiran.GuruID, mulai, selesai); err != nil {
+		return 0, err
+	}
+	if tumpang {
+		return 0, ErrKetidakhadiranTumpang
+	}
+
+	query = `
+        INSERT INTO ketidakhadiran_guru (guru_id, tanggal_mulai, tanggal_selesai, alasan)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created
+    `
+	err = tx.QueryRowxContext(ctx, query, ketidakhadiran.GuruID, mulai, selesai, ketidakhadiran.Alasan).
+		Scan(&ketidakhadiran.ID, &ketidakhadiran.Created)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range sesi {
+		query := `
+            INSERT INTO guru_pengganti (ketidakhadiran_id, jadwal_id, tanggal)
+            VALUES ($1, $2, $3)
+        `
+		if _, err := tx.ExecContext(ctx, query, ketidakhadiran.ID, item.JadwalID, item.Tanggal.Format("2006-01-02")); err != nil {
+			return 0, err
+		}
+	}
+
+	query = `
+		UPDATE guru_pengganti SET guru_pengganti_id = NULL, updated = NOW()
+		WHERE guru_pengganti_id = $1 AND tanggal BETWEEN $2::date AND $3::date
+	`
+	result, err := tx.ExecContext(ctx, query, ketidakhadiran.GuruID, mulai, selesai)
+	if err != nil {
+		return 0, err
+	}
+	dilepas, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(dilepas), tx.Commit()
+}
+
+// DeleteKetidakhadiran menghapus ketidakhadiran beserta seluruh pertemuan penggantinya.
+func (r *guruPenggantiRepository) DeleteKetidakhadiran(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM guru_pengganti WHERE ketidakhadiran_id = $1", id); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM ketidakhadiran_guru WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func (r *guruPenggantiRepository) GetKetidakhadiranByID(ctx context.Context, id int) (*models.KetidakhadiranGuru, error) {
+	var ketidakhadiran models.KetidakhadiranGuru
+	query := "SELECT * FROM ketidakhadiran_guru WHERE id = $1"
+	err := r.db.GetContext(ctx, &ketidakhadiran, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &ketidakhadiran, nil
+}
+
+// GetAllKetidakhadiran mengambil ketidakhadiran yang beririsan dengan rentang [mulai, selesai].
+func (r *guruPenggantiRepository) GetAllKetidakhadiran(ctx context.Context, mulai time.Time, selesai time.Time) ([]models.KetidakhadiranGuru, error) {
+	var results []models.KetidakhadiranGuru
+	query := `
+		SELECT * FROM ketidakhadiran_guru
+		WHERE tanggal_mulai <= $2::date AND tanggal_selesai >= $1::date
+		ORDER BY tanggal_mulai ASC, id ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, mulai.Format("2006-01-02"), selesai.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *guruPenggantiRepository) GetSesiByID(ctx context.Context, id int) (*SesiPengganti, error) {
+	var sesi SesiPengganti
+	query := sesiPenggantiSelect + " WHERE gp.id = $1"
+	err := r.db.GetContext(ctx, &sesi, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &sesi, nil
+}
+
+func (r *guruPenggantiRepository) GetSesiByKetidakhadiranID(ctx context.Context, ketidakhadiranID int) ([]SesiPengganti, error) {
+	var results []SesiPengganti
+	query := sesiPenggantiSelect + " WHERE gp.ketidakhadiran_id = $1 ORDER BY gp.tanggal ASC, jk.jam_mulai ASC"
+	err := r.db.SelectContext(ctx, &results, query, ketidakhadiranID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetSesiByGuruPengganti mengambil pertemuan yang digantikan guru mulai tanggal tersebut.
+func (r *guruPenggantiRepository) GetSesiByGuruPengganti(ctx context.Context, guruID int, mulai time.Time) ([]SesiPengganti, error) {
+	var results []SesiPengganti
+	query := sesiPenggantiSelect + `
+		WHERE gp.guru_pengganti_id = $1 AND gp.tanggal >= $2::date
+		ORDER BY gp.tanggal ASC, jk.jam_mulai ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, guruID, mulai.Format("2006-01-02"))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetGuruTersedia mengambil calon pengganti yang bebas pada pertemuan tersebut, diurutkan dari
+// yang mengajar mapel yang sama lalu yang bebannya paling ringan di hari itu.
+func (r *guruPenggantiRepository) GetGuruTersedia(ctx context.Context, sesiID int) ([]GuruTersedia, error) {
+	var results []GuruTersedia
+	err := r.db.SelectContext(ctx, &results, guruTersediaQuery, sesiID, 0)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Tetapkan menetapkan (atau melepas, jika guruID nil) pengganti satu pertemuan lalu menyimpan
+// notifikasinya dalam transaksi yang sama. Ketersediaan guru diperiksa ulang di bawah advisory lock
+// jadwal sehingga dua penugasan bersamaan tidak dapat bentrok.
+func (r *guruPenggantiRepository) Tetapkan(ctx context.Context, sesiID int, guruID *int, notifikasi []models.Notifikasi, untukKelas *NotifikasiKelas) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", kunciJadwal); err != nil {
+		return err
+	}
+
+	if guruID != nil {
+		var tersedia []GuruTersedia
+		if err := tx.SelectContext(ctx, &tersedia, guruTersediaQuery, sesiID, *guruID); err != nil {
+			return err
+		}
+		if len(tersedia) == 0 {
+			return ErrGuruTidakTersedia
+		}
+	}
+
+	query := "UPDATE guru_pengganti SET guru_pengganti_id = $1, updated = NOW() WHERE id = $2"
+	result, err := tx.ExecContext(ctx, query, guruID, sesiID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	for _, item := range notifikasi {
+		if err := insertNotifikasi(ctx, tx, item.Role, item.UserID, item.Judul, item.Pesan); err != nil {
+			return err
+		}
+	}
+	if untukKelas != nil {
+		if err := insertNotifikasiKelas(ctx, tx, untukKelas.KelasID, untukKelas.Judul, untukKelas.Pesan); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,61 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type HasilQuizRepository interface {
+	GetByID(ctx context.Context, id int) (*models.HasilQuiz, error)
+	GetAllBySiswaID(ctx context.Context, siswaID int) ([]models.HasilQuiz, error)
+	UpdateNilai(ctx context.Context, id int, nilai float64) error
+}
+
+type hasilQuizRepository struct {
+	db *sqlx.DB
+}
+
+func NewHasilQuizRepository(db *sqlx.DB) HasilQuizRepository {
+	return &hasilQuizRepository{db: db}
+}
+
+func (r *hasilQuizRepository) GetByID(ctx context.Context, id int) (*models.HasilQuiz, error) {
+	var hasilQuiz models.HasilQuiz
+	query := "SELECT * FROM hasil_quiz WHERE id = $1"
+	err := r.db.GetContext(ctx, &hasilQuiz, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &hasilQuiz, nil
+}
+
+// GetAllBySiswaID mengambil semua hasil quiz milik seorang siswa.
+func (r *hasilQuizRepository) GetAllBySiswaID(ctx context.Context, siswaID int) ([]models.HasilQuiz, error) {
+	var hasilQuizList []models.HasilQuiz
+	query := "SELECT * FROM hasil_quiz WHERE siswa_id = $1 ORDER BY tanggal_pengerjaan DESC"
+	err := r.db.SelectContext(ctx, &hasilQuizList, query, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return hasilQuizList, nil
+}
+
+func (r *hasilQuizRepository) UpdateNilai(ctx context.Context, id int, nilai float64) error {
+	query := "UPDATE hasil_quiz SET nilai = $1, kurva_id = NULL, updated = $2 WHERE id = $3"
+	result, err := r.db.ExecContext(ctx, query, nilai, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
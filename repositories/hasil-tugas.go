@@ -3,10 +3,17 @@ package repositories
 import (
 	"be-pui/models"
 	"context"
+	"database/sql"
+	"errors"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrKelompokSudahMengumpulkan dikembalikan saat kelompok yang mengumpulkan tugas ternyata sudah
+// memiliki hasil tugas, misalnya karena anggota lain mengumpulkan pada saat yang sama.
+var ErrKelompokSudahMengumpulkan = errors.New("kelompok sudah mengumpulkan tugas")
+
 type HasilTugasSiswa struct {
 	models.HasilTugas
 	NamaSiswa string `db:"nama_siswa"`
@@ -25,7 +32,10 @@ type HasilTugasRepository interface {
 	GetAllByTugasID(ctx context.Context, tugasID int) ([]HasilTugasSiswa, error)
 	GetAllByKelasID(ctx context.Context, kelasID int) ([]HasilTugasKelas, error)
 	GetAllByGuruAndMapelID(ctx context.Context, guruID, mapelID int) ([]HasilTugasKelas, error) // Method baru
-
+	GetByID(ctx context.Context, id int) (*models.HasilTugas, error)
+	CreateForKelompok(ctx context.Context, hasilTugas *models.HasilTugas, siswaIDs []int) error
+	UpdateNilai(ctx context.Context, id int, nilai float64, feedback *string) error
+	UpdateNilaiByKelompokID(ctx context.Context, kelompokID int, nilai float64, feedback *string) (int, error)
 }
 
 type hasilTugasRepository struct {
@@ -39,8 +49,8 @@ func NewHasilTugasRepository(db *sqlx.DB) HasilTugasRepository {
 // Create menyisipkan data pengumpulan tugas baru oleh siswa ke dalam database.
 func (r *hasilTugasRepository) Create(ctx context.Context, hasilTugas *models.HasilTugas) error {
 	query := `
-        INSERT INTO hasil_tugas (tugas_id, siswa_id, tanggal_pengumpulan, status, file_jawaban_url)
-        VALUES (:tugas_id, :siswa_id, :tanggal_pengumpulan, :status, :file_jawaban_url)
+        INSERT INTO hasil_tugas (tugas_id, siswa_id, tanggal_pengumpulan, status, file_jawaban_url, kelompok_id)
+        VALUES (:tugas_id, :siswa_id, :tanggal_pengumpulan, :status, :file_jawaban_url, :kelompok_id)
     `
 	_, err := r.db.NamedExecContext(ctx, query, hasilTugas)
 	return err
@@ -120,7 +130,7 @@ func (r *hasilTugasRepository) GetAllByGuruAndMapelID(ctx context.Context, guruI
 		JOIN siswa s ON ht.siswa_id = s.id
 		JOIN tugas t ON ht.tugas_id = t.id
 		JOIN kelas k ON s.kelas_id = k.id
-		WHERE k.guru_id = $1 AND t.mata_pelajaran_id = $2
+		WHERE (k.guru_id = $1 OR ` + penggantiHariIniFilter("$1", "k.id", "t.mata_pelajaran_id") + `) AND t.mata_pelajaran_id = $2
 		ORDER BY t.deadline DESC, s.nama ASC
 	`
 	err := r.db.SelectContext(ctx, &results, query, guruID, mapelID)
@@ -129,3 +139,104 @@ func (r *hasilTugasRepository) GetAllByGuruAndMapelID(ctx context.Context, guruI
 	}
 	return results, nil
 }
+
+func (r *hasilTugasRepository) GetByID(ctx context.Context, id int) (*models.HasilTugas, error) {
+	var hasilTugas models.HasilTugas
+	query := "SELECT * FROM hasil_tugas WHERE id = $1"
+	err := r.db.GetContext(ctx, &hasilTugas, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &hasilTugas, nil
+}
+
+// CreateForKelompok mencatat satu pengumpulan kelompok sebagai hasil tugas untuk setiap anggota dengan file yang sama.
+// Baris kelompok dikunci selama transaksi sehingga dua anggota yang mengumpulkan bersamaan tidak sama-sama
+// mencatat hasil; ErrKelompokSudahMengumpulkan dikembalikan jika kelompok sudah mengumpulkan.
+func (r *hasilTugasRepository) CreateForKelompok(ctx context.Context, hasilTugas *models.HasilTugas, siswaIDs []int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var kelompokID int
+	if err := tx.GetContext(ctx, &kelompokID, "SELECT id FROM kelompok_tugas WHERE id = $1 FOR UPDATE", hasilTugas.KelompokID); err != nil {
+		return err
+	}
+	var sudahAda bool
+	if err := tx.GetContext(ctx, &sudahAda, "SELECT EXISTS (SELECT 1 FROM hasil_tugas WHERE kelompok_id = $1)", kelompokID); err != nil {
+		return err
+	}
+	if sudahAda {
+		return ErrKelompokSudahMengumpulkan
+	}
+
+	query := `
+        INSERT INTO hasil_tugas (tugas_id, siswa_id, tanggal_pengumpulan, status, file_jawaban_url, kelompok_id)
+        VALUES (:tugas_id, :siswa_id, :tanggal_pengumpulan, :status, :file_jawaban_url, :kelompok_id)
+    `
+	for _, siswaID := range siswaIDs {
+		row := *hasilTugas
+		row.SiswaID = siswaID
+		if _, err := tx.NamedExecContext(ctx, query, &row); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateNilai memberikan (atau mengganti) nilai dan feedback pada satu hasil tugas. Untuk hasil tugas
+// kelompok, nilai ini ditandai sebagai nilai individu agar tidak ditimpa saat kelompok dinilai ulang.
+// Nilai baru dari guru juga menggantikan nilai yang disimpan sebelum penggabungan nilai sejawat.
+func (r *hasilTugasRepository) UpdateNilai(ctx context.Context, id int, nilai float64, feedback *string) error {
+	query := `
+		UPDATE hasil_tugas SET
+			nilai = $1, feedback = $2, nilai_individu = kelompok_id IS NOT NULL, nilai_sebelum_sejawat = NULL,
+			kurva_id = NULL, updated = $3
+		WHERE id = $4
+	`
+	result, err := r.db.ExecContext(ctx, query, nilai, feedback, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// UpdateNilaiByKelompokID memberikan nilai yang sama kepada anggota kelompok yang belum memiliki nilai
+// individu dan mengembalikan jumlah anggota yang dinilai. sql.ErrNoRows dikembalikan jika kelompok
+// belum mengumpulkan tugas.
+func (r *hasilTugasRepository) UpdateNilaiByKelompokID(ctx context.Context, kelompokID int, nilai float64, feedback *string) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var jumlahHasil int
+	if err := tx.GetContext(ctx, &jumlahHasil, "SELECT COUNT(*) FROM hasil_tugas WHERE kelompok_id = $1", kelompokID); err != nil {
+		return 0, err
+	}
+	if jumlahHasil == 0 {
+		return 0, sql.ErrNoRows
+	}
+
+	query := "UPDATE hasil_tugas SET nilai = $1, feedback = $2, kurva_id = NULL, updated = $3 WHERE kelompok_id = $4 AND NOT nilai_individu"
+	result, err := tx.ExecContext(ctx, query, nilai, feedback, time.Now(), kelompokID)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rows), tx.Commit()
+}
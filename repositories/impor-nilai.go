@@ -0,0 +1,92 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NilaiSiswa adalah nilai seorang siswa pada satu tugas/quiz. Nilai nil berarti belum dinilai.
+type NilaiSiswa struct {
+	SiswaID int      `db:"siswa_id"`
+	Nilai   *float64 `db:"nilai"`
+}
+
+// NilaiImpor adalah satu nilai hasil impor yang akan ditulis ke hasil tugas/quiz.
+type NilaiImpor struct {
+	SiswaID  int
+	Nilai    float64
+	Feedback *string
+}
+
+type ImporNilaiRepository interface {
+	GetNilaiByItemID(ctx context.Context, jenis string, itemID int) ([]NilaiSiswa, error)
+	Terapkan(ctx context.Context, jenis string, itemID int, nilai []NilaiImpor) error
+}
+
+type imporNilaiRepository struct {
+	db *sqlx.DB
+}
+
+func NewImporNilaiRepository(db *sqlx.DB) ImporNilaiRepository {
+	return &imporNilaiRepository{db: db}
+}
+
+func (r *imporNilaiRepository) GetNilaiByItemID(ctx context.Context, jenis string, itemID int) ([]NilaiSiswa, error) {
+	var results []NilaiSiswa
+	query := "SELECT siswa_id, nilai FROM hasil_tugas WHERE tugas_id = $1"
+	if jenis == "quiz" {
+		query = "SELECT siswa_id, nilai FROM hasil_quiz WHERE quiz_id = $1"
+	}
+	err := r.db.SelectContext(ctx, &results, query, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Terapkan menulis seluruh nilai impor dalam satu transaksi. Seperti penilaian guru, nilai tugas
+// kelompok yang diimpor menjadi nilai individu. Siswa yang belum memiliki hasil dibuatkan hasil baru
+// dengan status "impor"; jika satu baris gagal, semua dibatalkan.
+func (r *imporNilaiRepository) Terapkan(ctx context.Context, jenis string, itemID int, nilai []NilaiImpor) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	updateQuery := `
+		UPDATE hasil_tugas SET
+			nilai = $1, feedback = COALESCE($2, feedback), nilai_individu = kelompok_id IS NOT NULL,
+			nilai_sebelum_sejawat = NULL, kurva_id = NULL, updated = NOW()
+		WHERE tugas_id = $3 AND siswa_id = $4
+	`
+	insertQuery := "INSERT INTO hasil_tugas (tugas_id, siswa_id, tanggal_pengumpulan, status, nilai, feedback) VALUES ($3, $4, NOW(), 'impor', $1, $2)"
+	if jenis == "quiz" {
+		updateQuery = "UPDATE hasil_quiz SET nilai = $1, kurva_id = NULL, updated = NOW() WHERE quiz_id = $2 AND siswa_id = $3"
+		insertQuery = "INSERT INTO hasil_quiz (quiz_id, siswa_id, tanggal_pengerjaan, status, nilai) VALUES ($2, $3, NOW(), 'impor', $1)"
+	}
+
+	for _, item := range nilai {
+		args := []interface{}{item.Nilai, item.Feedback, itemID, item.SiswaID}
+		if jenis == "quiz" {
+			args = []interface{}{item.Nilai, itemID, item.SiswaID}
+		}
+
+		result, err := tx.ExecContext(ctx, updateQuery, args...)
+		if err != nil {
+			return err
+		}
+		rows, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if rows == 0 {
+			if _, err := tx.ExecContext(ctx, insertQuery, args...); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// kunciJadwal adalah kunci advisory lock Postgres yang menserialkan penulisan jadwal agar
+// pemeriksaan bentrok dan penyimpanan tidak diselingi transaksi lain.
+const kunciJadwal = 4101
+
+// pengampuFilter membatasi query pada guru yang mengampu mapel di suatu kelas: wali kelasnya atau guru
+// yang mengajar mapel tersebut di kelas itu menurut jadwal. guruParam adalah placeholder guru_id,
+// kelasKolom dan mapelKolom kolom kelas_id dan mata_pelajaran_id pada query.
+func pengampuFilter(guruParam string, kelasKolom string, mapelKolom string) string {
+	return `(EXISTS (SELECT 1 FROM kelas kpa WHERE kpa.id = ` + kelasKolom + ` AND kpa.guru_id = ` + guruParam + `)
+		OR EXISTS (
+			SELECT 1 FROM jadwal_kelas jkpa
+			WHERE jkpa.kelas_id = ` + kelasKolom + ` AND jkpa.guru_id = ` + guruParam + `
+				AND jkpa.mata_pelajaran_id = ` + mapelKolom + `
+		))`
+}
+
+// SlotBentrok adalah slot yang beririsan waktunya dengan slot lain beserta alasannya:
+// "kelas", "guru", dan/atau "ruang".
+type SlotBentrok struct {
+	Jadwal models.JadwalKelas
+	Alasan []string
+}
+
+// BentrokJadwalError dikembalikan saat slot yang akan disimpan beririsan dengan slot lain
+// di kelas yang sama, dengan guru yang sama, atau di ruang yang sama.
+type BentrokJadwalError struct {
+	Bentrok []SlotBentrok
+}
+
+func (e *BentrokJadwalError) Error() string {
+	return fmt.Sprintf("jadwal bentrok dengan %d slot lain", len(e.Bentrok))
+}
+
+// PasanganBentrok adalah dua slot tersimpan yang saling bentrok, untuk pemindaian seluruh jadwal.
+type PasanganBentrok struct {
+	JadwalAID int  `db:"jadwal_a_id"`
+	JadwalBID int  `db:"jadwal_b_id"`
+	Kelas     bool `db:"kelas_sama"`
+	Guru      bool `db:"guru_sama"`
+	Ruang     bool `db:"ruang_sama"`
+}
+
+type JadwalKelasRepository interface {
+	Create(ctx context.Context, jadwal *models.JadwalKelas) error
+	Update(ctx context.Context, jadwal *models.JadwalKelas) error
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*models.JadwalKelas, error)
+	GetAllByKelasID(ctx context.Context, kelasID int) ([]models.JadwalKelas, error)
+	GetAll(ctx context.Context) ([]models.JadwalKelas, error)
+	GetSemuaBentrok(ctx context.Context) ([]PasanganBentrok, error)
+	ReplaceByKelasIDs(ctx context.Context, kelasIDs []int, jadwal []models.JadwalKelas) error
+}
+
+type jadwalKelasRepository struct {
+	db *sqlx.DB
+}
+
+func NewJadwalKelasRepository(db *sqlx.DB) JadwalKelasRepository {
+	return &jadwalKelasRepository{db: db}
+}
+
+// urutanJadwal mengurutkan slot dari Senin sampai Sabtu lalu jam mulai.
+const urutanJadwal = "array_position(ARRAY['Senin','Selasa','Rabu','Kamis','Jumat','Sabtu'], hari) ASC, jam_mulai ASC"
+
+// Create menyimpan slot baru. Mengembalikan *BentrokJadwalError jika slot beririsan dengan slot
+// lain di kelas yang sama, dengan guru yang sama, atau di ruang yang sama.
+func (r *jadwalKelasRepository) Create(ctx context.Context, jadwal *models.JadwalKelas) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := cekBentrokJadwal(ctx, tx, jadwal); err != nil {
+		return err
+	}
+
+	if err := insertJadwal(ctx, tx, jadwal); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Update memperbarui slot dengan pemeriksaan bentrok yang sama seperti Create.
+func (r *jadwalKelasRepository) Update(ctx context.Context, jadwal *models.JadwalKelas) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := cekBentrokJadwal(ctx, tx, jadwal); err != nil {
+		return err
+	}
+
+	query := `
+        UPDATE jadwal_kelas SET
+            kelas_id = $1,
+            mata_pelajaran_id = $2,
+            hari = $3,
+            jam_mulai = $4,
+            jam_selesai = $5,
+            guru_id = $6,
+            ruang = $7,
+            updated = NOW()
+        WHERE id = $8
+    `
+	result, err := tx.ExecContext(ctx, query,
+		jadwal.KelasID, jadwal.MataPelajaranID, jadwal.Hari, formatJam(jadwal.JamMulai), formatJam(jadwal.JamSelesai),
+		jadwal.GuruID, jadwal.Ruang, jadwal.ID,
+	)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func (r *jadwalKelasRepository) Delete(ctx context.Context, id int) error {
+	query := "DELETE FROM jadwal_kelas WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *jadwalKelasRepository) GetByID(ctx context.Context, id int) (*models.JadwalKelas, error) {
+	var jadwal models.JadwalKelas
+	query := "SELECT * FROM jadwal_kelas WHERE id = $1"
+	err := r.db.GetContext(ctx, &jadwal, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &jadwal, nil
+}
+
+func (r *jadwalKelasRepository) GetAllByKelasID(ctx context.Context, kelasID int) ([]models.JadwalKelas, error) {
+	var results []models.JadwalKelas
+	query := "SELECT * FROM jadwal_kelas WHERE kelas_id = $1 ORDER BY " + urutanJadwal
+	err := r.db.SelectContext(ctx, &results, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ReplaceByKelasIDs menghapus seluruh jadwal kelas-kelas tersebut lalu menyimpan jadwal baru dalam
+// satu transaksi. Setiap slot baru tetap diperiksa bentroknya; jika ada yang bentrok, tidak ada
+// perubahan yang disimpan.
+func (r *jadwalKelasRepository) ReplaceByKelasIDs(ctx context.Context, kelasIDs []int, jadwal []models.JadwalKelas) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", kunciJadwal); err != nil {
+		return err
+	}
+	if len(kelasIDs) > 0 {
+		query, args, err := sqlx.In("DELETE FROM jadwal_kelas WHERE kelas_id IN (?)", kelasIDs)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+			return err
+		}
+	}
+
+	for i := range jadwal {
+		if err := cekBentrokJadwal(ctx, tx, &jadwal[i]); err != nil {
+			return err
+		}
+		if err := insertJadwal(ctx, tx, &jadwal[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *jadwalKelasRepository) GetAll(ctx context.Context) ([]models.JadwalKelas, error) {
+	var results []models.JadwalKelas
+	query := "SELECT * FROM jadwal_kelas ORDER BY kelas_id ASC, " + urutanJadwal
+	err := r.db.SelectContext(ctx, &results, query)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetSemuaBentrok memindai seluruh jadwal dan mengembalikan setiap pasangan slot yang beririsan
+// di hari yang sama dan memakai kelas, guru, atau ruang yang sama. Setiap pasangan muncul sekali.
+func (r *jadwalKelasRepository) GetSemuaBentrok(ctx context.Context) ([]PasanganBentrok, error) {
+	var results []PasanganBentrok
+	query := `
+		SELECT jadwal_a_id, jadwal_b_id, kelas_sama, guru_sama, ruang_sama
+		FROM (
+			SELECT
+				a.id AS jadwal_a_id,
+				b.id AS jadwal_b_id,
+				a.kelas_id = b.kelas_id AS kelas_sama,
+				COALESCE(a.guru_id = b.guru_id, FALSE) AS guru_sama,
+				COALESCE(LOWER(a.ruang) = LOWER(b.ruang), FALSE) AS ruang_sama
+			FROM jadwal_kelas a
+			JOIN jadwal_kelas b ON a.id < b.id AND a.hari = b.hari
+				AND a.jam_mulai < b.jam_selesai AND b.jam_mulai < a.jam_selesai
+		) pasangan
+		WHERE kelas_sama OR guru_sama OR ruang_sama
+		ORDER BY jadwal_a_id ASC, jadwal_b_id ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// cekBentrokJadwal mengambil advisory lock jadwal lalu mencari slot lain pada hari yang sama dengan
+// rentang jam beririsan yang memakai kelas, guru, atau ruang yang sama. Slot itu sendiri (saat
+// update) dikecualikan.
+func cekBentrokJadwal(ctx context.Context, tx *sqlx.Tx, jadwal *models.JadwalKelas) error {
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", kunciJadwal); err != nil {
+		return err
+	}
+
+	var kandidat []models.JadwalKelas
+	query := `
+		SELECT * FROM jadwal_kelas
+		WHERE id <> $1 AND hari = $3
+			AND jam_mulai < $5::time AND jam_selesai > $4::time
+			AND (kelas_id = $2 OR guru_id = $6 OR LOWER(ruang) = LOWER($7))
+		ORDER BY jam_mulai ASC
+	`
+	err := tx.SelectContext(ctx, &kandidat, query,
+		jadwal.ID, jadwal.KelasID, jadwal.Hari, formatJam(jadwal.JamMulai), formatJam(jadwal.JamSelesai),
+		jadwal.GuruID, jadwal.Ruang,
+	)
+	if err != nil {
+		return err
+	}
+	if len(kandidat) == 0 {
+		return nil
+	}
+
+	bentrok := make([]SlotBentrok, 0, len(kandidat))
+	for _, item := range kandidat {
+		bentrok = append(bentrok, SlotBentrok{Jadwal: item, Alasan: AlasanBentrok(jadwal, &item)})
+	}
+	return &BentrokJadwalError{Bentrok: bentrok}
+}
+
+func insertJadwal(ctx context.Context, tx *sqlx.Tx, jadwal *models.JadwalKelas) error {
+	query := `
+        INSERT INTO jadwal_kelas (kelas_id, mata_pelajaran_id, hari, jam_mulai, jam_selesai, guru_id, ruang)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        RETURNING id
+    `
+	return tx.QueryRowxContext(ctx, query,
+		jadwal.KelasID, jadwal.MataPelajaranID, jadwal.Hari, formatJam(jadwal.JamMulai), formatJam(jadwal.JamSelesai),
+		jadwal.GuruID, jadwal.Ruang,
+	).Scan(&jadwal.ID)
+}
+
+// AlasanBentrok mengembalikan sumber daya yang dipakai bersama oleh dua slot: "kelas", "guru",
+// dan/atau "ruang". Pemanggil memastikan waktu kedua slot beririsan.
+func AlasanBentrok(a *models.JadwalKelas, b *models.JadwalKelas) []string {
+	var alasan []string
+	if a.KelasID == b.KelasID {
+		alasan = append(alasan, "kelas")
+	}
+	if a.GuruID != nil && b.GuruID != nil && *a.GuruID == *b.GuruID {
+		alasan = append(alasan, "guru")
+	}
+	if a.Ruang != nil && b.Ruang != nil && strings.EqualFold(*a.Ruang, *b.Ruang) {
+		alasan = append(alasan, "ruang")
+	}
+	return alasan
+}
+
+func formatJam(t time.Time) string {
+	return t.Format("15:04:05")
+}
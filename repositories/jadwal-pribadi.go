@@ -0,0 +1,126 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SlotJadwal adalah slot jadwal beserta nama kelas, mapel, dan guru pengajarnya.
+type SlotJadwal struct {
+	models.JadwalKelas
+	NamaKelas string  `db:"nama_kelas"`
+	NamaMapel string  `db:"nama_mapel"`
+	NamaGuru  *string `db:"nama_guru"`
+}
+
+// AgendaItem adalah deadline tugas atau jendela waktu quiz. Untuk tugas, Waktu adalah deadline;
+// untuk quiz, Waktu adalah waktu mulai dan WaktuSelesai akhir jendela pengerjaan.
+type AgendaItem struct {
+	Jenis           string     `db:"jenis"`
+	ItemID          int        `db:"item_id"`
+	Judul           string     `db:"judul"`
+	KelasID         int        `db:"kelas_id"`
+	MataPelajaranID int        `db:"mata_pelajaran_id"`
+	Waktu           time.Time  `db:"waktu"`
+	WaktuSelesai    *time.Time `db:"waktu_selesai"`
+}
+
+type JadwalPribadiRepository interface {
+	GetSlotByKelasID(ctx context.Context, kelasID int) ([]SlotJadwal, error)
+	GetSlotByGuruID(ctx context.Context, guruID int) ([]SlotJadwal, error)
+	GetAgendaSiswa(ctx context.Context, kelasID int, siswaID int, mulai time.Time, selesai time.Time) ([]AgendaItem, error)
+	GetAgendaGuru(ctx context.Context, guruID int, mulai time.Time, selesai time.Time) ([]AgendaItem, error)
+}
+
+type jadwalPribadiRepository struct {
+	db *sqlx.DB
+}
+
+func NewJadwalPribadiRepository(db *sqlx.DB) JadwalPribadiRepository {
+	return &jadwalPribadiRepository{db: db}
+}
+
+const slotJadwalQuery = `
+	SELECT jk.*, k.name AS nama_kelas, mp.nama AS nama_mapel, g.nama AS nama_guru
+	FROM jadwal_kelas jk
+	JOIN kelas k ON jk.kelas_id = k.id
+	JOIN mata_pelajaran mp ON jk.mata_pelajaran_id = mp.id
+	LEFT JOIN guru g ON jk.guru_id = g.id
+`
+
+func (r *jadwalPribadiRepository) GetSlotByKelasID(ctx context.Context, kelasID int) ([]SlotJadwal, error) {
+	var results []SlotJadwal
+	query := slotJadwalQuery + " WHERE jk.kelas_id = $1 ORDER BY jk.jam_mulai ASC"
+	err := r.db.SelectContext(ctx, &results, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetSlotByGuruID mengambil seluruh slot yang diajar guru tersebut di semua kelas.
+func (r *jadwalPribadiRepository) GetSlotByGuruID(ctx context.Context, guruID int) ([]SlotJadwal, error) {
+	var results []SlotJadwal
+	query := slotJadwalQuery + " WHERE jk.guru_id = $1 ORDER BY jk.jam_mulai ASC"
+	err := r.db.SelectContext(ctx, &results, query, guruID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAgendaSiswa mengambil deadline tugas yang terlihat oleh siswa dan jendela quiz kelasnya
+// yang jatuh dalam rentang [mulai, selesai).
+func (r *jadwalPribadiRepository) GetAgendaSiswa(ctx context.Context, kelasID int, siswaID int, mulai time.Time, selesai time.Time) ([]AgendaItem, error) {
+	var results []AgendaItem
+	query := `
+		SELECT 'tugas' AS jenis, id AS item_id, judul, kelas_id, mata_pelajaran_id, deadline AS waktu, NULL::timestamptz AS waktu_selesai
+		FROM tugas
+		WHERE kelas_id = $1 AND deadline >= $3 AND deadline < $4
+			AND ` + publishedTugasFilter + ` AND ` + remedialTugasFilter("$2") + `
+		UNION ALL
+		SELECT 'quiz' AS jenis, id AS item_id, judul, kelas_id, mata_pelajaran_id, waktu_mulai AS waktu, waktu_selesai
+		FROM quiz
+		WHERE kelas_id = $1 AND waktu_mulai IS NOT NULL
+			AND waktu_mulai < $4 AND COALESCE(waktu_selesai, waktu_mulai) >= $3
+		ORDER BY waktu ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, siswaID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAgendaGuru mengambil deadline tugas dan jendela quiz dalam rentang [mulai, selesai) untuk
+// setiap pasangan kelas dan mapel yang diajar guru menurut jadwal.
+func (r *jadwalPribadiRepository) GetAgendaGuru(ctx context.Context, guruID int, mulai time.Time, selesai time.Time) ([]AgendaItem, error) {
+	var results []AgendaItem
+	query := `
+		SELECT 'tugas' AS jenis, t.id AS item_id, t.judul, t.kelas_id, t.mata_pelajaran_id, t.deadline AS waktu, NULL::timestamptz AS waktu_selesai
+		FROM tugas t
+		WHERE t.deadline >= $2 AND t.deadline < $3
+			AND EXISTS (
+				SELECT 1 FROM jadwal_kelas jk
+				WHERE jk.guru_id = $1 AND jk.kelas_id = t.kelas_id AND jk.mata_pelajaran_id = t.mata_pelajaran_id
+			)
+		UNION ALL
+		SELECT 'quiz' AS jenis, q.id AS item_id, q.judul, q.kelas_id, q.mata_pelajaran_id, q.waktu_mulai AS waktu, q.waktu_selesai
+		FROM quiz q
+		WHERE q.waktu_mulai IS NOT NULL
+			AND q.waktu_mulai < $3 AND COALESCE(q.waktu_selesai, q.waktu_mulai) >= $2
+			AND EXISTS (
+				SELECT 1 FROM jadwal_kelas jk
+				WHERE jk.guru_id = $1 AND jk.kelas_id = q.kelas_id AND jk.mata_pelajaran_id = q.mata_pelajaran_id
+			)
+		ORDER BY waktu ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, guruID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,292 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type KalenderAkademikRepository interface {
+	CreateTahunAjaran(ctx context.Context, tahunAjaran *models.TahunAjaran) error
+	UpdateTahunAjaran(ctx context.Context, tahunAjaran *models.TahunAjaran) error
+	DeleteTahunAjaran(ctx context.Context, id int) error
+	AktifkanTahunAjaran(ctx context.Context, id int) error
+	GetTahunAjaranByID(ctx context.Context, id int) (*models.TahunAjaran, error)
+	GetAllTahunAjaran(ctx context.Context) ([]models.TahunAjaran, error)
+
+	CreateSemester(ctx context.Context, semester *models.Semester) error
+	UpdateSemester(ctx context.Context, semester *models.Semester) error
+	DeleteSemester(ctx context.Context, id int) error
+	GetSemesterByID(ctx context.Context, id int) (*models.Semester, error)
+	GetSemesterByTahunAjaranID(ctx context.Context, tahunAjaranID int) ([]models.Semester, error)
+	GetSemesterPadaTanggal(ctx context.Context, tanggal time.Time) (*models.Semester, error)
+
+	CreateAgenda(ctx context.Context, agenda *models.AgendaAkademik) error
+	UpdateAgenda(ctx context.Context, agenda *models.AgendaAkademik) error
+	DeleteAgenda(ctx context.Context, id int) error
+	GetAgendaByID(ctx context.Context, id int) (*models.AgendaAkademik, error)
+	GetAgenda(ctx context.Context, mulai time.Time, selesai time.Time, jenis string) ([]models.AgendaAkademik, error)
+}
+
+type kalenderAkademikRepository struct {
+	db *sqlx.DB
+}
+
+func NewKalenderAkademikRepository(db *sqlx.DB) KalenderAkademikRepository {
+	return &kalenderAkademikRepository{db: db}
+}
+
+func formatTanggal(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func (r *kalenderAkademikRepository) CreateTahunAjaran(ctx context.Context, tahunAjaran *models.TahunAjaran) error {
+	query := `
+        INSERT INTO tahun_ajaran (nama, tanggal_mulai, tanggal_selesai)
+        VALUES ($1, $2, $3)
+        RETURNING id, aktif, created, updated
+    `
+	return r.db.QueryRowxContext(ctx, query,
+		tahunAjaran.Nama, formatTanggal(tahunAjaran.TanggalMulai), formatTanggal(tahunAjaran.TanggalSelesai),
+	).Scan(&tahunAjaran.ID, &tahunAjaran.Aktif, &tahunAjaran.Created, &tahunAjaran.Updated)
+}
+
+func (r *kalenderAkademikRepository) UpdateTahunAjaran(ctx context.Context, tahunAjaran *models.TahunAjaran) error {
+	query := `
+        UPDATE tahun_ajaran SET
+            nama = $1,
+            tanggal_mulai = $2,
+            tanggal_selesai = $3,
+            updated = NOW()
+        WHERE id = $4
+        RETURNING aktif, created, updated
+    `
+	return r.db.QueryRowxContext(ctx, query,
+		tahunAjaran.Nama, formatTanggal(tahunAjaran.TanggalMulai), formatTanggal(tahunAjaran.TanggalSelesai), tahunAjaran.ID,
+	).Scan(&tahunAjaran.Aktif, &tahunAjaran.Created, &tahunAjaran.Updated)
+}
+
+// DeleteTahunAjaran menghapus tahun ajaran beserta semesternya dalam satu transaksi.
+func (r *kalenderAkademikRepository) DeleteTahunAjaran(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM semester WHERE tahun_ajaran_id = $1", id); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM tahun_ajaran WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+// AktifkanTahunAjaran menjadikan satu tahun ajaran aktif dan menonaktifkan yang lain.
+func (r *kalenderAkademikRepository) AktifkanTahunAjaran(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tahun_ajaran SET aktif = FALSE, updated = NOW() WHERE aktif AND id <> $1", id); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, "UPDATE tahun_ajaran SET aktif = TRUE, updated = NOW() WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func (r *kalenderAkademikRepository) GetTahunAjaranByID(ctx context.Context, id int) (*models.TahunAjaran, error) {
+	var tahunAjaran models.TahunAjaran
+	query := "SELECT * FROM tahun_ajaran WHERE id = $1"
+	err := r.db.GetContext(ctx, &tahunAjaran, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &tahunAjaran, nil
+}
+
+func (r *kalenderAkademikRepository) GetAllTahunAjaran(ctx context.Context) ([]models.TahunAjaran, error) {
+	var results []models.TahunAjaran
+	query := "SELECT * FROM tahun_ajaran ORDER BY tanggal_mulai DESC"
+	err := r.db.SelectContext(ctx, &results, query)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *kalenderAkademikRepository) CreateSemester(ctx context.Context, semester *models.Semester) error {
+	query := `
+        INSERT INTO semester (tahun_ajaran_id, nama, tanggal_mulai, tanggal_selesai)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created, updated
+    `
+	return r.db.QueryRowxContext(ctx, query,
+		semester.TahunAjaranID, semester.Nama, formatTanggal(semester.TanggalMulai), formatTanggal(semester.TanggalSelesai),
+	).Scan(&semester.ID, &semester.Created, &semester.Updated)
+}
+
+func (r *kalenderAkademikRepository) UpdateSemester(ctx context.Context, semester *models.Semester) error {
+	query := `
+        UPDATE semester SET
+            nama = $1,
+            tanggal_mulai = $2,
+            tanggal_selesai = $3,
+            updated = NOW()
+        WHERE id = $4
+        RETURNING tahun_ajaran_id, created, updated
+    `
+	return r.db.QueryRowxContext(ctx, query,
+		semester.Nama, formatTanggal(semester.TanggalMulai), formatTanggal(semester.TanggalSelesai), semester.ID,
+	).Scan(&semester.TahunAjaranID, &semester.Created, &semester.Updated)
+}
+
+func (r *kalenderAkademikRepository) DeleteSemester(ctx context.Context, id int) error {
+	query := "DELETE FROM semester WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *kalenderAkademikRepository) GetSemesterByID(ctx context.Context, id int) (*models.Semester, error) {
+	var semester models.Semester
+	query := "SELECT * FROM semester WHERE id = $1"
+	err := r.db.GetContext(ctx, &semester, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &semester, nil
+}
+
+func (r *kalenderAkademikRepository) GetSemesterByTahunAjaranID(ctx context.Context, tahunAjaranID int) ([]models.Semester, error) {
+	var results []models.Semester
+	query := "SELECT * FROM semester WHERE tahun_ajaran_id = $1 ORDER BY tanggal_mulai ASC"
+	err := r.db.SelectContext(ctx, &results, query, tahunAjaranID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetSemesterPadaTanggal mengambil semester yang memuat tanggal tersebut; sql.ErrNoRows jika
+// tanggal berada di luar semester mana pun (misalnya libur kenaikan kelas).
+func (r *kalenderAkademikRepository) GetSemesterPadaTanggal(ctx context.Context, tanggal time.Time) (*models.Semester, error) {
+	var semester models.Semester
+	query := `
+		SELECT * FROM semester
+		WHERE $1::date BETWEEN tanggal_mulai AND tanggal_selesai
+		ORDER BY tanggal_mulai DESC
+		LIMIT 1
+	`
+	err := r.db.GetContext(ctx, &semester, query, formatTanggal(tanggal))
+	if err != nil {
+		return nil, err
+	}
+	return &semester, nil
+}
+
+func (r *kalenderAkademikRepository) CreateAgenda(ctx context.Context, agenda *models.AgendaAkademik) error {
+	query := `
+        INSERT INTO agenda_akademik (jenis, nama, tanggal_mulai, tanggal_selesai, keterangan)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created, updated
+    `
+	return r.db.QueryRowxContext(ctx, query,
+		agenda.Jenis, agenda.Nama, formatTanggal(agenda.TanggalMulai), formatTanggal(agenda.TanggalSelesai), agenda.Keterangan,
+	).Scan(&agenda.ID, &agenda.Created, &agenda.Updated)
+}
+
+func (r *kalenderAkademikRepository) UpdateAgenda(ctx context.Context, agenda *models.AgendaAkademik) error {
+	query := `
+        UPDATE agenda_akademik SET
+            jenis = $1,
+            nama = $2,
+            tanggal_mulai = $3,
+            tanggal_selesai = $4,
+            keterangan = $5,
+            updated = NOW()
+        WHERE id = $6
+        RETURNING created, updated
+    `
+	return r.db.QueryRowxContext(ctx, query,
+		agenda.Jenis, agenda.Nama, formatTanggal(agenda.TanggalMulai), formatTanggal(agenda.TanggalSelesai), agenda.Keterangan, agenda.ID,
+	).Scan(&agenda.Created, &agenda.Updated)
+}
+
+func (r *kalenderAkademikRepository) DeleteAgenda(ctx context.Context, id int) error {
+	query := "DELETE FROM agenda_akademik WHERE id = $1"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *kalenderAkademikRepository) GetAgendaByID(ctx context.Context, id int) (*models.AgendaAkademik, error) {
+	var agenda models.AgendaAkademik
+	query := "SELECT * FROM agenda_akademik WHERE id = $1"
+	err := r.db.GetContext(ctx, &agenda, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &agenda, nil
+}
+
+// GetAgenda mengambil agenda yang beririsan dengan rentang [mulai, selesai]. Jenis kosong berarti
+// semua jenis; "libur" mencakup libur nasional dan libur sekolah.
+func (r *kalenderAkademikRepository) GetAgenda(ctx context.Context, mulai time.Time, selesai time.Time, jenis string) ([]models.AgendaAkademik, error) {
+	var results []models.AgendaAkademik
+	query := `
+		SELECT * FROM agenda_akademik
+		WHERE tanggal_mulai <= $2::date AND tanggal_selesai >= $1::date
+			AND ($3 = '' OR jenis = $3 OR ($3 = 'libur' AND jenis IN ('libur_nasional', 'libur_sekolah')))
+		ORDER BY tanggal_mulai ASC, id ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, formatTanggal(mulai), formatTanggal(selesai), jenis)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
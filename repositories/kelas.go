@@ -9,6 +9,7 @@ import (
 
 type KelasRepository interface {
 	Create(ctx context.Context, kelas *models.Kelas) error
+	GetByID(ctx context.Context, id int) (*models.Kelas, error)
 }
 
 type kelasRepository struct {
@@ -27,3 +28,13 @@ func (r *kelasRepository) Create(ctx context.Context, kelas *models.Kelas) error
 	_, err := r.db.NamedExecContext(ctx, query, kelas)
 	return err
 }
+
+func (r *kelasRepository) GetByID(ctx context.Context, id int) (*models.Kelas, error) {
+	var kelas models.Kelas
+	query := "SELECT * FROM kelas WHERE id = $1"
+	err := r.db.GetContext(ctx, &kelas, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &kelas, nil
+}
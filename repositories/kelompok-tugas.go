@@ -0,0 +1,162 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// ErrKelompokSudahDipakai dikembalikan saat pembagian kelompok akan diganti padahal tugas sudah
+// memiliki pengumpulan atau nilai.
+var ErrKelompokSudahDipakai = errors.New("tugas sudah memiliki pengumpulan")
+
+type KelompokWithAnggota struct {
+	models.KelompokTugas
+	Anggota []AnggotaKelompokSiswa `db:"-"`
+}
+
+type AnggotaKelompokSiswa struct {
+	KelompokID int    `db:"kelompok_id"`
+	SiswaID    int    `db:"siswa_id"`
+	NamaSiswa  string `db:"nama_siswa"`
+}
+
+type KelompokTugasRepository interface {
+	ReplaceForTugas(ctx context.Context, tugasID int, kelompok []KelompokWithAnggota) error
+	GetAllByTugasID(ctx context.Context, tugasID int) ([]KelompokWithAnggota, error)
+	GetByID(ctx context.Context, id int) (*models.KelompokTugas, error)
+	GetByTugasAndSiswaID(ctx context.Context, tugasID int, siswaID int) (*models.KelompokTugas, error)
+	GetAnggotaIDs(ctx context.Context, kelompokID int) ([]int, error)
+}
+
+type kelompokTugasRepository struct {
+	db *sqlx.DB
+}
+
+func NewKelompokTugasRepository(db *sqlx.DB) KelompokTugasRepository {
+	return &kelompokTugasRepository{db: db}
+}
+
+// ReplaceForTugas menghapus pembagian kelompok lama suatu tugas lalu menyimpan pembagian yang baru dalam satu transaksi.
+// Pembagian ditolak dengan ErrKelompokSudahDipakai jika tugas sudah memiliki hasil tugas.
+func (r *kelompokTugasRepository) ReplaceForTugas(ctx context.Context, tugasID int, kelompok []KelompokWithAnggota) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM tugas WHERE id = $1 FOR UPDATE", tugasID); err != nil {
+		return err
+	}
+	var sudahDipakai bool
+	if err := tx.GetContext(ctx, &sudahDipakai, "SELECT EXISTS (SELECT 1 FROM hasil_tugas WHERE tugas_id = $1)", tugasID); err != nil {
+		return err
+	}
+	if sudahDipakai {
+		return ErrKelompokSudahDipakai
+	}
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM anggota_kelompok WHERE kelompok_id IN (SELECT id FROM kelompok_tugas WHERE tugas_id = $1)", tugasID); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM kelompok_tugas WHERE tugas_id = $1", tugasID); err != nil {
+		return err
+	}
+
+	for i := range kelompok {
+		var kelompokID int
+		query := "INSERT INTO kelompok_tugas (tugas_id, nama) VALUES ($1, $2) RETURNING id"
+		if err := tx.GetContext(ctx, &kelompokID, query, tugasID, kelompok[i].Nama); err != nil {
+			return err
+		}
+		kelompok[i].ID = kelompokID
+		kelompok[i].TugasID = tugasID
+
+		for _, anggota := range kelompok[i].Anggota {
+			query := "INSERT INTO anggota_kelompok (kelompok_id, siswa_id) VALUES ($1, $2)"
+			if _, err := tx.ExecContext(ctx, query, kelompokID, anggota.SiswaID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetAllByTugasID mengambil semua kelompok suatu tugas beserta anggotanya.
+func (r *kelompokTugasRepository) GetAllByTugasID(ctx context.Context, tugasID int) ([]KelompokWithAnggota, error) {
+	var kelompokList []models.KelompokTugas
+	query := "SELECT * FROM kelompok_tugas WHERE tugas_id = $1 ORDER BY id ASC"
+	if err := r.db.SelectContext(ctx, &kelompokList, query, tugasID); err != nil {
+		return nil, err
+	}
+
+	var anggotaList []AnggotaKelompokSiswa
+	query = `
+		SELECT
+			ak.kelompok_id,
+			ak.siswa_id,
+			s.nama AS nama_siswa
+		FROM anggota_kelompok ak
+		JOIN kelompok_tugas kt ON ak.kelompok_id = kt.id
+		JOIN siswa s ON ak.siswa_id = s.id
+		WHERE kt.tugas_id = $1
+		ORDER BY s.nama ASC
+	`
+	if err := r.db.SelectContext(ctx, &anggotaList, query, tugasID); err != nil {
+		return nil, err
+	}
+
+	anggotaMap := make(map[int][]AnggotaKelompokSiswa)
+	for _, anggota := range anggotaList {
+		anggotaMap[anggota.KelompokID] = append(anggotaMap[anggota.KelompokID], anggota)
+	}
+
+	results := make([]KelompokWithAnggota, 0, len(kelompokList))
+	for _, kelompok := range kelompokList {
+		results = append(results, KelompokWithAnggota{
+			KelompokTugas: kelompok,
+			Anggota:       anggotaMap[kelompok.ID],
+		})
+	}
+	return results, nil
+}
+
+func (r *kelompokTugasRepository) GetByID(ctx context.Context, id int) (*models.KelompokTugas, error) {
+	var kelompok models.KelompokTugas
+	query := "SELECT * FROM kelompok_tugas WHERE id = $1"
+	err := r.db.GetContext(ctx, &kelompok, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &kelompok, nil
+}
+
+// GetByTugasAndSiswaID mencari kelompok tempat seorang siswa tergabung untuk tugas tertentu.
+func (r *kelompokTugasRepository) GetByTugasAndSiswaID(ctx context.Context, tugasID int, siswaID int) (*models.KelompokTugas, error) {
+	var kelompok models.KelompokTugas
+	query := `
+		SELECT kt.*
+		FROM kelompok_tugas kt
+		JOIN anggota_kelompok ak ON ak.kelompok_id = kt.id
+		WHERE kt.tugas_id = $1 AND ak.siswa_id = $2
+	`
+	err := r.db.GetContext(ctx, &kelompok, query, tugasID, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return &kelompok, nil
+}
+
+func (r *kelompokTugasRepository) GetAnggotaIDs(ctx context.Context, kelompokID int) ([]int, error) {
+	var siswaIDs []int
+	query := "SELECT siswa_id FROM anggota_kelompok WHERE kelompok_id = $1 ORDER BY siswa_id ASC"
+	err := r.db.SelectContext(ctx, &siswaIDs, query, kelompokID)
+	if err != nil {
+		return nil, err
+	}
+	return siswaIDs, nil
+}
@@ -0,0 +1,97 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SiswaDiBawahKKM adalah satu nilai siswa (rekap, tugas, atau quiz) yang berada di bawah KKM.
+type SiswaDiBawahKKM struct {
+	SiswaID   int     `db:"siswa_id"`
+	NamaSiswa string  `db:"nama_siswa"`
+	Jenis     string  `db:"jenis"`
+	SumberID  int     `db:"sumber_id"`
+	Judul     string  `db:"judul"`
+	Nilai     float64 `db:"nilai"`
+}
+
+type KKMRepository interface {
+	Upsert(ctx context.Context, kkm *models.KKM) error
+	GetAll(ctx context.Context) ([]models.KKM, error)
+	GetByMapelAndTingkat(ctx context.Context, mapelID int, tingkat int) (*models.KKM, error)
+	GetSiswaDiBawahKKM(ctx context.Context, kelasID int, mapelID int, kkm float64) ([]SiswaDiBawahKKM, error)
+}
+
+type kkmRepository struct {
+	db *sqlx.DB
+}
+
+func NewKKMRepository(db *sqlx.DB) KKMRepository {
+	return &kkmRepository{db: db}
+}
+
+func (r *kkmRepository) Upsert(ctx context.Context, kkm *models.KKM) error {
+	query := `
+        INSERT INTO kkm (mata_pelajaran_id, tingkat, nilai)
+        VALUES (:mata_pelajaran_id, :tingkat, :nilai)
+        ON CONFLICT (mata_pelajaran_id, tingkat) DO UPDATE SET
+            nilai = EXCLUDED.nilai,
+            updated = NOW()
+    `
+	_, err := r.db.NamedExecContext(ctx, query, kkm)
+	return err
+}
+
+func (r *kkmRepository) GetAll(ctx context.Context) ([]models.KKM, error) {
+	var kkms []models.KKM
+	query := "SELECT * FROM kkm ORDER BY mata_pelajaran_id ASC, tingkat ASC"
+	err := r.db.SelectContext(ctx, &kkms, query)
+	if err != nil {
+		return nil, err
+	}
+	return kkms, nil
+}
+
+func (r *kkmRepository) GetByMapelAndTingkat(ctx context.Context, mapelID int, tingkat int) (*models.KKM, error) {
+	var kkm models.KKM
+	query := "SELECT * FROM kkm WHERE mata_pelajaran_id = $1 AND tingkat = $2"
+	err := r.db.GetContext(ctx, &kkm, query, mapelID, tingkat)
+	if err != nil {
+		return nil, err
+	}
+	return &kkm, nil
+}
+
+// GetSiswaDiBawahKKM menandai siswa di satu kelas dan mapel yang nilai rekap, tugas, atau quiz-nya di bawah KKM.
+// Tugas dan quiz remedial tidak ikut diperiksa.
+func (r *kkmRepository) GetSiswaDiBawahKKM(ctx context.Context, kelasID int, mapelID int, kkm float64) ([]SiswaDiBawahKKM, error) {
+	var results []SiswaDiBawahKKM
+	query := `
+		SELECT rn.siswa_id, s.nama AS nama_siswa, 'rekap' AS jenis, rn.id AS sumber_id, 'Nilai Akhir' AS judul, rn.nilai_akhir AS nilai
+		FROM rekap_nilai rn
+		JOIN siswa s ON rn.siswa_id = s.id
+		WHERE rn.kelas_id = $1 AND rn.mata_pelajaran_id = $2 AND rn.nilai_akhir < $3
+		UNION ALL
+		SELECT ht.siswa_id, s.nama AS nama_siswa, 'tugas' AS jenis, t.id AS sumber_id, t.judul, ht.nilai
+		FROM hasil_tugas ht
+		JOIN tugas t ON ht.tugas_id = t.id
+		JOIN siswa s ON ht.siswa_id = s.id
+		WHERE t.kelas_id = $1 AND t.mata_pelajaran_id = $2 AND ht.nilai < $3
+			AND t.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas')
+		UNION ALL
+		SELECT hq.siswa_id, s.nama AS nama_siswa, 'quiz' AS jenis, q.id AS sumber_id, q.judul, hq.nilai
+		FROM hasil_quiz hq
+		JOIN quiz q ON hq.quiz_id = q.id
+		JOIN siswa s ON hq.siswa_id = s.id
+		WHERE q.kelas_id = $1 AND q.mata_pelajaran_id = $2 AND hq.nilai < $3
+			AND q.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'quiz')
+		ORDER BY nama_siswa ASC, jenis ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, mapelID, kkm)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
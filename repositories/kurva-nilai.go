@@ -0,0 +1,176 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NilaiItemSiswa adalah nilai seorang siswa pada satu tugas/quiz beserta namanya.
+type NilaiItemSiswa struct {
+	SiswaID   int     `db:"siswa_id"`
+	NamaSiswa string  `db:"nama_siswa"`
+	Nilai     float64 `db:"nilai"`
+}
+
+type KurvaNilaiRepository interface {
+	GetAktif(ctx context.Context, jenis string, itemID int) (*models.KurvaNilai, error)
+	GetNilaiMentah(ctx context.Context, kurvaID int) ([]models.NilaiMentahKurva, error)
+	GetNilaiItem(ctx context.Context, jenis string, itemID int) ([]NilaiItemSiswa, error)
+	Terapkan(ctx context.Context, kurva *models.KurvaNilai, nilai []models.NilaiMentahKurva) error
+	Kembalikan(ctx context.Context, kurva *models.KurvaNilai) (int, []models.NilaiMentahKurva, error)
+}
+
+type kurvaNilaiRepository struct {
+	db *sqlx.DB
+}
+
+func NewKurvaNilaiRepository(db *sqlx.DB) KurvaNilaiRepository {
+	return &kurvaNilaiRepository{db: db}
+}
+
+func (r *kurvaNilaiRepository) GetAktif(ctx context.Context, jenis string, itemID int) (*models.KurvaNilai, error) {
+	var kurva models.KurvaNilai
+	query := "SELECT * FROM kurva_nilai WHERE jenis = $1 AND item_id = $2 AND dibatalkan IS NULL"
+	err := r.db.GetContext(ctx, &kurva, query, jenis, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return &kurva, nil
+}
+
+func (r *kurvaNilaiRepository) GetNilaiMentah(ctx context.Context, kurvaID int) ([]models.NilaiMentahKurva, error) {
+	var results []models.NilaiMentahKurva
+	query := "SELECT * FROM nilai_mentah_kurva WHERE kurva_id = $1 ORDER BY siswa_id ASC"
+	err := r.db.SelectContext(ctx, &results, query, kurvaID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiItem mengambil seluruh nilai yang sudah ada pada satu tugas/quiz, terurut nama siswa.
+func (r *kurvaNilaiRepository) GetNilaiItem(ctx context.Context, jenis string, itemID int) ([]NilaiItemSiswa, error) {
+	var results []NilaiItemSiswa
+	query := `
+		SELECT ht.siswa_id, s.nama AS nama_siswa, ht.nilai
+		FROM hasil_tugas ht
+		JOIN siswa s ON ht.siswa_id = s.id
+		WHERE ht.tugas_id = $1 AND ht.nilai IS NOT NULL
+		ORDER BY s.nama ASC
+	`
+	if jenis == "quiz" {
+		query = `
+			SELECT hq.siswa_id, s.nama AS nama_siswa, hq.nilai
+			FROM hasil_quiz hq
+			JOIN siswa s ON hq.siswa_id = s.id
+			WHERE hq.quiz_id = $1 AND hq.nilai IS NOT NULL
+			ORDER BY s.nama ASC
+		`
+	}
+	err := r.db.SelectContext(ctx, &results, query, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Terapkan mencatat kurva beserta nilai mentah setiap siswa lalu menulis nilai hasil kurva,
+// semuanya dalam satu transaksi. Setiap hasil yang dikurva ditandai kurva_id; penulisan nilai lain
+// menghapus tanda tersebut.
+func (r *kurvaNilaiRepository) Terapkan(ctx context.Context, kurva *models.KurvaNilai, nilai []models.NilaiMentahKurva) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO kurva_nilai (jenis, item_id, metode, parameter, guru_id)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created
+    `
+	if err := tx.QueryRowxContext(ctx, query, kurva.Jenis, kurva.ItemID, kurva.Metode, kurva.Parameter, kurva.GuruID).Scan(&kurva.ID, &kurva.Created); err != nil {
+		return err
+	}
+
+	updateQuery := "UPDATE hasil_tugas SET nilai = $1, kurva_id = $4, updated = NOW() WHERE tugas_id = $2 AND siswa_id = $3"
+	if kurva.Jenis == "quiz" {
+		updateQuery = "UPDATE hasil_quiz SET nilai = $1, kurva_id = $4, updated = NOW() WHERE quiz_id = $2 AND siswa_id = $3"
+	}
+	insertQuery := "INSERT INTO nilai_mentah_kurva (kurva_id, siswa_id, nilai_mentah, nilai_kurva) VALUES ($1, $2, $3, $4)"
+	for _, item := range nilai {
+		if _, err := tx.ExecContext(ctx, insertQuery, kurva.ID, item.SiswaID, item.NilaiMentah, item.NilaiKurva); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, updateQuery, item.NilaiKurva, kurva.ItemID, item.SiswaID, kurva.ID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// Kembalikan mengembalikan nilai mentah lalu menandai kurva dibatalkan. Nilai yang sudah diubah setelah
+// kurva diterapkan (misalnya lewat banding) tidak lagi bertanda kurva_id sehingga tidak ditimpa; nilai mentahnya tetap disimpan pada kurva yang
+// dibatalkan dan dikembalikan sebagai nilai yang tidak dipulihkan. Mengembalikan jumlah nilai yang
+// dipulihkan.
+func (r *kurvaNilaiRepository) Kembalikan(ctx context.Context, kurva *models.KurvaNilai) (int, []models.NilaiMentahKurva, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		WITH dipulihkan AS (
+			UPDATE hasil_tugas ht SET nilai = nm.nilai_mentah, kurva_id = NULL, updated = NOW()
+			FROM nilai_mentah_kurva nm
+			WHERE nm.kurva_id = $1 AND ht.tugas_id = $2 AND ht.siswa_id = nm.siswa_id AND ht.kurva_id = nm.kurva_id
+			RETURNING ht.siswa_id
+		)
+		DELETE FROM nilai_mentah_kurva nm USING dipulihkan d
+		WHERE nm.kurva_id = $1 AND nm.siswa_id = d.siswa_id
+	`
+	if kurva.Jenis == "quiz" {
+		query = `
+			WITH dipulihkan AS (
+				UPDATE hasil_quiz hq SET nilai = nm.nilai_mentah, kurva_id = NULL, updated = NOW()
+				FROM nilai_mentah_kurva nm
+				WHERE nm.kurva_id = $1 AND hq.quiz_id = $2 AND hq.siswa_id = nm.siswa_id AND hq.kurva_id = nm.kurva_id
+				RETURNING hq.siswa_id
+			)
+			DELETE FROM nilai_mentah_kurva nm USING dipulihkan d
+			WHERE nm.kurva_id = $1 AND nm.siswa_id = d.siswa_id
+		`
+	}
+	result, err := tx.ExecContext(ctx, query, kurva.ID, kurva.ItemID)
+	if err != nil {
+		return 0, nil, err
+	}
+	dipulihkan, err := result.RowsAffected()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var dilewati []models.NilaiMentahKurva
+	query = "SELECT * FROM nilai_mentah_kurva WHERE kurva_id = $1 ORDER BY siswa_id ASC"
+	if err := tx.SelectContext(ctx, &dilewati, query, kurva.ID); err != nil {
+		return 0, nil, err
+	}
+
+	if len(dilewati) == 0 {
+		_, err = tx.ExecContext(ctx, "DELETE FROM kurva_nilai WHERE id = $1", kurva.ID)
+	} else {
+		_, err = tx.ExecContext(ctx, "UPDATE kurva_nilai SET dibatalkan = NOW() WHERE id = $1", kurva.ID)
+	}
+	if err != nil {
+		return 0, nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	return int(dipulihkan), dilewati, nil
+}
@@ -0,0 +1,77 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type NotifikasiRepository interface {
+	Create(ctx context.Context, notifikasi *models.Notifikasi) error
+	GetAllByUser(ctx context.Context, role string, userID int, hanyaBelumDibaca bool) ([]models.Notifikasi, error)
+	TandaiDibaca(ctx context.Context, id int, role string, userID int) error
+}
+
+type notifikasiRepository struct {
+	db *sqlx.DB
+}
+
+func NewNotifikasiRepository(db *sqlx.DB) NotifikasiRepository {
+	return &notifikasiRepository{db: db}
+}
+
+func (r *notifikasiRepository) Create(ctx context.Context, notifikasi *models.Notifikasi) error {
+	return insertNotifikasi(ctx, r.db, notifikasi.Role, notifikasi.UserID, notifikasi.Judul, notifikasi.Pesan)
+}
+
+func (r *notifikasiRepository) GetAllByUser(ctx context.Context, role string, userID int, hanyaBelumDibaca bool) ([]models.Notifikasi, error) {
+	var results []models.Notifikasi
+	query := `
+		SELECT * FROM notifikasi
+		WHERE role = $1 AND user_id = $2 AND (NOT $3 OR NOT dibaca)
+		ORDER BY created DESC
+		LIMIT 100
+	`
+	err := r.db.SelectContext(ctx, &results, query, role, userID, hanyaBelumDibaca)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// TandaiDibaca hanya mengubah notifikasi milik pengguna tersebut; selain itu mengembalikan sql.ErrNoRows.
+func (r *notifikasiRepository) TandaiDibaca(ctx context.Context, id int, role string, userID int) error {
+	query := "UPDATE notifikasi SET dibaca = TRUE WHERE id = $1 AND role = $2 AND user_id = $3"
+	result, err := r.db.ExecContext(ctx, query, id, role, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// insertNotifikasi dipakai juga oleh repository lain agar notifikasi tersimpan dalam transaksi
+// yang sama dengan perubahan yang memicunya.
+func insertNotifikasi(ctx context.Context, db sqlx.ExecerContext, role string, userID int, judul string, pesan string) error {
+	query := "INSERT INTO notifikasi (role, user_id, judul, pesan) VALUES ($1, $2, $3, $4)"
+	_, err := db.ExecContext(ctx, query, role, userID, judul, pesan)
+	return err
+}
+
+// insertNotifikasiKelas mengirim notifikasi yang sama ke seluruh siswa satu kelas.
+func insertNotifikasiKelas(ctx context.Context, db sqlx.ExecerContext, kelasID int, judul string, pesan string) error {
+	query := `
+		INSERT INTO notifikasi (role, user_id, judul, pesan)
+		SELECT 'siswa', id, $2, $3 FROM siswa WHERE kelas_id = $1
+	`
+	_, err := db.ExecContext(ctx, query, kelasID, judul, pesan)
+	return err
+}
@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type OrangTuaRepository interface {
+	Create(ctx context.Context, orangTua *models.OrangTua, siswaIDs []int) error
+	GetByID(ctx context.Context, id int) (*models.OrangTua, error)
+	GetByEmail(ctx context.Context, email string) (*models.OrangTua, error)
+	HubungkanSiswa(ctx context.Context, orangTuaID int, siswaID int) error
+	GetAnak(ctx context.Context, orangTuaID int) ([]models.Siswa, error)
+	IsOrangTuaSiswa(ctx context.Context, orangTuaID int, siswaID int) (bool, error)
+}
+
+type orangTuaRepository struct {
+	db *sqlx.DB
+}
+
+func NewOrangTuaRepository(db *sqlx.DB) OrangTuaRepository {
+	return &orangTuaRepository{db: db}
+}
+
+// Create membuat akun orang tua sekaligus menghubungkannya ke siswa dalam satu transaksi.
+func (r *orangTuaRepository) Create(ctx context.Context, orangTua *models.OrangTua, siswaIDs []int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO orang_tua (nama, email, password, no_hp)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created, updated
+    `
+	err = tx.QueryRowxContext(ctx, query, orangTua.Nama, orangTua.Email, orangTua.Password, orangTua.NoHp).
+		Scan(&orangTua.ID, &orangTua.Created, &orangTua.Updated)
+	if err != nil {
+		return err
+	}
+
+	for _, siswaID := range siswaIDs {
+		query := "INSERT INTO orang_tua_siswa (orang_tua_id, siswa_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+		if _, err := tx.ExecContext(ctx, query, orangTua.ID, siswaID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *orangTuaRepository) GetByID(ctx context.Context, id int) (*models.OrangTua, error) {
+	var orangTua models.OrangTua
+	query := "SELECT * FROM orang_tua WHERE id = $1"
+	err := r.db.GetContext(ctx, &orangTua, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &orangTua, nil
+}
+
+func (r *orangTuaRepository) GetByEmail(ctx context.Context, email string) (*models.OrangTua, error) {
+	var orangTua models.OrangTua
+	query := "SELECT * FROM orang_tua WHERE email = $1"
+	err := r.db.GetContext(ctx, &orangTua, query, email)
+	if err != nil {
+		return nil, err
+	}
+	return &orangTua, nil
+}
+
+func (r *orangTuaRepository) HubungkanSiswa(ctx context.Context, orangTuaID int, siswaID int) error {
+	query := "INSERT INTO orang_tua_siswa (orang_tua_id, siswa_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+	_, err := r.db.ExecContext(ctx, query, orangTuaID, siswaID)
+	return err
+}
+
+func (r *orangTuaRepository) GetAnak(ctx context.Context, orangTuaID int) ([]models.Siswa, error) {
+	var results []models.Siswa
+	query := `
+		SELECT s.* FROM siswa s
+		JOIN orang_tua_siswa ots ON ots.siswa_id = s.id
+		WHERE ots.orang_tua_id = $1
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, orangTuaID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *orangTuaRepository) IsOrangTuaSiswa(ctx context.Context, orangTuaID int, siswaID int) (bool, error) {
+	var terhubung bool
+	query := "SELECT EXISTS (SELECT 1 FROM orang_tua_siswa WHERE orang_tua_id = $1 AND siswa_id = $2)"
+	err := r.db.GetContext(ctx, &terhubung, query, orangTuaID, siswaID)
+	if err != nil {
+		return false, err
+	}
+	return terhubung, nil
+}
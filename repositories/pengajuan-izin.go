@@ -0,0 +1,245 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrPengajuanTumpang dikembalikan saat siswa sudah memiliki pengajuan menunggu atau disetujui
+	// yang beririsan dengan rentang tanggal yang diajukan.
+	ErrPengajuanTumpang = errors.New("pengajuan izin beririsan dengan pengajuan lain")
+	// ErrPengajuanSudahDiproses dikembalikan saat pengajuan yang akan diproses tidak lagi menunggu.
+	ErrPengajuanSudahDiproses = errors.New("pengajuan izin sudah diproses")
+)
+
+// PengajuanIzinDetail adalah pengajuan izin beserta nama siswa, kelas, dan wali kelasnya saat ini.
+type PengajuanIzinDetail struct {
+	models.PengajuanIzin
+	NamaSiswa   string  `db:"nama_siswa"`
+	KelasID     *int    `db:"kelas_id"`
+	NamaKelas   *string `db:"nama_kelas"`
+	WaliKelasID *int    `db:"wali_kelas_id"`
+}
+
+type PengajuanIzinRepository interface {
+	Create(ctx context.Context, pengajuan *models.PengajuanIzin, notifikasi []models.Notifikasi) error
+	GetByID(ctx context.Context, id int) (*PengajuanIzinDetail, error)
+	GetAllBySiswaID(ctx context.Context, siswaID int) ([]PengajuanIzinDetail, error)
+	GetAllByOrangTuaID(ctx context.Context, orangTuaID int) ([]PengajuanIzinDetail, error)
+	GetAllByWaliKelas(ctx context.Context, guruID int, status string) ([]PengajuanIzinDetail, error)
+	Proses(ctx context.Context, id int, status string, guruID int, tanggapan *string, notifikasi []models.Notifikasi) (int, error)
+}
+
+type pengajuanIzinRepository struct {
+	db *sqlx.DB
+}
+
+func NewPengajuanIzinRepository(db *sqlx.DB) PengajuanIzinRepository {
+	return &pengajuanIzinRepository{db: db}
+}
+
+const pengajuanIzinSelect = `
+	SELECT
+		pi.*,
+		s.nama AS nama_siswa, s.kelas_id, k.name AS nama_kelas, k.guru_id AS wali_kelas_id
+	FROM pengajuan_izin pi
+	JOIN siswa s ON pi.siswa_id = s.id
+	LEFT JOIN kelas k ON s.kelas_id = k.id
+`
+
+// terapkanPengajuanIzinQuery mengisi absensi siswa dengan jenis pengajuan yang disetujui pada setiap
+// sesi absensi kelasnya di rentang pengajuan. Status hadir tidak ditimpa karena siswa ternyata masuk.
+// $1 bukan nol membatasi ke satu pengajuan, $2 bukan nol membatasi ke satu sesi.
+const terapkanPengajuanIzinQuery = `
+	INSERT INTO absensi (sesi_id, siswa_id, status, catatan)
+	SELECT DISTINCT ON (sa.id, pi.siswa_id) sa.id, pi.siswa_id, pi.jenis, 'Pengajuan izin #' || pi.id
+	FROM pengajuan_izin pi
+	JOIN siswa s ON pi.siswa_id = s.id
+	JOIN sesi_absensi sa ON sa.kelas_id = s.kelas_id AND sa.tanggal BETWEEN pi.tanggal_mulai AND pi.tanggal_selesai
+	WHERE pi.status = 'disetujui' AND ($1 = 0 OR pi.id = $1) AND ($2 = 0 OR sa.id = $2)
+	ORDER BY sa.id, pi.siswa_id, pi.id DESC
+	ON CONFLICT (sesi_id, siswa_id) DO UPDATE SET
+		status = EXCLUDED.status,
+		catatan = EXCLUDED.catatan,
+		updated = NOW()
+	WHERE absensi.status <> 'hadir'
+`
+
+// terapkanPengajuanIzin menjalankan terapkanPengajuanIzinQuery dan mengembalikan jumlah absensi
+// yang diisi atau diubah.
+func terapkanPengajuanIzin(ctx context.Context, db sqlx.ExecerContext, pengajuanID int, sesiID int) (int, error) {
+	result, err := db.ExecContext(ctx, terapkanPengajuanIzinQuery, pengajuanID, sesiID)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rows), nil
+}
+
+// Create menyimpan pengajuan beserta notifikasi untuk wali kelas dalam satu transaksi. Pengajuan
+// yang beririsan dengan pengajuan lain siswa itu yang masih menunggu atau sudah disetujui ditolak.
+func (r *pengajuanIzinRepository) Create(ctx context.Context, pengajuan *models.PengajuanIzin, notifikasi []models.Notifikasi) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SELECT id FROM siswa WHERE id = $1 FOR UPDATE", pengajuan.SiswaID); err != nil {
+		return err
+	}
+
+	mulai := formatTanggal(pengajuan.TanggalMulai)
+	selesai := formatTanggal(pengajuan.TanggalSelesai)
+
+	var tumpang bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM pengajuan_izin
+			WHERE siswa_id = $1 AND status IN ('menunggu', 'disetujui')
+				AND tanggal_mulai <= $3::date AND tanggal_selesai >= $2::date
+		)
+	`
+	if err := tx.GetContext(ctx, &tumpang, query, pengajuan.SiswaID, mulai, selesai); err != nil {
+		return err
+	}
+	if tumpang {
+		return ErrPengajuanTumpang
+	}
+
+	query = `
+        INSERT INTO pengajuan_izin (siswa_id, jenis, tanggal_mulai, tanggal_selesai, alasan, surat_dokter_url, diajukan_role, diajukan_id)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        RETURNING id, status, created, updated
+    `
+	err = tx.QueryRowxContext(ctx, query,
+		pengajuan.SiswaID, pengajuan.Jenis, mulai, selesai, pengajuan.Alasan, pengajuan.SuratDokterUrl,
+		pengajuan.DiajukanRole, pengajuan.DiajukanID,
+	).Scan(&pengajuan.ID, &pengajuan.Status, &pengajuan.Created, &pengajuan.Updated)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range notifikasi {
+		if err := insertNotifikasi(ctx, tx, item.Role, item.UserID, item.Judul, item.Pesan); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *pengajuanIzinRepository) GetByID(ctx context.Context, id int) (*PengajuanIzinDetail, error) {
+	var pengajuan PengajuanIzinDetail
+	query := pengajuanIzinSelect + " WHERE pi.id = $1"
+	err := r.db.GetContext(ctx, &pengajuan, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &pengajuan, nil
+}
+
+func (r *pengajuanIzinRepository) GetAllBySiswaID(ctx context.Context, siswaID int) ([]PengajuanIzinDetail, error) {
+	var results []PengajuanIzinDetail
+	query := pengajuanIzinSelect + " WHERE pi.siswa_id = $1 ORDER BY pi.created DESC"
+	err := r.db.SelectContext(ctx, &results, query, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAllByOrangTuaID mengambil pengajuan seluruh anak yang terhubung dengan akun orang tua.
+func (r *pengajuanIzinRepository) GetAllByOrangTuaID(ctx context.Context, orangTuaID int) ([]PengajuanIzinDetail, error) {
+	var results []PengajuanIzinDetail
+	query := pengajuanIzinSelect + `
+		JOIN orang_tua_siswa ots ON ots.siswa_id = pi.siswa_id
+		WHERE ots.orang_tua_id = $1
+		ORDER BY pi.created DESC
+	`
+	err := r.db.SelectContext(ctx, &results, query, orangTuaID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAllByWaliKelas mengambil pengajuan siswa di kelas yang diampu guru sebagai wali kelas.
+// Status kosong berarti semua status.
+func (r *pengajuanIzinRepository) GetAllByWaliKelas(ctx context.Context, guruID int, status string) ([]PengajuanIzinDetail, error) {
+	var results []PengajuanIzinDetail
+	query := pengajuanIzinSelect + `
+		WHERE k.guru_id = $1 AND ($2 = '' OR pi.status = $2)
+		ORDER BY pi.created DESC
+	`
+	err := r.db.SelectContext(ctx, &results, query, guruID, status)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Proses menyetujui atau menolak pengajuan yang masih menunggu beserta notifikasinya dalam satu
+// transaksi. Pengajuan yang disetujui langsung diterapkan ke absensi yang sudah ada; jumlah absensi
+// yang berubah dikembalikan.
+func (r *pengajuanIzinRepository) Proses(ctx context.Context, id int, status string, guruID int, tanggapan *string, notifikasi []models.Notifikasi) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		UPDATE pengajuan_izin SET
+			status = $1,
+			tanggapan = $2,
+			guru_id = $3,
+			diproses = $4,
+			updated = $4
+		WHERE id = $5 AND status = 'menunggu'
+	`
+	result, err := tx.ExecContext(ctx, query, status, tanggapan, guruID, now, id)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	if rows == 0 {
+		var ada bool
+		if err := tx.GetContext(ctx, &ada, "SELECT EXISTS (SELECT 1 FROM pengajuan_izin WHERE id = $1)", id); err != nil {
+			return 0, err
+		}
+		if !ada {
+			return 0, sql.ErrNoRows
+		}
+		return 0, ErrPengajuanSudahDiproses
+	}
+
+	diterapkan := 0
+	if status == "disetujui" {
+		diterapkan, err = terapkanPengajuanIzin(ctx, tx, id, 0)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, item := range notifikasi {
+		if err := insertNotifikasi(ctx, tx, item.Role, item.UserID, item.Judul, item.Pesan); err != nil {
+			return 0, err
+		}
+	}
+
+	return diterapkan, tx.Commit()
+}
@@ -0,0 +1,291 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PenilaianSejawatTugas adalah penugasan penilaian sejawat dari sisi siswa penilai.
+// Identitas pemilik jawaban sengaja tidak disertakan agar penilaian tetap anonim; FileJawabanUrl
+// memuat ID siswa pemilik sehingga hanya dipakai di server untuk menyajikan file.
+type PenilaianSejawatTugas struct {
+	models.PenilaianSejawat
+	JudulTugas     string  `db:"judul_tugas"`
+	FileJawabanUrl *string `db:"file_jawaban_url"`
+}
+
+type PenilaianSejawatDetail struct {
+	models.PenilaianSejawat
+	NamaPenilai string `db:"nama_penilai"`
+}
+
+type RekapPenilaianSejawat struct {
+	HasilTugasID    int      `db:"hasil_tugas_id"`
+	SiswaID         int      `db:"siswa_id"`
+	NamaSiswa       string   `db:"nama_siswa"`
+	NilaiGuru       *float64 `db:"nilai_guru"`
+	RataRataSejawat *float64 `db:"rata_rata_sejawat"`
+	JumlahSelesai   int      `db:"jumlah_selesai"`
+	JumlahPenilai   int      `db:"jumlah_penilai"`
+}
+
+type PenilaianSejawatRepository interface {
+	ReplaceRubrik(ctx context.Context, tugasID int, rubrik []models.RubrikTugas) error
+	GetRubrikByTugasID(ctx context.Context, tugasID int) ([]models.RubrikTugas, error)
+	CreateBatch(ctx context.Context, penilaian []models.PenilaianSejawat) error
+	CountByTugasID(ctx context.Context, tugasID int) (int, error)
+	GetByID(ctx context.Context, id int) (*models.PenilaianSejawat, error)
+	GetAllByPenilaiID(ctx context.Context, penilaiID int) ([]PenilaianSejawatTugas, error)
+	GetAllByTugasID(ctx context.Context, tugasID int) ([]PenilaianSejawatDetail, error)
+	Submit(ctx context.Context, id int, nilai float64, komentar *string, skor []models.SkorRubrikSejawat) error
+	GetRekapByTugasID(ctx context.Context, tugasID int) ([]RekapPenilaianSejawat, error)
+	ApplyToNilai(ctx context.Context, tugasID int, bobot float64) error
+	BatalkanNilai(ctx context.Context, tugasID int) (int, error)
+}
+
+type penilaianSejawatRepository struct {
+	db *sqlx.DB
+}
+
+func NewPenilaianSejawatRepository(db *sqlx.DB) PenilaianSejawatRepository {
+	return &penilaianSejawatRepository{db: db}
+}
+
+// ReplaceRubrik mengganti seluruh kriteria rubrik suatu tugas.
+func (r *penilaianSejawatRepository) ReplaceRubrik(ctx context.Context, tugasID int, rubrik []models.RubrikTugas) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM rubrik_tugas WHERE tugas_id = $1", tugasID); err != nil {
+		return err
+	}
+
+	query := `
+        INSERT INTO rubrik_tugas (tugas_id, kriteria, deskripsi, bobot, tujuan_pembelajaran_id)
+        VALUES (:tugas_id, :kriteria, :deskripsi, :bobot, :tujuan_pembelajaran_id)
+    `
+	for i := range rubrik {
+		rubrik[i].TugasID = tugasID
+		if _, err := tx.NamedExecContext(ctx, query, &rubrik[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *penilaianSejawatRepository) GetRubrikByTugasID(ctx context.Context, tugasID int) ([]models.RubrikTugas, error) {
+	var rubrik []models.RubrikTugas
+	query := "SELECT * FROM rubrik_tugas WHERE tugas_id = $1 ORDER BY id ASC"
+	err := r.db.SelectContext(ctx, &rubrik, query, tugasID)
+	if err != nil {
+		return nil, err
+	}
+	return rubrik, nil
+}
+
+// CreateBatch menyimpan hasil distribusi penilaian sejawat dalam satu transaksi.
+func (r *penilaianSejawatRepository) CreateBatch(ctx context.Context, penilaian []models.PenilaianSejawat) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+        INSERT INTO penilaian_sejawat (tugas_id, hasil_tugas_id, penilai_id, status)
+        VALUES (:tugas_id, :hasil_tugas_id, :penilai_id, :status)
+    `
+	for i := range penilaian {
+		if _, err := tx.NamedExecContext(ctx, query, &penilaian[i]); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *penilaianSejawatRepository) CountByTugasID(ctx context.Context, tugasID int) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM penilaian_sejawat WHERE tugas_id = $1"
+	err := r.db.GetContext(ctx, &count, query, tugasID)
+	return count, err
+}
+
+func (r *penilaianSejawatRepository) GetByID(ctx context.Context, id int) (*models.PenilaianSejawat, error) {
+	var penilaian models.PenilaianSejawat
+	query := "SELECT * FROM penilaian_sejawat WHERE id = $1"
+	err := r.db.GetContext(ctx, &penilaian, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &penilaian, nil
+}
+
+// GetAllByPenilaiID mengambil semua jawaban yang harus dinilai oleh seorang siswa.
+func (r *penilaianSejawatRepository) GetAllByPenilaiID(ctx context.Context, penilaiID int) ([]PenilaianSejawatTugas, error) {
+	var results []PenilaianSejawatTugas
+	query := `
+		SELECT
+			ps.*,
+			t.judul AS judul_tugas,
+			ht.file_jawaban_url
+		FROM penilaian_sejawat ps
+		JOIN tugas t ON ps.tugas_id = t.id
+		JOIN hasil_tugas ht ON ps.hasil_tugas_id = ht.id
+		WHERE ps.penilai_id = $1
+		ORDER BY ps.status ASC, t.deadline DESC
+	`
+	err := r.db.SelectContext(ctx, &results, query, penilaiID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAllByTugasID mengambil seluruh penilaian sejawat suatu tugas beserta nama penilainya (khusus guru).
+func (r *penilaianSejawatRepository) GetAllByTugasID(ctx context.Context, tugasID int) ([]PenilaianSejawatDetail, error) {
+	var results []PenilaianSejawatDetail
+	query := `
+		SELECT
+			ps.*,
+			s.nama AS nama_penilai
+		FROM penilaian_sejawat ps
+		JOIN siswa s ON ps.penilai_id = s.id
+		WHERE ps.tugas_id = $1
+		ORDER BY ps.hasil_tugas_id ASC, s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, tugasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// Submit menyimpan skor per kriteria rubrik dan nilai akhir penilaian sejawat.
+func (r *penilaianSejawatRepository) Submit(ctx context.Context, id int, nilai float64, komentar *string, skor []models.SkorRubrikSejawat) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM skor_rubrik_sejawat WHERE penilaian_id = $1", id); err != nil {
+		return err
+	}
+
+	for _, item := range skor {
+		query := "INSERT INTO skor_rubrik_sejawat (penilaian_id, rubrik_id, skor) VALUES ($1, $2, $3)"
+		if _, err := tx.ExecContext(ctx, query, id, item.RubrikID, item.Skor); err != nil {
+			return err
+		}
+	}
+
+	query := "UPDATE penilaian_sejawat SET nilai = $1, komentar = $2, status = 'selesai', updated = $3 WHERE id = $4"
+	if _, err := tx.ExecContext(ctx, query, nilai, komentar, time.Now(), id); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetRekapByTugasID menghitung rata-rata nilai sejawat untuk setiap pengumpulan tugas.
+func (r *penilaianSejawatRepository) GetRekapByTugasID(ctx context.Context, tugasID int) ([]RekapPenilaianSejawat, error) {
+	var results []RekapPenilaianSejawat
+	query := `
+		SELECT
+			ht.id AS hasil_tugas_id,
+			ht.siswa_id,
+			s.nama AS nama_siswa,
+			COALESCE(ht.nilai_sebelum_sejawat, ht.nilai) AS nilai_guru,
+			AVG(ps.nilai) FILTER (WHERE ps.status = 'selesai') AS rata_rata_sejawat,
+			COUNT(ps.id) FILTER (WHERE ps.status = 'selesai') AS jumlah_selesai,
+			COUNT(ps.id) AS jumlah_penilai
+		FROM hasil_tugas ht
+		JOIN siswa s ON ht.siswa_id = s.id
+		LEFT JOIN penilaian_sejawat ps ON ps.hasil_tugas_id = ht.id
+		WHERE ht.tugas_id = $1
+		GROUP BY ht.id, s.nama
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, tugasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ApplyToNilai menggabungkan rata-rata nilai sejawat ke nilai guru dengan bobot (persen) tertentu,
+// lalu menandai tugas agar penggabungan tidak diterapkan dua kali. Nilai guru disimpan di
+// nilai_sebelum_sejawat agar penggabungan dapat dibatalkan.
+func (r *penilaianSejawatRepository) ApplyToNilai(ctx context.Context, tugasID int, bobot float64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE hasil_tugas ht SET
+			nilai_sebelum_sejawat = ht.nilai,
+			nilai = ROUND(((1 - $2 / 100.0) * ht.nilai + ($2 / 100.0) * p.rata_rata)::numeric, 2),
+			kurva_id = NULL,
+			updated = NOW()
+		FROM (
+			SELECT hasil_tugas_id, AVG(nilai) AS rata_rata
+			FROM penilaian_sejawat
+			WHERE tugas_id = $1 AND status = 'selesai'
+			GROUP BY hasil_tugas_id
+		) p
+		WHERE ht.id = p.hasil_tugas_id AND ht.nilai IS NOT NULL
+	`
+	if _, err := tx.ExecContext(ctx, query, tugasID, bobot); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tugas SET peer_review_diterapkan = TRUE, updated = NOW() WHERE id = $1", tugasID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// BatalkanNilai mengembalikan nilai guru yang disimpan ApplyToNilai dan membuka kembali penilaian
+// sejawat tugas. Hasil tugas yang sudah dinilai ulang guru sesudah penggabungan tidak lagi memiliki
+// nilai_sebelum_sejawat sehingga tidak diubah. Jumlah nilai yang dikembalikan dikembalikan.
+func (r *penilaianSejawatRepository) BatalkanNilai(ctx context.Context, tugasID int) (int, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE hasil_tugas SET
+			nilai = nilai_sebelum_sejawat,
+			nilai_sebelum_sejawat = NULL,
+			kurva_id = NULL,
+			updated = NOW()
+		WHERE tugas_id = $1 AND nilai_sebelum_sejawat IS NOT NULL
+	`
+	result, err := tx.ExecContext(ctx, query, tugasID)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE tugas SET peer_review_diterapkan = FALSE, updated = NOW() WHERE id = $1", tugasID); err != nil {
+		return 0, err
+	}
+
+	return int(rows), tx.Commit()
+}
@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// RingkasanTugasSiswa merangkum pengumpulan tugas seorang siswa untuk tugas yang sudah terbit di kelasnya.
+type RingkasanTugasSiswa struct {
+	SiswaID               int    `db:"siswa_id"`
+	NamaSiswa             string `db:"nama_siswa"`
+	KelasID               int    `db:"kelas_id"`
+	NamaKelas             string `db:"nama_kelas"`
+	TugasJatuhTempo       int    `db:"tugas_jatuh_tempo"`
+	TugasTidakDikumpulkan int    `db:"tugas_tidak_dikumpulkan"`
+	JumlahDikumpulkan     int    `db:"jumlah_dikumpulkan"`
+	JumlahTerlambat       int    `db:"jumlah_terlambat"`
+}
+
+// RiwayatNilai adalah satu nilai tugas/quiz siswa beserta tanggalnya, untuk melihat tren nilai.
+type RiwayatNilai struct {
+	SiswaID int       `db:"siswa_id"`
+	Nilai   float64   `db:"nilai"`
+	Tanggal time.Time `db:"tanggal"`
+}
+
+type PeringatanDiniRepository interface {
+	GetRingkasanTugas(ctx context.Context, kelasID int) ([]RingkasanTugasSiswa, error)
+	GetRiwayatNilai(ctx context.Context, kelasID int) ([]RiwayatNilai, error)
+}
+
+type peringatanDiniRepository struct {
+	db *sqlx.DB
+}
+
+func NewPeringatanDiniRepository(db *sqlx.DB) PeringatanDiniRepository {
+	return &peringatanDiniRepository{db: db}
+}
+
+// GetRingkasanTugas menghitung tugas lewat deadline yang tidak dikumpulkan dan rasio keterlambatan
+// per siswa. kelasID 0 berarti seluruh kelas. Tugas remedial hanya dihitung untuk pesertanya.
+func (r *peringatanDiniRepository) GetRingkasanTugas(ctx context.Context, kelasID int) ([]RingkasanTugasSiswa, error) {
+	var results []RingkasanTugasSiswa
+	query := `
+		SELECT
+			s.id AS siswa_id,
+			s.nama AS nama_siswa,
+			k.id AS kelas_id,
+			k.name AS nama_kelas,
+			COUNT(t.id) FILTER (WHERE t.deadline < NOW()) AS tugas_jatuh_tempo,
+			COUNT(t.id) FILTER (WHERE t.deadline < NOW() AND ht.id IS NULL) AS tugas_tidak_dikumpulkan,
+			COUNT(ht.id) AS jumlah_dikumpulkan,
+			COUNT(ht.id) FILTER (WHERE ht.status = 'terlambat') AS jumlah_terlambat
+		FROM siswa s
+		JOIN kelas k ON s.kelas_id = k.id
+		LEFT JOIN tugas t ON t.kelas_id = s.kelas_id
+			AND t.is_draft = FALSE AND (t.publish_at IS NULL OR t.publish_at <= NOW())
+			AND (t.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas') OR t.id IN (
+				SELECT rm.item_id FROM remedial rm
+				JOIN peserta_remedial pr ON pr.remedial_id = rm.id
+				WHERE rm.jenis = 'tugas' AND pr.siswa_id = s.id
+			))
+		LEFT JOIN hasil_tugas ht ON ht.tugas_id = t.id AND ht.siswa_id = s.id
+		WHERE ($1 = 0 OR s.kelas_id = $1)
+		GROUP BY s.id, s.nama, k.id, k.name
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetRiwayatNilai mengambil nilai akhir tugas dan quiz siswa yang sudah terbit, terurut per siswa lalu
+// tanggal. Tugas/quiz remedial tidak disertakan karena hasilnya sudah masuk ke nilai tugas/quiz asal.
+// kelasID 0 berarti seluruh kelas.
+func (r *peringatanDiniRepository) GetRiwayatNilai(ctx context.Context, kelasID int) ([]RiwayatNilai, error) {
+	var results []RiwayatNilai
+	query := `
+		SELECT siswa_id, nilai, tanggal
+		FROM (
+			SELECT ht.siswa_id, ht.nilai, t.deadline AS tanggal
+			FROM hasil_tugas ht
+			JOIN tugas t ON ht.tugas_id = t.id
+			JOIN siswa s ON ht.siswa_id = s.id AND s.kelas_id = t.kelas_id
+			WHERE ht.nilai IS NOT NULL AND ($1 = 0 OR t.kelas_id = $1)
+				AND t.status_nilai = 'terbit'
+				AND t.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas')
+			UNION ALL
+			SELECT hq.siswa_id, hq.nilai, hq.tanggal_pengerjaan AS tanggal
+			FROM hasil_quiz hq
+			JOIN quiz q ON hq.quiz_id = q.id
+			JOIN siswa s ON hq.siswa_id = s.id AND s.kelas_id = q.kelas_id
+			WHERE ($1 = 0 OR q.kelas_id = $1)
+				AND q.status_nilai = 'terbit'
+				AND q.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'quiz')
+		) riwayat
+		ORDER BY siswa_id ASC, tanggal ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,76 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NilaiMenungguReview adalah tugas atau quiz yang nilainya menunggu persetujuan wali kelas.
+type NilaiMenungguReview struct {
+	Jenis           string    `db:"jenis"`
+	ID              int       `db:"id"`
+	Judul           string    `db:"judul"`
+	KelasID         int       `db:"kelas_id"`
+	NamaKelas       string    `db:"nama_kelas"`
+	MataPelajaranID int       `db:"mata_pelajaran_id"`
+	Updated         time.Time `db:"updated"`
+}
+
+type PublikasiNilaiRepository interface {
+	UpdateStatus(ctx context.Context, jenis string, id int, status string, catatan *string) error
+	GetMenungguByWaliKelasID(ctx context.Context, guruID int) ([]NilaiMenungguReview, error)
+}
+
+type publikasiNilaiRepository struct {
+	db *sqlx.DB
+}
+
+func NewPublikasiNilaiRepository(db *sqlx.DB) PublikasiNilaiRepository {
+	return &publikasiNilaiRepository{db: db}
+}
+
+// UpdateStatus mengubah status publikasi nilai pada tabel tugas atau quiz sesuai jenis.
+func (r *publikasiNilaiRepository) UpdateStatus(ctx context.Context, jenis string, id int, status string, catatan *string) error {
+	table := "tugas"
+	if jenis == "quiz" {
+		table = "quiz"
+	}
+	query := "UPDATE " + table + " SET status_nilai = $1, catatan_moderasi = $2, updated = $3 WHERE id = $4"
+	result, err := r.db.ExecContext(ctx, query, status, catatan, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// GetMenungguByWaliKelasID mengambil semua tugas dan quiz di kelas perwalian guru yang nilainya menunggu review.
+func (r *publikasiNilaiRepository) GetMenungguByWaliKelasID(ctx context.Context, guruID int) ([]NilaiMenungguReview, error) {
+	var results []NilaiMenungguReview
+	query := `
+		SELECT 'tugas' AS jenis, t.id, t.judul, t.kelas_id, k.name AS nama_kelas, t.mata_pelajaran_id, t.updated
+		FROM tugas t
+		JOIN kelas k ON t.kelas_id = k.id
+		WHERE k.guru_id = $1 AND t.status_nilai = 'review'
+		UNION ALL
+		SELECT 'quiz' AS jenis, q.id, q.judul, q.kelas_id, k.name AS nama_kelas, q.mata_pelajaran_id, q.updated
+		FROM quiz q
+		JOIN kelas k ON q.kelas_id = k.id
+		WHERE k.guru_id = $1 AND q.status_nilai = 'review'
+		ORDER BY updated ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, guruID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type QuizRepository interface {
+	GetByID(ctx context.Context, id int) (*models.Quiz, error)
+	GetAllByKelasID(ctx context.Context, kelasID int) ([]models.Quiz, error)
+	GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]models.Quiz, error)
+	DiampuGuru(ctx context.Context, id int, guruID int) (bool, error)
+}
+
+type quizRepository struct {
+	db *sqlx.DB
+}
+
+func NewQuizRepository(db *sqlx.DB) QuizRepository {
+	return &quizRepository{db: db}
+}
+
+func (r *quizRepository) GetByID(ctx context.Context, id int) (*models.Quiz, error) {
+	var quiz models.Quiz
+	query := "SELECT * FROM quiz WHERE id = $1"
+	err := r.db.GetContext(ctx, &quiz, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &quiz, nil
+}
+
+// DiampuGuru memeriksa apakah guru mengampu quiz: wali kelasnya atau guru mapelnya di kelas itu.
+func (r *quizRepository) DiampuGuru(ctx context.Context, id int, guruID int) (bool, error) {
+	var diampu bool
+	query := "SELECT EXISTS (SELECT 1 FROM quiz q WHERE q.id = $1 AND " + pengampuFilter("$2", "q.kelas_id", "q.mata_pelajaran_id") + ")"
+	err := r.db.GetContext(ctx, &diampu, query, id, guruID)
+	return diampu, err
+}
+
+// GetAllByKelasID mengambil semua quiz untuk satu kelas tertentu.
+func (r *quizRepository) GetAllByKelasID(ctx context.Context, kelasID int) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	query := "SELECT * FROM quiz WHERE kelas_id = $1 ORDER BY created DESC"
+	err := r.db.SelectContext(ctx, &quizzes, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return quizzes, nil
+}
+
+func (r *quizRepository) GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]models.Quiz, error) {
+	var quizzes []models.Quiz
+	query := "SELECT * FROM quiz WHERE kelas_id = $1 AND mata_pelajaran_id = $2 ORDER BY created DESC"
+	err := r.db.SelectContext(ctx, &quizzes, query, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return quizzes, nil
+}
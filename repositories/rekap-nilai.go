@@ -0,0 +1,298 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NilaiKomponen adalah satu nilai tugas atau quiz yang menjadi bahan perhitungan rekap.
+type NilaiKomponen struct {
+	SumberID int     `db:"sumber_id"`
+	Nilai    float64 `db:"nilai"`
+	Kategori string  `db:"kategori"`
+}
+
+// NilaiKomponenMapel adalah NilaiKomponen beserta mapel asalnya, dipakai saat nilai seluruh mapel
+// dibaca sekaligus.
+type NilaiKomponenMapel struct {
+	NilaiKomponen
+	MataPelajaranID int    `db:"mata_pelajaran_id"`
+	NamaMapel       string `db:"nama_mapel"`
+}
+
+type RekapNilaiSiswa struct {
+	models.RekapNilai
+	NamaSiswa string `db:"nama_siswa"`
+}
+
+type RekapNilaiKelas struct {
+	models.RekapNilai
+	NamaSiswa string `db:"nama_siswa"`
+	NamaMapel string `db:"nama_mapel"`
+}
+
+// BarisGradebook adalah satu nilai tugas/quiz seorang siswa beserta rekapnya. Siswa tanpa nilai
+// tetap muncul satu kali dengan Jenis, SumberID, dan Nilai bernilai nil.
+type BarisGradebook struct {
+	SiswaID    int      `db:"siswa_id"`
+	NamaSiswa  string   `db:"nama_siswa"`
+	Jenis      *string  `db:"jenis"`
+	SumberID   *int     `db:"sumber_id"`
+	Nilai      *float64 `db:"nilai"`
+	NilaiTugas *float64 `db:"nilai_tugas"`
+	NilaiQuiz  *float64 `db:"nilai_quiz"`
+	NilaiAkhir *float64 `db:"nilai_akhir"`
+}
+
+type RekapNilaiRepository interface {
+	Upsert(ctx context.Context, rekap *models.RekapNilai) error
+	Delete(ctx context.Context, siswaID int, kelasID int, mapelID int) error
+	GetKelasIDsByMapelID(ctx context.Context, mapelID int) ([]int, error)
+	GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]RekapNilaiSiswa, error)
+	GetAllBySiswaID(ctx context.Context, siswaID int) ([]models.RekapNilai, error)
+	GetAllByKelasID(ctx context.Context, kelasID int) ([]RekapNilaiKelas, error)
+	GetNilaiTugas(ctx context.Context, siswaID int, kelasID int, mapelID int) ([]NilaiKomponen, error)
+	IterateGradebook(ctx context.Context, kelasID int, mapelID int, fn func(BarisGradebook) error) error
+	GetNilaiQuiz(ctx context.Context, siswaID int, kelasID int, mapelID int) ([]NilaiKomponen, error)
+	GetNilaiTugasPeriode(ctx context.Context, siswaID int, kelasID int, mulai time.Time, selesai time.Time) ([]NilaiKomponenMapel, error)
+	GetNilaiQuizPeriode(ctx context.Context, siswaID int, kelasID int, mulai time.Time, selesai time.Time) ([]NilaiKomponenMapel, error)
+}
+
+type rekapNilaiRepository struct {
+	db *sqlx.DB
+}
+
+func NewRekapNilaiRepository(db *sqlx.DB) RekapNilaiRepository {
+	return &rekapNilaiRepository{db: db}
+}
+
+// Upsert menyimpan rekap nilai siswa untuk satu mapel dan kelas, menimpa rekap sebelumnya jika ada.
+func (r *rekapNilaiRepository) Upsert(ctx context.Context, rekap *models.RekapNilai) error {
+	query := `
+        INSERT INTO rekap_nilai (siswa_id, mata_pelajaran_id, kelas_id, nilai_quiz, nilai_tugas, nilai_akhir)
+        VALUES (:siswa_id, :mata_pelajaran_id, :kelas_id, :nilai_quiz, :nilai_tugas, :nilai_akhir)
+        ON CONFLICT (siswa_id, mata_pelajaran_id, kelas_id) DO UPDATE SET
+            nilai_quiz = EXCLUDED.nilai_quiz,
+            nilai_tugas = EXCLUDED.nilai_tugas,
+            nilai_akhir = EXCLUDED.nilai_akhir,
+            updated = NOW()
+    `
+	_, err := r.db.NamedExecContext(ctx, query, rekap)
+	return err
+}
+
+func (r *rekapNilaiRepository) Delete(ctx context.Context, siswaID int, kelasID int, mapelID int) error {
+	query := "DELETE FROM rekap_nilai WHERE siswa_id = $1 AND kelas_id = $2 AND mata_pelajaran_id = $3"
+	_, err := r.db.ExecContext(ctx, query, siswaID, kelasID, mapelID)
+	return err
+}
+
+// GetKelasIDsByMapelID mengambil kelas yang memiliki tugas atau quiz untuk satu mapel.
+func (r *rekapNilaiRepository) GetKelasIDsByMapelID(ctx context.Context, mapelID int) ([]int, error) {
+	var kelasIDs []int
+	query := `
+		SELECT kelas_id FROM tugas WHERE mata_pelajaran_id = $1
+		UNION
+		SELECT kelas_id FROM quiz WHERE mata_pelajaran_id = $1
+		ORDER BY kelas_id ASC
+	`
+	err := r.db.SelectContext(ctx, &kelasIDs, query, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return kelasIDs, nil
+}
+
+func (r *rekapNilaiRepository) GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]RekapNilaiSiswa, error) {
+	var results []RekapNilaiSiswa
+	query := `
+		SELECT
+			rn.*,
+			s.nama AS nama_siswa
+		FROM rekap_nilai rn
+		JOIN siswa s ON rn.siswa_id = s.id
+		WHERE rn.kelas_id = $1 AND rn.mata_pelajaran_id = $2
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *rekapNilaiRepository) GetAllBySiswaID(ctx context.Context, siswaID int) ([]models.RekapNilai, error) {
+	var results []models.RekapNilai
+	query := "SELECT * FROM rekap_nilai WHERE siswa_id = $1 ORDER BY mata_pelajaran_id ASC"
+	err := r.db.SelectContext(ctx, &results, query, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetAllByKelasID mengambil rekap nilai seluruh siswa dan mapel di satu kelas.
+func (r *rekapNilaiRepository) GetAllByKelasID(ctx context.Context, kelasID int) ([]RekapNilaiKelas, error) {
+	var results []RekapNilaiKelas
+	query := `
+		SELECT
+			rn.*,
+			s.nama AS nama_siswa,
+			mp.nama AS nama_mapel
+		FROM rekap_nilai rn
+		JOIN siswa s ON rn.siswa_id = s.id
+		JOIN mata_pelajaran mp ON rn.mata_pelajaran_id = mp.id
+		WHERE rn.kelas_id = $1
+		ORDER BY s.nama ASC, mp.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiTugas mengambil semua nilai tugas siswa yang sudah dinilai dan diterbitkan untuk satu kelas
+// dan mapel. Tugas remedial tidak dihitung karena nilainya sudah diterapkan ke tugas asal.
+func (r *rekapNilaiRepository) GetNilaiTugas(ctx context.Context, siswaID int, kelasID int, mapelID int) ([]NilaiKomponen, error) {
+	var results []NilaiKomponen
+	query := `
+		SELECT
+			t.id AS sumber_id,
+			ht.nilai,
+			COALESCE(t.kategori, '') AS kategori
+		FROM hasil_tugas ht
+		JOIN tugas t ON ht.tugas_id = t.id
+		WHERE ht.siswa_id = $1 AND t.kelas_id = $2 AND t.mata_pelajaran_id = $3 AND ht.nilai IS NOT NULL
+			AND t.status_nilai = 'terbit'
+			AND t.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas')
+	`
+	err := r.db.SelectContext(ctx, &results, query, siswaID, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiQuiz mengambil semua nilai quiz siswa yang sudah diterbitkan untuk satu kelas dan mapel.
+func (r *rekapNilaiRepository) GetNilaiQuiz(ctx context.Context, siswaID int, kelasID int, mapelID int) ([]NilaiKomponen, error) {
+	var results []NilaiKomponen
+	query := `
+		SELECT
+			q.id AS sumber_id,
+			hq.nilai,
+			'' AS kategori
+		FROM hasil_quiz hq
+		JOIN quiz q ON hq.quiz_id = q.id
+		WHERE hq.siswa_id = $1 AND q.kelas_id = $2 AND q.mata_pelajaran_id = $3
+			AND q.status_nilai = 'terbit'
+			AND q.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'quiz')
+	`
+	err := r.db.SelectContext(ctx, &results, query, siswaID, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiTugasPeriode mengambil nilai tugas terbit siswa di satu kelas untuk semua mapel, terbatas
+// pada tugas yang tenggatnya jatuh di antara mulai dan selesai.
+func (r *rekapNilaiRepository) GetNilaiTugasPeriode(ctx context.Context, siswaID int, kelasID int, mulai time.Time, selesai time.Time) ([]NilaiKomponenMapel, error) {
+	var results []NilaiKomponenMapel
+	query := `
+		SELECT
+			t.id AS sumber_id,
+			ht.nilai,
+			COALESCE(t.kategori, '') AS kategori,
+			t.mata_pelajaran_id,
+			mp.nama AS nama_mapel
+		FROM hasil_tugas ht
+		JOIN tugas t ON ht.tugas_id = t.id
+		JOIN mata_pelajaran mp ON t.mata_pelajaran_id = mp.id
+		WHERE ht.siswa_id = $1 AND t.kelas_id = $2 AND ht.nilai IS NOT NULL
+			AND t.status_nilai = 'terbit'
+			AND t.deadline::date BETWEEN $3::date AND $4::date
+			AND t.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas')
+	`
+	err := r.db.SelectContext(ctx, &results, query, siswaID, kelasID, formatTanggal(mulai), formatTanggal(selesai))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiQuizPeriode mengambil nilai quiz terbit siswa di satu kelas untuk semua mapel, terbatas
+// pada quiz yang dimulai (atau dibuat, jika tanpa waktu mulai) di antara mulai dan selesai.
+func (r *rekapNilaiRepository) GetNilaiQuizPeriode(ctx context.Context, siswaID int, kelasID int, mulai time.Time, selesai time.Time) ([]NilaiKomponenMapel, error) {
+	var results []NilaiKomponenMapel
+	query := `
+		SELECT
+			q.id AS sumber_id,
+			hq.nilai,
+			'' AS kategori,
+			q.mata_pelajaran_id,
+			mp.nama AS nama_mapel
+		FROM hasil_quiz hq
+		JOIN quiz q ON hq.quiz_id = q.id
+		JOIN mata_pelajaran mp ON q.mata_pelajaran_id = mp.id
+		WHERE hq.siswa_id = $1 AND q.kelas_id = $2
+			AND q.status_nilai = 'terbit'
+			AND COALESCE(q.waktu_mulai, q.created)::date BETWEEN $3::date AND $4::date
+			AND q.id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'quiz')
+	`
+	err := r.db.SelectContext(ctx, &results, query, siswaID, kelasID, formatTanggal(mulai), formatTanggal(selesai))
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// IterateGradebook membaca nilai seluruh siswa kelas untuk satu mapel baris demi baris, terurut per siswa,
+// lalu memanggil fn untuk setiap baris. Dipakai untuk ekspor agar data tidak dimuat sekaligus ke memori.
+func (r *rekapNilaiRepository) IterateGradebook(ctx context.Context, kelasID int, mapelID int, fn func(BarisGradebook) error) error {
+	query := `
+		SELECT
+			s.id AS siswa_id,
+			s.nama AS nama_siswa,
+			n.jenis,
+			n.sumber_id,
+			n.nilai,
+			rn.nilai_tugas,
+			rn.nilai_quiz,
+			rn.nilai_akhir
+		FROM siswa s
+		LEFT JOIN rekap_nilai rn ON rn.siswa_id = s.id AND rn.kelas_id = $1 AND rn.mata_pelajaran_id = $2
+		LEFT JOIN (
+			SELECT 'tugas' AS jenis, ht.tugas_id AS sumber_id, ht.siswa_id, ht.nilai
+			FROM hasil_tugas ht
+			JOIN tugas t ON ht.tugas_id = t.id
+			WHERE t.kelas_id = $1 AND t.mata_pelajaran_id = $2
+			UNION ALL
+			SELECT 'quiz' AS jenis, hq.quiz_id AS sumber_id, hq.siswa_id, hq.nilai
+			FROM hasil_quiz hq
+			JOIN quiz q ON hq.quiz_id = q.id
+			WHERE q.kelas_id = $1 AND q.mata_pelajaran_id = $2
+		) n ON n.siswa_id = s.id
+		WHERE s.kelas_id = $1
+		ORDER BY s.nama ASC, s.id ASC
+	`
+	rows, err := r.db.QueryxContext(ctx, query, kelasID, mapelID)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var baris BarisGradebook
+		if err := rows.StructScan(&baris); err != nil {
+			return err
+		}
+		if err := fn(baris); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type PesertaRemedialSiswa struct {
+	models.PesertaRemedial
+	NamaSiswa string `db:"nama_siswa"`
+}
+
+type nilaiSiswa struct {
+	SiswaID int     `db:"siswa_id"`
+	Nilai   float64 `db:"nilai"`
+}
+
+type RemedialRepository interface {
+	Create(ctx context.Context, remedial *models.Remedial, peserta []models.PesertaRemedial) error
+	GetByID(ctx context.Context, id int) (*models.Remedial, error)
+	GetByItemID(ctx context.Context, jenis string, itemID int) (*models.Remedial, error)
+	GetAllByAsalID(ctx context.Context, jenis string, asalID int) ([]models.Remedial, error)
+	GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]models.Remedial, error)
+	GetPeserta(ctx context.Context, remedialID int) ([]PesertaRemedialSiswa, error)
+	IsPeserta(ctx context.Context, jenis string, itemID int, siswaID int) (bool, error)
+	GetNilaiAsal(ctx context.Context, jenis string, asalID int, siswaID int) (*float64, error)
+	GetNilaiItem(ctx context.Context, jenis string, itemID int) (map[int]float64, error)
+	TerapkanNilai(ctx context.Context, remedial *models.Remedial, siswaID int, nilaiAwal *float64, nilaiRemedial float64, nilaiAkhir float64) error
+}
+
+type remedialRepository struct {
+	db *sqlx.DB
+}
+
+func NewRemedialRepository(db *sqlx.DB) RemedialRepository {
+	return &remedialRepository{db: db}
+}
+
+// Create menyimpan data remedial beserta daftar pesertanya dalam satu transaksi.
+func (r *remedialRepository) Create(ctx context.Context, remedial *models.Remedial, peserta []models.PesertaRemedial) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := "INSERT INTO remedial (jenis, asal_id, item_id, kebijakan) VALUES ($1, $2, $3, $4) RETURNING id"
+	if err := tx.GetContext(ctx, &remedial.ID, query, remedial.Jenis, remedial.AsalID, remedial.ItemID, remedial.Kebijakan); err != nil {
+		return err
+	}
+
+	for _, item := range peserta {
+		query := "INSERT INTO peserta_remedial (remedial_id, siswa_id, nilai_awal) VALUES ($1, $2, $3)"
+		if _, err := tx.ExecContext(ctx, query, remedial.ID, item.SiswaID, item.NilaiAwal); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *remedialRepository) GetByID(ctx context.Context, id int) (*models.Remedial, error) {
+	var remedial models.Remedial
+	query := "SELECT * FROM remedial WHERE id = $1"
+	err := r.db.GetContext(ctx, &remedial, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &remedial, nil
+}
+
+// GetByItemID mencari data remedial berdasarkan tugas/quiz remedialnya.
+func (r *remedialRepository) GetByItemID(ctx context.Context, jenis string, itemID int) (*models.Remedial, error) {
+	var remedial models.Remedial
+	query := "SELECT * FROM remedial WHERE jenis = $1 AND item_id = $2"
+	err := r.db.GetContext(ctx, &remedial, query, jenis, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return &remedial, nil
+}
+
+// GetAllByAsalID mengambil seluruh remedial untuk satu tugas/quiz asal.
+func (r *remedialRepository) GetAllByAsalID(ctx context.Context, jenis string, asalID int) ([]models.Remedial, error) {
+	var results []models.Remedial
+	query := "SELECT * FROM remedial WHERE jenis = $1 AND asal_id = $2"
+	err := r.db.SelectContext(ctx, &results, query, jenis, asalID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *remedialRepository) GetPeserta(ctx context.Context, remedialID int) ([]PesertaRemedialSiswa, error) {
+	var results []PesertaRemedialSiswa
+	query := `
+		SELECT
+			pr.*,
+			s.nama AS nama_siswa
+		FROM peserta_remedial pr
+		JOIN siswa s ON pr.siswa_id = s.id
+		WHERE pr.remedial_id = $1
+		ORDER BY s.nama ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, remedialID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// IsPeserta memeriksa apakah siswa termasuk peserta untuk tugas/quiz remedial tertentu.
+func (r *remedialRepository) IsPeserta(ctx context.Context, jenis string, itemID int, siswaID int) (bool, error) {
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM peserta_remedial pr
+			JOIN remedial rm ON pr.remedial_id = rm.id
+			WHERE rm.jenis = $1 AND rm.item_id = $2 AND pr.siswa_id = $3
+		)
+	`
+	err := r.db.GetContext(ctx, &exists, query, jenis, itemID, siswaID)
+	return exists, err
+}
+
+// GetNilaiAsal mengambil nilai siswa pada tugas/quiz asal. Hasil nil berarti belum ada nilai.
+func (r *remedialRepository) GetNilaiAsal(ctx context.Context, jenis string, asalID int, siswaID int) (*float64, error) {
+	var nilai []*float64
+	query := "SELECT nilai FROM hasil_tugas WHERE tugas_id = $1 AND siswa_id = $2"
+	if jenis == "quiz" {
+		query = "SELECT nilai FROM hasil_quiz WHERE quiz_id = $1 AND siswa_id = $2"
+	}
+	if err := r.db.SelectContext(ctx, &nilai, query, asalID, siswaID); err != nil {
+		return nil, err
+	}
+	if len(nilai) == 0 {
+		return nil, nil
+	}
+	return nilai[0], nil
+}
+
+// GetAllByKelasAndMapelID mengambil seluruh remedial yang tugas/quiz remedialnya berada di satu kelas
+// dan mapel.
+func (r *remedialRepository) GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]models.Remedial, error) {
+	var results []models.Remedial
+	query := `
+		SELECT r.*
+		FROM remedial r
+		LEFT JOIN tugas t ON r.jenis = 'tugas' AND r.item_id = t.id
+		LEFT JOIN quiz q ON r.jenis = 'quiz' AND r.item_id = q.id
+		WHERE COALESCE(t.kelas_id, q.kelas_id) = $1 AND COALESCE(t.mata_pelajaran_id, q.mata_pelajaran_id) = $2
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiItem mengambil nilai setiap siswa yang sudah dinilai pada tugas/quiz, dipetakan per siswa.
+func (r *remedialRepository) GetNilaiItem(ctx context.Context, jenis string, itemID int) (map[int]float64, error) {
+	var rows []nilaiSiswa
+	query := "SELECT siswa_id, nilai FROM hasil_tugas WHERE tugas_id = $1 AND nilai IS NOT NULL"
+	if jenis == "quiz" {
+		query = "SELECT siswa_id, nilai FROM hasil_quiz WHERE quiz_id = $1"
+	}
+	if err := r.db.SelectContext(ctx, &rows, query, itemID); err != nil {
+		return nil, err
+	}
+
+	nilai := make(map[int]float64, len(rows))
+	for _, row := range rows {
+		nilai[row.SiswaID] = row.Nilai
+	}
+	return nilai, nil
+}
+
+// TerapkanNilai mencatat nilai awal dan nilai remedial peserta lalu menulis nilai akhirnya ke hasil
+// tugas/quiz asal. Jika siswa belum memiliki hasil pada tugas/quiz asal, hasil baru dibuat dengan
+// status "remedial".
+func (r *remedialRepository) TerapkanNilai(ctx context.Context, remedial *models.Remedial, siswaID int, nilaiAwal *float64, nilaiRemedial float64, nilaiAkhir float64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	now := time.Now()
+	query := `
+		UPDATE peserta_remedial SET
+			nilai_awal = $1,
+			nilai_remedial = $2,
+			nilai_akhir = $3,
+			diterapkan = $4
+		WHERE remedial_id = $5 AND siswa_id = $6
+	`
+	if _, err := tx.ExecContext(ctx, query, nilaiAwal, nilaiRemedial, nilaiAkhir, now, remedial.ID, siswaID); err != nil {
+		return err
+	}
+
+	updateQuery := "UPDATE hasil_tugas SET nilai = $1, kurva_id = NULL, updated = $2 WHERE tugas_id = $3 AND siswa_id = $4"
+	insertQuery := "INSERT INTO hasil_tugas (tugas_id, siswa_id, tanggal_pengumpulan, status, nilai) VALUES ($3, $4, $2, 'remedial', $1)"
+	if remedial.Jenis == "quiz" {
+		updateQuery = "UPDATE hasil_quiz SET nilai = $1, kurva_id = NULL, updated = $2 WHERE quiz_id = $3 AND siswa_id = $4"
+		insertQuery = "INSERT INTO hasil_quiz (quiz_id, siswa_id, tanggal_pengerjaan, status, nilai) VALUES ($3, $4, $2, 'remedial', $1)"
+	}
+
+	result, err := tx.ExecContext(ctx, updateQuery, nilaiAkhir, now, remedial.AsalID, siswaID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		if _, err := tx.ExecContext(ctx, insertQuery, nilaiAkhir, now, remedial.AsalID, siswaID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
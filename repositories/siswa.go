@@ -28,6 +28,7 @@ type SiswaRepository interface {
 	GetByID(ctx context.Context, id int) (*models.Siswa, error)
 	GetByEmail(ctx context.Context, email string) (*models.Siswa, error)
 	GetProfileByID(ctx context.Context, id int) (*SiswaProfile, error)
+	GetAllByKelasID(ctx context.Context, kelasID int) ([]models.Siswa, error)
 }
 
 type siswaRepository struct {
@@ -94,3 +95,14 @@ func (r *siswaRepository) GetProfileByID(ctx context.Context, id int) (*SiswaPro
 	}
 	return &profile, nil
 }
+
+// GetAllByKelasID mengambil semua siswa yang terdaftar di satu kelas.
+func (r *siswaRepository) GetAllByKelasID(ctx context.Context, kelasID int) ([]models.Siswa, error) {
+	var siswas []models.Siswa
+	query := "SELECT * FROM siswa WHERE kelas_id = $1 ORDER BY nama ASC"
+	err := r.db.SelectContext(ctx, &siswas, query, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	return siswas, nil
+}
@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type TokenKalenderRepository interface {
+	Simpan(ctx context.Context, token *models.TokenKalender) error
+	Hapus(ctx context.Context, role string, userID int) error
+	GetByUser(ctx context.Context, role string, userID int) (*models.TokenKalender, error)
+	GetByHash(ctx context.Context, tokenHash string) (*models.TokenKalender, error)
+}
+
+type tokenKalenderRepository struct {
+	db *sqlx.DB
+}
+
+func NewTokenKalenderRepository(db *sqlx.DB) TokenKalenderRepository {
+	return &tokenKalenderRepository{db: db}
+}
+
+// Simpan membuat token pengguna atau menggantinya jika sudah ada, sehingga URL feed lama langsung
+// tidak berlaku.
+func (r *tokenKalenderRepository) Simpan(ctx context.Context, token *models.TokenKalender) error {
+	query := `
+        INSERT INTO token_kalender (role, user_id, token_hash)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (role, user_id) DO UPDATE SET
+            token_hash = EXCLUDED.token_hash,
+            created = NOW()
+        RETURNING id, created
+    `
+	return r.db.QueryRowxContext(ctx, query, token.Role, token.UserID, token.TokenHash).Scan(&token.ID, &token.Created)
+}
+
+func (r *tokenKalenderRepository) Hapus(ctx context.Context, role string, userID int) error {
+	query := "DELETE FROM token_kalender WHERE role = $1 AND user_id = $2"
+	result, err := r.db.ExecContext(ctx, query, role, userID)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+func (r *tokenKalenderRepository) GetByUser(ctx context.Context, role string, userID int) (*models.TokenKalender, error) {
+	var token models.TokenKalender
+	query := "SELECT * FROM token_kalender WHERE role = $1 AND user_id = $2"
+	err := r.db.GetContext(ctx, &token, query, role, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
+
+func (r *tokenKalenderRepository) GetByHash(ctx context.Context, tokenHash string) (*models.TokenKalender, error) {
+	var token models.TokenKalender
+	query := "SELECT * FROM token_kalender WHERE token_hash = $1"
+	err := r.db.GetContext(ctx, &token, query, tokenHash)
+	if err != nil {
+		return nil, err
+	}
+	return &token, nil
+}
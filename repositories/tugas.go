@@ -3,11 +3,17 @@ package repositories
 import (
 	"be-pui/models"
 	"context"
+	"database/sql"
+	"errors"
 	"time"
 
 	"github.com/jmoiron/sqlx"
 )
 
+// ErrTugasSudahTerbit dikembalikan saat tugas yang akan diterbitkan sudah terbit, sehingga waktu
+// terbitnya tidak ditimpa.
+var ErrTugasSudahTerbit = errors.New("tugas sudah terbit")
+
 type TugasRepository interface {
 	Create(ctx context.Context, tugas *models.Tugas) error
 	Update(ctx context.Context, tugas *models.Tugas) error
@@ -17,7 +23,23 @@ type TugasRepository interface {
 	GetAllByKelasID(ctx context.Context, kelasID int) ([]models.Tugas, error)
 	GetAllByMapelID(ctx context.Context, mapelID int) ([]models.Tugas, error)
 	GetAllByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int) ([]models.Tugas, error) // Method baru
+	GetPublishedByKelasID(ctx context.Context, kelasID int, siswaID int) ([]models.Tugas, error)
+	GetPublishedByMapelID(ctx context.Context, mapelID int, siswaID int) ([]models.Tugas, error)
+	GetPublishedByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int, siswaID int) ([]models.Tugas, error)
+	Publish(ctx context.Context, id int) error
+	DiampuGuru(ctx context.Context, id int, guruID int) (bool, error)
+}
+
+// publishedTugasFilter membatasi query hanya pada tugas yang sudah terbit untuk siswa.
+const publishedTugasFilter = "is_draft = FALSE AND (publish_at IS NULL OR publish_at <= NOW())"
 
+// remedialTugasFilter menyembunyikan tugas remedial dari siswa yang bukan pesertanya.
+// siswaParam adalah placeholder parameter siswa_id pada query, misalnya "$2".
+func remedialTugasFilter(siswaParam string) string {
+	return `(id NOT IN (SELECT item_id FROM remedial WHERE jenis = 'tugas') OR id IN (
+		SELECT rm.item_id FROM remedial rm
+		JOIN peserta_remedial pr ON pr.remedial_id = rm.id
+		WHERE rm.jenis = 'tugas' AND pr.siswa_id = ` + siswaParam + `))`
 }
 
 type tugasRepository struct {
@@ -30,8 +52,8 @@ func NewTugasRepository(db *sqlx.DB) TugasRepository {
 
 func (r *tugasRepository) Create(ctx context.Context, tugas *models.Tugas) error {
 	query := `
-        INSERT INTO tugas (judul, deskripsi, mata_pelajaran_id, kelas_id, deadline)
-        VALUES (:judul, :deskripsi, :mata_pelajaran_id, :kelas_id, :deadline)
+        INSERT INTO tugas (judul, deskripsi, mata_pelajaran_id, kelas_id, deadline, kategori, is_draft, publish_at, is_kelompok)
+        VALUES (:judul, :deskripsi, :mata_pelajaran_id, :kelas_id, :deadline, :kategori, :is_draft, :publish_at, :is_kelompok)
     `
 	_, err := r.db.NamedExecContext(ctx, query, tugas)
 	return err
@@ -46,6 +68,14 @@ func (r *tugasRepository) Update(ctx context.Context, tugas *models.Tugas) error
             mata_pelajaran_id = :mata_pelajaran_id,
             kelas_id = :kelas_id,
             deadline = :deadline,
+            kategori = :kategori,
+            is_draft = :is_draft,
+            publish_at = :publish_at,
+            is_kelompok = :is_kelompok,
+            peer_review = :peer_review,
+            jumlah_penilai = :jumlah_penilai,
+            bobot_peer_review = :bobot_peer_review,
+            peer_review_diterapkan = :peer_review_diterapkan,
             updated = :updated
         WHERE id = :id
     `
@@ -110,3 +140,70 @@ func (r *tugasRepository) GetAllByKelasAndMapelID(ctx context.Context, kelasID i
 	}
 	return tugases, nil
 }
+
+// GetPublishedByKelasID mengambil tugas kelas yang sudah terbit (bukan draft dan publish_at sudah lewat)
+// dan terlihat oleh siswa tersebut.
+func (r *tugasRepository) GetPublishedByKelasID(ctx context.Context, kelasID int, siswaID int) ([]models.Tugas, error) {
+	var tugases []models.Tugas
+	query := "SELECT * FROM tugas WHERE kelas_id = $1 AND " + publishedTugasFilter + " AND " + remedialTugasFilter("$2") + " ORDER BY deadline DESC"
+	err := r.db.SelectContext(ctx, &tugases, query, kelasID, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return tugases, nil
+}
+
+// GetPublishedByMapelID mengambil tugas mata pelajaran yang sudah terbit.
+func (r *tugasRepository) GetPublishedByMapelID(ctx context.Context, mapelID int, siswaID int) ([]models.Tugas, error) {
+	var tugases []models.Tugas
+	query := "SELECT * FROM tugas WHERE mata_pelajaran_id = $1 AND " + publishedTugasFilter + " AND " + remedialTugasFilter("$2") + " ORDER BY deadline DESC"
+	err := r.db.SelectContext(ctx, &tugases, query, mapelID, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return tugases, nil
+}
+
+func (r *tugasRepository) GetPublishedByKelasAndMapelID(ctx context.Context, kelasID int, mapelID int, siswaID int) ([]models.Tugas, error) {
+	var tugases []models.Tugas
+	query := "SELECT * FROM tugas WHERE kelas_id = $1 AND mata_pelajaran_id = $2 AND " + publishedTugasFilter + " AND " + remedialTugasFilter("$3") + " ORDER BY deadline DESC"
+	err := r.db.SelectContext(ctx, &tugases, query, kelasID, mapelID, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	return tugases, nil
+}
+
+// DiampuGuru memeriksa apakah guru mengampu tugas: wali kelasnya atau guru mapelnya di kelas itu.
+func (r *tugasRepository) DiampuGuru(ctx context.Context, id int, guruID int) (bool, error) {
+	var diampu bool
+	query := "SELECT EXISTS (SELECT 1 FROM tugas t WHERE t.id = $1 AND " + pengampuFilter("$2", "t.kelas_id", "t.mata_pelajaran_id") + ")"
+	err := r.db.GetContext(ctx, &diampu, query, id, guruID)
+	return diampu, err
+}
+
+// Publish menerbitkan tugas draft atau terjadwal saat ini juga. ErrTugasSudahTerbit dikembalikan jika
+// tugas sudah terbit, dan sql.ErrNoRows jika tugas tidak ada.
+func (r *tugasRepository) Publish(ctx context.Context, id int) error {
+	query := "UPDATE tugas SET is_draft = FALSE, publish_at = NOW(), updated = NOW() WHERE id = $1 AND (is_draft OR publish_at > NOW())"
+	result, err := r.db.ExecContext(ctx, query, id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows > 0 {
+		return nil
+	}
+
+	var ada bool
+	if err := r.db.GetContext(ctx, &ada, "SELECT EXISTS (SELECT 1 FROM tugas WHERE id = $1)", id); err != nil {
+		return err
+	}
+	if ada {
+		return ErrTugasSudahTerbit
+	}
+	return sql.ErrNoRows
+}
@@ -0,0 +1,227 @@
+package repositories
+
+import (
+	"be-pui/models"
+	"context"
+	"database/sql"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// NilaiTujuanSiswa adalah rata-rata bukti nilai seorang siswa pada satu tujuan pembelajaran.
+// Bukti berasal dari tugas/quiz yang ditandai dengan tujuan tersebut dan skor kriteria rubrik
+// yang ditautkan ke tujuan tersebut.
+type NilaiTujuanSiswa struct {
+	SiswaID     int     `db:"siswa_id"`
+	TujuanID    int     `db:"tujuan_id"`
+	Nilai       float64 `db:"nilai"`
+	JumlahBukti int     `db:"jumlah_bukti"`
+}
+
+type TujuanPembelajaranRepository interface {
+	Create(ctx context.Context, tujuan *models.TujuanPembelajaran) error
+	Update(ctx context.Context, tujuan *models.TujuanPembelajaran) error
+	Delete(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*models.TujuanPembelajaran, error)
+	GetAll(ctx context.Context, mapelID int, tingkat int) ([]models.TujuanPembelajaran, error)
+	GetByIDs(ctx context.Context, ids []int) ([]models.TujuanPembelajaran, error)
+	ReplaceTag(ctx context.Context, jenis string, itemID int, tujuanIDs []int) error
+	GetTagByItem(ctx context.Context, jenis string, itemID int) ([]models.TujuanPembelajaran, error)
+	GetNilaiTujuan(ctx context.Context, kelasID int, mapelID int, siswaID int, hanyaTerbit bool) ([]NilaiTujuanSiswa, error)
+}
+
+type tujuanPembelajaranRepository struct {
+	db *sqlx.DB
+}
+
+func NewTujuanPembelajaranRepository(db *sqlx.DB) TujuanPembelajaranRepository {
+	return &tujuanPembelajaranRepository{db: db}
+}
+
+func (r *tujuanPembelajaranRepository) Create(ctx context.Context, tujuan *models.TujuanPembelajaran) error {
+	query := `
+        INSERT INTO tujuan_pembelajaran (mata_pelajaran_id, tingkat, kode, capaian_pembelajaran, deskripsi)
+        VALUES (:mata_pelajaran_id, :tingkat, :kode, :capaian_pembelajaran, :deskripsi)
+    `
+	_, err := r.db.NamedExecContext(ctx, query, tujuan)
+	return err
+}
+
+func (r *tujuanPembelajaranRepository) Update(ctx context.Context, tujuan *models.TujuanPembelajaran) error {
+	query := `
+        UPDATE tujuan_pembelajaran SET
+            mata_pelajaran_id = :mata_pelajaran_id,
+            tingkat = :tingkat,
+            kode = :kode,
+            capaian_pembelajaran = :capaian_pembelajaran,
+            deskripsi = :deskripsi,
+            updated = NOW()
+        WHERE id = :id
+    `
+	result, err := r.db.NamedExecContext(ctx, query, tujuan)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// Delete menghapus tujuan pembelajaran beserta tag tugas/quiz-nya dan melepas tautan kriteria rubrik.
+func (r *tujuanPembelajaranRepository) Delete(ctx context.Context, id int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tag_tujuan_pembelajaran WHERE tujuan_id = $1", id); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "UPDATE rubrik_tugas SET tujuan_pembelajaran_id = NULL WHERE tujuan_pembelajaran_id = $1", id); err != nil {
+		return err
+	}
+	result, err := tx.ExecContext(ctx, "DELETE FROM tujuan_pembelajaran WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rows == 0 {
+		return sql.ErrNoRows
+	}
+
+	return tx.Commit()
+}
+
+func (r *tujuanPembelajaranRepository) GetByID(ctx context.Context, id int) (*models.TujuanPembelajaran, error) {
+	var tujuan models.TujuanPembelajaran
+	query := "SELECT * FROM tujuan_pembelajaran WHERE id = $1"
+	err := r.db.GetContext(ctx, &tujuan, query, id)
+	if err != nil {
+		return nil, err
+	}
+	return &tujuan, nil
+}
+
+// GetAll mengambil tujuan pembelajaran terurut per mapel, tingkat, lalu kode.
+// mapelID atau tingkat bernilai 0 berarti tanpa filter.
+func (r *tujuanPembelajaranRepository) GetAll(ctx context.Context, mapelID int, tingkat int) ([]models.TujuanPembelajaran, error) {
+	var results []models.TujuanPembelajaran
+	query := `
+		SELECT * FROM tujuan_pembelajaran
+		WHERE ($1 = 0 OR mata_pelajaran_id = $1) AND ($2 = 0 OR tingkat = $2)
+		ORDER BY mata_pelajaran_id ASC, tingkat ASC, kode ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, mapelID, tingkat)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+func (r *tujuanPembelajaranRepository) GetByIDs(ctx context.Context, ids []int) ([]models.TujuanPembelajaran, error) {
+	var results []models.TujuanPembelajaran
+	if len(ids) == 0 {
+		return results, nil
+	}
+	query, args, err := sqlx.In("SELECT * FROM tujuan_pembelajaran WHERE id IN (?) ORDER BY kode ASC", ids)
+	if err != nil {
+		return nil, err
+	}
+	err = r.db.SelectContext(ctx, &results, r.db.Rebind(query), args...)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// ReplaceTag mengganti seluruh tujuan pembelajaran yang ditandai pada satu tugas atau quiz.
+func (r *tujuanPembelajaranRepository) ReplaceTag(ctx context.Context, jenis string, itemID int, tujuanIDs []int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM tag_tujuan_pembelajaran WHERE jenis = $1 AND item_id = $2", jenis, itemID); err != nil {
+		return err
+	}
+
+	query := "INSERT INTO tag_tujuan_pembelajaran (jenis, item_id, tujuan_id) VALUES ($1, $2, $3)"
+	for _, tujuanID := range tujuanIDs {
+		if _, err := tx.ExecContext(ctx, query, jenis, itemID, tujuanID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *tujuanPembelajaranRepository) GetTagByItem(ctx context.Context, jenis string, itemID int) ([]models.TujuanPembelajaran, error) {
+	var results []models.TujuanPembelajaran
+	query := `
+		SELECT tp.*
+		FROM tag_tujuan_pembelajaran tg
+		JOIN tujuan_pembelajaran tp ON tg.tujuan_id = tp.id
+		WHERE tg.jenis = $1 AND tg.item_id = $2
+		ORDER BY tp.kode ASC
+	`
+	err := r.db.SelectContext(ctx, &results, query, jenis, itemID)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// GetNilaiTujuan menghitung rata-rata bukti nilai per siswa dan tujuan pembelajaran di satu kelas.
+// Setiap tugas/quiz bertanda dan setiap kriteria rubrik bertaut dihitung sebagai satu bukti; skor
+// kriteria rubrik dirata-rata dari seluruh penilaian sejawat yang selesai. mapelID dan siswaID
+// bernilai 0 berarti tanpa filter. hanyaTerbit membatasi bukti ke tugas/quiz yang nilainya terbit.
+func (r *tujuanPembelajaranRepository) GetNilaiTujuan(ctx context.Context, kelasID int, mapelID int, siswaID int, hanyaTerbit bool) ([]NilaiTujuanSiswa, error) {
+	var results []NilaiTujuanSiswa
+	query := `
+		SELECT bukti.siswa_id, bukti.tujuan_id, AVG(bukti.nilai) AS nilai, COUNT(*) AS jumlah_bukti
+		FROM (
+			SELECT ht.siswa_id, tg.tujuan_id, ht.nilai
+			FROM hasil_tugas ht
+			JOIN tugas t ON ht.tugas_id = t.id
+			JOIN tag_tujuan_pembelajaran tg ON tg.jenis = 'tugas' AND tg.item_id = t.id
+			WHERE t.kelas_id = $1 AND ($2 = 0 OR t.mata_pelajaran_id = $2)
+				AND ht.nilai IS NOT NULL AND (NOT $4 OR t.status_nilai = 'terbit')
+			UNION ALL
+			SELECT hq.siswa_id, tg.tujuan_id, hq.nilai
+			FROM hasil_quiz hq
+			JOIN quiz q ON hq.quiz_id = q.id
+			JOIN tag_tujuan_pembelajaran tg ON tg.jenis = 'quiz' AND tg.item_id = q.id
+			WHERE q.kelas_id = $1 AND ($2 = 0 OR q.mata_pelajaran_id = $2)
+				AND (NOT $4 OR q.status_nilai = 'terbit')
+			UNION ALL
+			SELECT ht.siswa_id, rt.tujuan_pembelajaran_id AS tujuan_id, AVG(srs.skor) AS nilai
+			FROM skor_rubrik_sejawat srs
+			JOIN rubrik_tugas rt ON srs.rubrik_id = rt.id
+			JOIN penilaian_sejawat ps ON srs.penilaian_id = ps.id AND ps.status = 'selesai'
+			JOIN hasil_tugas ht ON ps.hasil_tugas_id = ht.id
+			JOIN tugas t ON rt.tugas_id = t.id
+			WHERE rt.tujuan_pembelajaran_id IS NOT NULL
+				AND t.kelas_id = $1 AND ($2 = 0 OR t.mata_pelajaran_id = $2)
+				AND (NOT $4 OR t.status_nilai = 'terbit')
+			GROUP BY ht.siswa_id, rt.tujuan_pembelajaran_id, rt.id
+		) bukti
+		JOIN siswa s ON bukti.siswa_id = s.id AND s.kelas_id = $1
+		WHERE $3 = 0 OR bukti.siswa_id = $3
+		GROUP BY bukti.siswa_id, bukti.tujuan_id
+	`
+	err := r.db.SelectContext(ctx, &results, query, kelasID, mapelID, siswaID, hanyaTerbit)
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
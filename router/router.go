@@ -5,6 +5,7 @@ import (
 	"be-pui/handler"
 	"be-pui/middleware"
 	"be-pui/repositories"
+	"be-pui/services"
 	"be-pui/utils"
 
 	"github.com/gin-gonic/gin"
@@ -25,14 +26,78 @@ func SetupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 	mapelRepo := repositories.NewMapelRepository(db)
 	tugasRepo := repositories.NewTugasRepository(db)
 	hasilTugasRepo := repositories.NewHasilTugasRepository(db)
+	kelompokTugasRepo := repositories.NewKelompokTugasRepository(db)
+	penilaianSejawatRepo := repositories.NewPenilaianSejawatRepository(db)
+	hasilQuizRepo := repositories.NewHasilQuizRepository(db)
+	bandingNilaiRepo := repositories.NewBandingNilaiRepository(db)
+	quizRepo := repositories.NewQuizRepository(db)
+	publikasiNilaiRepo := repositories.NewPublikasiNilaiRepository(db)
+	bobotNilaiRepo := repositories.NewBobotNilaiRepository(db)
+	rekapNilaiRepo := repositories.NewRekapNilaiRepository(db)
+	kkmRepo := repositories.NewKKMRepository(db)
+	remedialRepo := repositories.NewRemedialRepository(db)
+	deskripsiRaporRepo := repositories.NewDeskripsiRaporRepository(db)
+	imporNilaiRepo := repositories.NewImporNilaiRepository(db)
+	peringatanDiniRepo := repositories.NewPeringatanDiniRepository(db)
+	tujuanPembelajaranRepo := repositories.NewTujuanPembelajaranRepository(db)
+	kurvaNilaiRepo := repositories.NewKurvaNilaiRepository(db)
+	jadwalKelasRepo := repositories.NewJadwalKelasRepository(db)
+	jadwalPribadiRepo := repositories.NewJadwalPribadiRepository(db)
+	tokenKalenderRepo := repositories.NewTokenKalenderRepository(db)
+	notifikasiRepo := repositories.NewNotifikasiRepository(db)
+	guruPenggantiRepo := repositories.NewGuruPenggantiRepository(db)
+	kalenderAkademikRepo := repositories.NewKalenderAkademikRepository(db)
+	absensiRepo := repositories.NewAbsensiRepository(db)
+	orangTuaRepo := repositories.NewOrangTuaRepository(db)
+	pengajuanIzinRepo := repositories.NewPengajuanIzinRepository(db)
+
+	// Services
+	rekapNilaiService := services.NewRekapNilaiService(rekapNilaiRepo, bobotNilaiRepo, siswaRepo, tugasRepo, quizRepo)
+	remedialService := services.NewRemedialService(remedialRepo, kkmRepo, kelasRepo)
+	nilaiService := services.NewNilaiService(remedialService, rekapNilaiService, tugasRepo, quizRepo)
+	raporService := services.NewRaporService(rekapNilaiService, siswaRepo, kelasRepo, guruRepo, kkmRepo, deskripsiRaporRepo, absensiRepo, kalenderAkademikRepo, cfg.Sekolah)
+	imporNilaiService := services.NewImporNilaiService(imporNilaiRepo, siswaRepo, kurvaNilaiRepo, nilaiService)
+	statistikKelasService := services.NewStatistikKelasService(rekapNilaiRepo, kelasRepo, kkmRepo, siswaRepo, kalenderAkademikRepo, rekapNilaiService)
+	peringatanDiniService := services.NewPeringatanDiniService(peringatanDiniRepo, absensiRepo)
+	gradebookService := services.NewGradebookService(rekapNilaiRepo, tugasRepo, quizRepo, remedialRepo)
+	deskripsiRaporService := services.NewDeskripsiRaporService(deskripsiRaporRepo, rekapNilaiRepo, kelasRepo, mapelRepo, kkmRepo)
+	tujuanPembelajaranService := services.NewTujuanPembelajaranService(tujuanPembelajaranRepo, siswaRepo, kelasRepo)
+	kurvaNilaiService := services.NewKurvaNilaiService(kurvaNilaiRepo)
+	generatorJadwalService := services.NewGeneratorJadwalService(jadwalKelasRepo)
+	jadwalPribadiService := services.NewJadwalPribadiService(jadwalPribadiRepo, siswaRepo, kalenderAkademikRepo)
+	kalenderService := services.NewKalenderService(tokenKalenderRepo, jadwalPribadiRepo, siswaRepo, kalenderAkademikRepo)
+	kalenderAkademikService := services.NewKalenderAkademikService(kalenderAkademikRepo)
+	guruPenggantiService := services.NewGuruPenggantiService(guruPenggantiRepo, jadwalPribadiRepo, guruRepo, kalenderAkademikRepo)
+	absensiService := services.NewAbsensiService(absensiRepo, jadwalKelasRepo, kalenderAkademikRepo)
+	pengajuanIzinService := services.NewPengajuanIzinService(pengajuanIzinRepo, siswaRepo, kelasRepo, orangTuaRepo)
 
 	// Handlers
 	adminHandler := handler.NewAdminHandler(adminRepo, jwtUtil)
-	guruHandler := handler.NewGuruHandler(guruRepo, tugasRepo, hasilTugasRepo, jwtUtil)
+	guruHandler := handler.NewGuruHandler(guruRepo, tugasRepo, hasilTugasRepo, kelompokTugasRepo, nilaiService, jwtUtil)
 	kelasHandler := handler.NewKelasHandler(kelasRepo)
-	siswaHandler := handler.NewSiswaHandler(siswaRepo, tugasRepo, hasilTugasRepo, jwtUtil, cfg)
+	siswaHandler := handler.NewSiswaHandler(siswaRepo, tugasRepo, hasilTugasRepo, kelompokTugasRepo, remedialRepo, jwtUtil, cfg)
 	mapelHandler := handler.NewMapelHandler(mapelRepo)
-	tugasHandler := handler.NewTugasHandler(tugasRepo)
+	tugasHandler := handler.NewTugasHandler(tugasRepo, kalenderAkademikService)
+	kelompokTugasHandler := handler.NewKelompokTugasHandler(kelompokTugasRepo, tugasRepo, siswaRepo)
+	penilaianSejawatHandler := handler.NewPenilaianSejawatHandler(penilaianSejawatRepo, tugasRepo, hasilTugasRepo, nilaiService, cfg)
+	bandingNilaiHandler := handler.NewBandingNilaiHandler(bandingNilaiRepo, hasilTugasRepo, hasilQuizRepo, tugasRepo, quizRepo, nilaiService)
+	rekapNilaiHandler := handler.NewRekapNilaiHandler(rekapNilaiRepo, bobotNilaiRepo, rekapNilaiService, gradebookService, statistikKelasService)
+	publikasiNilaiHandler := handler.NewPublikasiNilaiHandler(publikasiNilaiRepo, tugasRepo, quizRepo, kelasRepo, rekapNilaiService)
+	remedialHandler := handler.NewRemedialHandler(kkmRepo, remedialRepo, kelasRepo, siswaRepo, tugasRepo, quizRepo)
+	raporHandler := handler.NewRaporHandler(raporService)
+	imporNilaiHandler := handler.NewImporNilaiHandler(tugasRepo, quizRepo, imporNilaiService, nilaiService)
+	peringatanDiniHandler := handler.NewPeringatanDiniHandler(peringatanDiniService)
+	deskripsiRaporHandler := handler.NewDeskripsiRaporHandler(deskripsiRaporRepo, deskripsiRaporService)
+	tujuanPembelajaranHandler := handler.NewTujuanPembelajaranHandler(tujuanPembelajaranRepo, tugasRepo, quizRepo, kelasRepo, tujuanPembelajaranService)
+	kurvaNilaiHandler := handler.NewKurvaNilaiHandler(tugasRepo, quizRepo, kurvaNilaiRepo, kurvaNilaiService, nilaiService)
+	jadwalKelasHandler := handler.NewJadwalKelasHandler(jadwalKelasRepo, generatorJadwalService, jadwalPribadiService)
+	kalenderHandler := handler.NewKalenderHandler(tokenKalenderRepo, kalenderService, cfg)
+	notifikasiHandler := handler.NewNotifikasiHandler(notifikasiRepo)
+	guruPenggantiHandler := handler.NewGuruPenggantiHandler(guruPenggantiRepo, guruPenggantiService)
+	kalenderAkademikHandler := handler.NewKalenderAkademikHandler(kalenderAkademikRepo, kalenderAkademikService)
+	absensiHandler := handler.NewAbsensiHandler(absensiRepo, absensiService)
+	orangTuaHandler := handler.NewOrangTuaHandler(orangTuaRepo, jwtUtil)
+	pengajuanIzinHandler := handler.NewPengajuanIzinHandler(pengajuanIzinRepo, pengajuanIzinService, cfg)
 
 	router := gin.Default()
 
@@ -76,6 +141,13 @@ func SetupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 			{
 				guruProfileRoutes.GET("/profile", guruHandler.GetProfileGuru)
 				guruProfileRoutes.GET("/tugas", guruHandler.CheckTugasSiswa)
+				guruProfileRoutes.PUT("/tugas/hasil/:id/nilai", guruHandler.GradeHasilTugas)
+				guruProfileRoutes.PUT("/tugas/kelompok/:id/nilai", guruHandler.GradeKelompok)
+				guruProfileRoutes.GET("/banding", bandingNilaiHandler.GetBandingGuru)
+				guruProfileRoutes.PUT("/banding/:id/terima", bandingNilaiHandler.TerimaBanding)
+				guruProfileRoutes.PUT("/banding/:id/tolak", bandingNilaiHandler.TolakBanding)
+				guruProfileRoutes.GET("/jadwal", jadwalKelasHandler.GetJadwalGuru)
+				guruProfileRoutes.GET("/pengganti", guruPenggantiHandler.GetPenggantianSaya)
 			}
 
 			guruManagementRoutes := guruRoutes.Group("/")
@@ -108,6 +180,12 @@ func SetupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 				siswaProfileRoutes.GET("/tugas", siswaHandler.GetMyTugas)
 				siswaProfileRoutes.POST("/tugas/submit", siswaHandler.SubmitTugas)
 				siswaProfileRoutes.GET("/tugas/status", siswaHandler.CheckTugasCompletion)
+				siswaProfileRoutes.GET("/peer-review", penilaianSejawatHandler.GetMyPeerReview)
+				siswaProfileRoutes.POST("/peer-review/:id", penilaianSejawatHandler.SubmitPeerReview)
+				siswaProfileRoutes.GET("/peer-review/:id/file", penilaianSejawatHandler.GetFilePeerReview)
+				siswaProfileRoutes.GET("/banding", bandingNilaiHandler.GetMyBanding)
+				siswaProfileRoutes.POST("/banding", bandingNilaiHandler.AjukanBanding)
+				siswaProfileRoutes.GET("/jadwal", jadwalKelasHandler.GetJadwalSiswa)
 			}
 
 			siswaManagementRoutes := siswaRoutes.Group("/")
@@ -118,6 +196,25 @@ func SetupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 			}
 		}
 
+		// --- Rute Orang Tua ---
+		orangTuaRoutes := api.Group("/orang-tua")
+		{
+			orangTuaRoutes.POST("/login", orangTuaHandler.LoginOrangTua)
+
+			orangTuaProfileRoutes := orangTuaRoutes.Group("/")
+			orangTuaProfileRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("orang tua"))
+			{
+				orangTuaProfileRoutes.GET("/profile", orangTuaHandler.GetProfileOrangTua)
+			}
+
+			orangTuaManagementRoutes := orangTuaRoutes.Group("/")
+			orangTuaManagementRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("super admin", "admin biasa"))
+			{
+				orangTuaManagementRoutes.POST("/", orangTuaHandler.CreateOrangTua)
+				orangTuaManagementRoutes.POST("/:id/siswa", orangTuaHandler.HubungkanSiswa)
+			}
+		}
+
 		// --- Rute Mata Pelajaran (Mapel) ---
 		mapelRoutes := api.Group("/mapels")
 		mapelRoutes.Use(authMiddleware.Auth())
@@ -126,6 +223,8 @@ func SetupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 
 			mapelRoutes.GET("/", authMiddleware.RequireRole("super admin", "admin biasa", "guru", "siswa"), mapelHandler.GetAllMapel)
 			mapelRoutes.GET("/:id", authMiddleware.RequireRole("super admin", "admin biasa", "guru", "siswa"), mapelHandler.GetByIDMapel)
+			mapelRoutes.PUT("/:id/bobot", authMiddleware.RequireRole("super admin", "admin biasa"), rekapNilaiHandler.SimpanBobot)
+			mapelRoutes.GET("/:id/bobot", authMiddleware.RequireRole("super admin", "admin biasa", "guru"), rekapNilaiHandler.GetBobot)
 		}
 
 		// --- Rute Tugas ---
@@ -133,9 +232,193 @@ func SetupRouter(db *sqlx.DB, cfg *config.Config) *gin.Engine {
 		tugasRoutes.Use(authMiddleware.Auth())
 		{
 			tugasRoutes.POST("/", authMiddleware.RequireRole("guru"), tugasHandler.CreateTugas)
+			tugasRoutes.PUT("/:id/publish", authMiddleware.RequireRole("guru"), tugasHandler.PublishTugas)
+			tugasRoutes.POST("/:id/kelompok", authMiddleware.RequireRole("guru"), kelompokTugasHandler.AturKelompok)
+			tugasRoutes.GET("/:id/kelompok", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), kelompokTugasHandler.GetKelompokByTugasID)
+			tugasRoutes.PUT("/:id/rubrik", authMiddleware.RequireRole("guru"), penilaianSejawatHandler.SimpanRubrik)
+			tugasRoutes.GET("/:id/rubrik", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), penilaianSejawatHandler.GetRubrik)
+			tugasRoutes.PUT("/:id/peer-review", authMiddleware.RequireRole("guru"), penilaianSejawatHandler.AturPeerReview)
+			tugasRoutes.GET("/:id/peer-review", authMiddleware.RequireRole("guru"), penilaianSejawatHandler.GetRekapPeerReview)
+			tugasRoutes.POST("/:id/peer-review/distribusi", authMiddleware.RequireRole("guru"), penilaianSejawatHandler.DistribusiPeerReview)
+			tugasRoutes.POST("/:id/peer-review/terapkan", authMiddleware.RequireRole("guru"), penilaianSejawatHandler.TerapkanPeerReview)
+			tugasRoutes.DELETE("/:id/peer-review/terapkan", authMiddleware.RequireRole("guru"), penilaianSejawatHandler.BatalkanPeerReview)
 			tugasRoutes.GET("/kelas/:kelas_id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), tugasHandler.GetAllTugasByKelasID)
 			tugasRoutes.GET("/mapel/:mapel_id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), tugasHandler.GetAllTugasByMapelID)
 		}
+
+		// --- Rute Rekap Nilai ---
+		rekapNilaiRoutes := api.Group("/rekap-nilai")
+		rekapNilaiRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("guru", "super admin", "admin biasa"))
+		{
+			rekapNilaiRoutes.POST("/hitung", rekapNilaiHandler.HitungUlangRekap)
+			rekapNilaiRoutes.GET("/kelas/:kelas_id", rekapNilaiHandler.GetRekapKelas)
+			rekapNilaiRoutes.GET("/kelas/:kelas_id/export", rekapNilaiHandler.ExportGradebook)
+			rekapNilaiRoutes.GET("/kelas/:kelas_id/statistik", rekapNilaiHandler.GetStatistikKelas)
+		}
+
+		// --- Rute Peringatan Dini ---
+		peringatanDiniRoutes := api.Group("/peringatan-dini")
+		peringatanDiniRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("guru", "super admin", "admin biasa"))
+		{
+			peringatanDiniRoutes.GET("", peringatanDiniHandler.GetSiswaBerisiko)
+		}
+
+		// --- Rute Publikasi Nilai ---
+		nilaiRoutes := api.Group("/nilai")
+		nilaiRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("guru"))
+		{
+			nilaiRoutes.GET("/moderasi", publikasiNilaiHandler.GetMenungguReview)
+			nilaiRoutes.POST("/:jenis/:id/terbitkan", publikasiNilaiHandler.TerbitkanNilai)
+			nilaiRoutes.PUT("/:jenis/:id/setujui", publikasiNilaiHandler.SetujuiNilai)
+			nilaiRoutes.PUT("/:jenis/:id/kembalikan", publikasiNilaiHandler.KembalikanNilai)
+			nilaiRoutes.POST("/:jenis/:id/impor", imporNilaiHandler.ImporNilai)
+			nilaiRoutes.POST("/:jenis/:id/kurva", kurvaNilaiHandler.TerapkanKurva)
+			nilaiRoutes.GET("/:jenis/:id/kurva", kurvaNilaiHandler.GetKurva)
+			nilaiRoutes.DELETE("/:jenis/:id/kurva", kurvaNilaiHandler.BatalkanKurva)
+		}
+
+		// --- Rute KKM ---
+		kkmRoutes := api.Group("/kkm")
+		kkmRoutes.Use(authMiddleware.Auth())
+		{
+			kkmRoutes.PUT("", authMiddleware.RequireRole("super admin", "admin biasa"), remedialHandler.SimpanKKM)
+			kkmRoutes.GET("", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), remedialHandler.GetAllKKM)
+		}
+
+		// --- Rute Remedial ---
+		remedialRoutes := api.Group("/remedial")
+		remedialRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("guru"))
+		{
+			remedialRoutes.GET("/siswa", remedialHandler.GetSiswaRemedial)
+			remedialRoutes.POST("", remedialHandler.BuatRemedial)
+			remedialRoutes.GET("/:id", remedialHandler.GetRemedialByID)
+		}
+
+		// --- Rute Rapor ---
+		raporRoutes := api.Group("/rapor")
+		raporRoutes.Use(authMiddleware.Auth())
+		{
+			raporRoutes.GET("/siswa/:siswa_id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), raporHandler.GetRaporSiswa)
+			raporRoutes.GET("/kelas/:kelas_id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), raporHandler.GetRaporKelas)
+		}
+
+		// --- Rute Deskripsi Rapor ---
+		deskripsiRoutes := api.Group("/deskripsi-rapor")
+		deskripsiRoutes.Use(authMiddleware.Auth())
+		{
+			deskripsiRoutes.POST("/template", authMiddleware.RequireRole("super admin", "admin biasa"), deskripsiRaporHandler.CreateTemplate)
+			deskripsiRoutes.GET("/template", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), deskripsiRaporHandler.GetAllTemplate)
+			deskripsiRoutes.PUT("/template/:id", authMiddleware.RequireRole("super admin", "admin biasa"), deskripsiRaporHandler.UpdateTemplate)
+			deskripsiRoutes.DELETE("/template/:id", authMiddleware.RequireRole("super admin", "admin biasa"), deskripsiRaporHandler.DeleteTemplate)
+			deskripsiRoutes.POST("/generate", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), deskripsiRaporHandler.GenerateDeskripsi)
+			deskripsiRoutes.GET("/kelas/:kelas_id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), deskripsiRaporHandler.GetDeskripsiKelas)
+			deskripsiRoutes.PUT("/:id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), deskripsiRaporHandler.EditDeskripsi)
+			deskripsiRoutes.PUT("/:id/final", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), deskripsiRaporHandler.FinalkanDeskripsi)
+		}
+
+		// --- Rute Jadwal Kelas ---
+		jadwalRoutes := api.Group("/jadwal")
+		jadwalRoutes.Use(authMiddleware.Auth())
+		{
+			jadwalRoutes.POST("", authMiddleware.RequireRole("super admin", "admin biasa"), jadwalKelasHandler.CreateJadwal)
+			jadwalRoutes.POST("/generate", authMiddleware.RequireRole("super admin", "admin biasa"), jadwalKelasHandler.GenerateJadwal)
+			jadwalRoutes.GET("/bentrok", authMiddleware.RequireRole("super admin", "admin biasa"), jadwalKelasHandler.GetBentrokJadwal)
+			jadwalRoutes.GET("/kelas/:kelas_id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), jadwalKelasHandler.GetJadwalKelas)
+			jadwalRoutes.GET("/:id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), jadwalKelasHandler.GetJadwalByID)
+			jadwalRoutes.PUT("/:id", authMiddleware.RequireRole("super admin", "admin biasa"), jadwalKelasHandler.UpdateJadwal)
+			jadwalRoutes.DELETE("/:id", authMiddleware.RequireRole("super admin", "admin biasa"), jadwalKelasHandler.DeleteJadwal)
+		}
+
+		// --- Rute Kalender (ICS) ---
+		// Feed diakses aplikasi kalender tanpa JWT; token rahasia pada URL menjadi otorisasinya.
+		kalenderRoutes := api.Group("/kalender")
+		{
+			kalenderRoutes.GET("/feed/:token", kalenderHandler.GetFeedKalender)
+			kalenderRoutes.GET("/token", authMiddleware.Auth(), authMiddleware.RequireRole("guru", "siswa"), kalenderHandler.GetTokenKalender)
+			kalenderRoutes.POST("/token", authMiddleware.Auth(), authMiddleware.RequireRole("guru", "siswa"), kalenderHandler.BuatTokenKalender)
+			kalenderRoutes.DELETE("/token", authMiddleware.Auth(), authMiddleware.RequireRole("guru", "siswa"), kalenderHandler.CabutTokenKalender)
+		}
+
+		// --- Rute Kalender Akademik ---
+		kalenderAkademikRoutes := api.Group("/kalender-akademik")
+		kalenderAkademikRoutes.Use(authMiddleware.Auth())
+		{
+			kalenderAkademikRoutes.GET("/cek", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), kalenderAkademikHandler.CekTanggal)
+			kalenderAkademikRoutes.GET("/tahun-ajaran", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), kalenderAkademikHandler.GetAllTahunAjaran)
+			kalenderAkademikRoutes.POST("/tahun-ajaran", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.CreateTahunAjaran)
+			kalenderAkademikRoutes.PUT("/tahun-ajaran/:id", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.UpdateTahunAjaran)
+			kalenderAkademikRoutes.PUT("/tahun-ajaran/:id/aktif", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.AktifkanTahunAjaran)
+			kalenderAkademikRoutes.DELETE("/tahun-ajaran/:id", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.DeleteTahunAjaran)
+			kalenderAkademikRoutes.GET("/tahun-ajaran/:id/semester", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), kalenderAkademikHandler.GetSemesterByTahunAjaran)
+			kalenderAkademikRoutes.POST("/semester", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.CreateSemester)
+			kalenderAkademikRoutes.PUT("/semester/:id", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.UpdateSemester)
+			kalenderAkademikRoutes.DELETE("/semester/:id", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.DeleteSemester)
+			kalenderAkademikRoutes.GET("/agenda", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), kalenderAkademikHandler.GetAgenda)
+			kalenderAkademikRoutes.POST("/agenda", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.CreateAgenda)
+			kalenderAkademikRoutes.PUT("/agenda/:id", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.UpdateAgenda)
+			kalenderAkademikRoutes.DELETE("/agenda/:id", authMiddleware.RequireRole("super admin", "admin biasa"), kalenderAkademikHandler.DeleteAgenda)
+		}
+
+		// --- Rute Guru Pengganti ---
+		penggantiRoutes := api.Group("/guru-pengganti")
+		penggantiRoutes.Use(authMiddleware.Auth(), authMiddleware.RequireRole("super admin", "admin biasa"))
+		{
+			penggantiRoutes.POST("/ketidakhadiran", guruPenggantiHandler.CatatKetidakhadiran)
+			penggantiRoutes.GET("/ketidakhadiran", guruPenggantiHandler.GetAllKetidakhadiran)
+			penggantiRoutes.GET("/ketidakhadiran/:id", guruPenggantiHandler.GetKetidakhadiran)
+			penggantiRoutes.DELETE("/ketidakhadiran/:id", guruPenggantiHandler.DeleteKetidakhadiran)
+			penggantiRoutes.GET("/sesi/:id/saran", guruPenggantiHandler.GetSaranPengganti)
+			penggantiRoutes.PUT("/sesi/:id", guruPenggantiHandler.TetapkanPengganti)
+		}
+
+		// --- Rute Absensi ---
+		absensiRoutes := api.Group("/absensi")
+		absensiRoutes.Use(authMiddleware.Auth())
+		{
+			absensiRoutes.POST("/sesi", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.BukaSesi)
+			absensiRoutes.GET("/sesi/:id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.GetSesi)
+			absensiRoutes.PUT("/sesi/:id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.SimpanAbsensi)
+			absensiRoutes.POST("/sesi/:id/check-in", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.BukaCheckIn)
+			absensiRoutes.GET("/sesi/:id/check-in", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.GetTokenCheckIn)
+			absensiRoutes.DELETE("/sesi/:id/check-in", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.TutupCheckIn)
+			absensiRoutes.POST("/check-in", authMiddleware.RequireRole("siswa"), absensiHandler.CheckIn)
+			absensiRoutes.GET("/rekap/kelas/:kelas_id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), absensiHandler.GetRekapKelas)
+			absensiRoutes.GET("/rekap/siswa/:siswa_id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), absensiHandler.GetRekapSiswa)
+		}
+
+		// --- Rute Pengajuan Izin ---
+		pengajuanIzinRoutes := api.Group("/pengajuan-izin")
+		pengajuanIzinRoutes.Use(authMiddleware.Auth())
+		{
+			pengajuanIzinRoutes.POST("", authMiddleware.RequireRole("siswa", "orang tua"), pengajuanIzinHandler.AjukanIzin)
+			pengajuanIzinRoutes.GET("", authMiddleware.RequireRole("siswa", "orang tua", "guru"), pengajuanIzinHandler.GetDaftarPengajuan)
+			pengajuanIzinRoutes.GET("/:id", authMiddleware.RequireRole("siswa", "orang tua", "guru", "super admin", "admin biasa"), pengajuanIzinHandler.GetPengajuan)
+			pengajuanIzinRoutes.GET("/:id/surat-dokter", authMiddleware.RequireRole("siswa", "orang tua", "guru", "super admin", "admin biasa"), pengajuanIzinHandler.GetSuratDokter)
+			pengajuanIzinRoutes.PUT("/:id/setujui", authMiddleware.RequireRole("guru"), pengajuanIzinHandler.SetujuiPengajuan)
+			pengajuanIzinRoutes.PUT("/:id/tolak", authMiddleware.RequireRole("guru"), pengajuanIzinHandler.TolakPengajuan)
+		}
+
+		// --- Rute Notifikasi ---
+		notifikasiRoutes := api.Group("/notifikasi")
+		notifikasiRoutes.Use(authMiddleware.Auth())
+		{
+			notifikasiRoutes.GET("", notifikasiHandler.GetMyNotifikasi)
+			notifikasiRoutes.PUT("/:id/baca", notifikasiHandler.TandaiDibaca)
+		}
+
+		// --- Rute Tujuan Pembelajaran ---
+		tujuanRoutes := api.Group("/tujuan-pembelajaran")
+		tujuanRoutes.Use(authMiddleware.Auth())
+		{
+			tujuanRoutes.POST("", authMiddleware.RequireRole("super admin", "admin biasa"), tujuanPembelajaranHandler.CreateTujuan)
+			tujuanRoutes.GET("", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), tujuanPembelajaranHandler.GetAllTujuan)
+			tujuanRoutes.PUT("/:id", authMiddleware.RequireRole("super admin", "admin biasa"), tujuanPembelajaranHandler.UpdateTujuan)
+			tujuanRoutes.DELETE("/:id", authMiddleware.RequireRole("super admin", "admin biasa"), tujuanPembelajaranHandler.DeleteTujuan)
+			tujuanRoutes.PUT("/tag/:jenis/:id", authMiddleware.RequireRole("guru"), tujuanPembelajaranHandler.TandaiTujuan)
+			tujuanRoutes.GET("/tag/:jenis/:id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), tujuanPembelajaranHandler.GetTagTujuan)
+			tujuanRoutes.GET("/ketercapaian/kelas/:kelas_id", authMiddleware.RequireRole("guru", "super admin", "admin biasa"), tujuanPembelajaranHandler.GetKetercapaianKelas)
+			tujuanRoutes.GET("/ketercapaian/siswa/:siswa_id", authMiddleware.RequireRole("guru", "siswa", "super admin", "admin biasa"), tujuanPembelajaranHandler.GetKetercapaianSiswa)
+		}
 	}
 
 	return router
@@ -0,0 +1,165 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	ErrSesiAbsensi   = errors.New("sesi absensi tidak valid")
+	ErrBukanPengajar = errors.New("guru tidak mengajar pertemuan ini")
+)
+
+// RekapAbsensiSiswa adalah jumlah pertemuan per status kehadiran seorang siswa beserta rinciannya.
+type RekapAbsensiSiswa struct {
+	SiswaID int
+	Hadir   int
+	Izin    int
+	Sakit   int
+	Alpa    int
+	Riwayat []repositories.RiwayatAbsensi
+}
+
+// AbsensiService membuka sesi absensi dari slot jadwal dan memastikan hanya guru pengajar atau
+// guru pengganti pada tanggal itu yang dapat mengisinya.
+type AbsensiService struct {
+	absensiRepo  repositories.AbsensiRepository
+	jadwalRepo   repositories.JadwalKelasRepository
+	kalenderRepo repositories.KalenderAkademikRepository
+}
+
+func NewAbsensiService(
+	absensiRepo repositories.AbsensiRepository,
+	jadwalRepo repositories.JadwalKelasRepository,
+	kalenderRepo repositories.KalenderAkademikRepository,
+) *AbsensiService {
+	return &AbsensiService{
+		absensiRepo:  absensiRepo,
+		jadwalRepo:   jadwalRepo,
+		kalenderRepo: kalenderRepo,
+	}
+}
+
+// BukaSesi membuka (atau mengambil yang sudah ada) sesi absensi slot jadwal pada tanggal tersebut.
+// Tanggal harus jatuh pada hari slot, bukan hari libur, dan tidak di masa depan. guruID nil berarti
+// dibuka oleh admin.
+func (s *AbsensiService) BukaSesi(ctx context.Context, jadwalID int, tanggal time.Time, guruID *int) (*repositories.SesiAbsensiDetail, error) {
+	jadwal, err := s.jadwalRepo.GetByID(ctx, jadwalID)
+	if err != nil {
+		return nil, err
+	}
+	if NamaHari(tanggal) != jadwal.Hari {
+		return nil, fmt.Errorf("%w: jadwal ini jatuh pada hari %s, bukan %s", ErrSesiAbsensi, jadwal.Hari, NamaHari(tanggal))
+	}
+	sekarang := time.Now()
+	if tanggal.After(time.Date(sekarang.Year(), sekarang.Month(), sekarang.Day(), 0, 0, 0, 0, time.Local)) {
+		return nil, fmt.Errorf("%w: absensi belum dapat diisi untuk tanggal yang akan datang", ErrSesiAbsensi)
+	}
+	libur, err := hariLibur(ctx, s.kalenderRepo, tanggal, tanggal)
+	if err != nil {
+		return nil, err
+	}
+	if nama, ok := libur[tanggal.Format("2006-01-02")]; ok {
+		return nil, fmt.Errorf("%w: tanggal tersebut libur (%s)", ErrSesiAbsensi, nama)
+	}
+	if guruID != nil {
+		if err := s.periksaPengajar(ctx, *guruID, jadwalID, tanggal); err != nil {
+			return nil, err
+		}
+	}
+
+	sesi := &models.SesiAbsensi{JadwalID: &jadwalID, Tanggal: tanggal, GuruID: guruID}
+	if err := s.absensiRepo.BukaSesi(ctx, sesi); err != nil {
+		return nil, err
+	}
+	return s.absensiRepo.GetSesiByID(ctx, sesi.ID)
+}
+
+// GetSesi mengambil sesi absensi beserta daftar hadir seluruh siswa kelasnya.
+func (s *AbsensiService) GetSesi(ctx context.Context, sesiID int, guruID *int) (*repositories.SesiAbsensiDetail, []repositories.AbsensiSiswa, error) {
+	sesi, err := s.getSesiPengajar(ctx, sesiID, guruID)
+	if err != nil {
+		return nil, nil, err
+	}
+	daftar, err := s.absensiRepo.GetDaftarHadir(ctx, sesiID)
+	if err != nil {
+		return nil, nil, err
+	}
+	return sesi, daftar, nil
+}
+
+// SimpanAbsensi menyimpan status kehadiran banyak siswa sekaligus. Status harus hadir, izin, sakit,
+// atau alpa, dan satu siswa hanya boleh muncul sekali.
+func (s *AbsensiService) SimpanAbsensi(ctx context.Context, sesiID int, guruID *int, absensi []models.Absensi) error {
+	sudahAda := make(map[int]bool)
+	for _, item := range absensi {
+		if !models.StatusAbsensiValid(item.Status) {
+			return fmt.Errorf("%w: status %q untuk siswa %d harus hadir, izin, sakit, atau alpa", ErrSesiAbsensi, item.Status, item.SiswaID)
+		}
+		if sudahAda[item.SiswaID] {
+			return fmt.Errorf("%w: siswa %d tercantum lebih dari sekali", ErrSesiAbsensi, item.SiswaID)
+		}
+		sudahAda[item.SiswaID] = true
+	}
+
+	if _, err := s.getSesiPengajar(ctx, sesiID, guruID); err != nil {
+		return err
+	}
+	return s.absensiRepo.SimpanAbsensi(ctx, sesiID, guruID, absensi)
+}
+
+// RekapSiswa menghitung status kehadiran siswa per pertemuan dalam rentang [mulai, selesai].
+func (s *AbsensiService) RekapSiswa(ctx context.Context, siswaID int, mulai time.Time, selesai time.Time) (*RekapAbsensiSiswa, error) {
+	riwayat, err := s.absensiRepo.GetRiwayatSiswa(ctx, siswaID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	rekap := &RekapAbsensiSiswa{SiswaID: siswaID, Riwayat: riwayat}
+	for _, item := range riwayat {
+		switch item.Status {
+		case models.AbsensiHadir:
+			rekap.Hadir++
+		case models.AbsensiIzin:
+			rekap.Izin++
+		case models.AbsensiSakit:
+			rekap.Sakit++
+		case models.AbsensiAlpa:
+			rekap.Alpa++
+		}
+	}
+	return rekap, nil
+}
+
+// getSesiPengajar mengambil sesi dan memastikan guru (jika bukan admin) berhak mengelolanya. Sesi yang
+// slot jadwalnya sudah dihapus hanya dapat dikelola admin.
+func (s *AbsensiService) getSesiPengajar(ctx context.Context, sesiID int, guruID *int) (*repositories.SesiAbsensiDetail, error) {
+	sesi, err := s.absensiRepo.GetSesiByID(ctx, sesiID)
+	if err != nil {
+		return nil, err
+	}
+	if guruID != nil {
+		if sesi.JadwalID == nil {
+			return nil, ErrBukanPengajar
+		}
+		if err := s.periksaPengajar(ctx, *guruID, *sesi.JadwalID, sesi.Tanggal); err != nil {
+			return nil, err
+		}
+	}
+	return sesi, nil
+}
+
+func (s *AbsensiService) periksaPengajar(ctx context.Context, guruID int, jadwalID int, tanggal time.Time) error {
+	boleh, err := s.absensiRepo.BolehMengisi(ctx, guruID, jadwalID, tanggal)
+	if err != nil {
+		return err
+	}
+	if !boleh {
+		return ErrBukanPengajar
+	}
+	return nil
+}
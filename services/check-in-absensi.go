@@ -0,0 +1,151 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// periodeTokenCheckIn adalah lama satu token QR berlaku sebelum diganti token berikutnya.
+	periodeTokenCheckIn = 15 * time.Second
+	// toleransiTokenCheckIn adalah jumlah periode sebelumnya yang masih diterima untuk menutup jeda
+	// antara layar guru berganti dan siswa selesai memindai.
+	toleransiTokenCheckIn = 1
+	// MaksDurasiCheckIn membatasi lama check-in QR dibuka, dalam menit.
+	MaksDurasiCheckIn = 60
+)
+
+var (
+	ErrCheckInDitutup      = errors.New("check-in untuk sesi ini tidak dibuka")
+	ErrTokenCheckInSalah   = errors.New("token check-in tidak valid")
+	ErrTokenCheckInBasi    = errors.New("token check-in sudah kedaluwarsa")
+	ErrCheckInBukanHariIni = errors.New("check-in hanya dapat dibuka untuk pertemuan hari ini")
+)
+
+// TokenCheckIn adalah isi QR yang ditampilkan guru. Token diganti setiap periodeTokenCheckIn;
+// BerlakuSampai memberi tahu layar guru kapan harus mengambil token berikutnya.
+type TokenCheckIn struct {
+	Token         string
+	BerlakuSampai time.Time
+	CheckInSampai time.Time
+}
+
+// BukaCheckIn membuka check-in QR untuk sesi hari ini selama durasi menit. Kunci rahasia baru
+// dibuat setiap kali dibuka sehingga token dari pembukaan sebelumnya tidak lagi berlaku.
+func (s *AbsensiService) BukaCheckIn(ctx context.Context, sesiID int, guruID *int, durasi int) (*TokenCheckIn, error) {
+	if durasi < 1 || durasi > MaksDurasiCheckIn {
+		return nil, fmt.Errorf("%w: durasi check-in harus 1-%d menit", ErrSesiAbsensi, MaksDurasiCheckIn)
+	}
+	sesi, err := s.getSesiPengajar(ctx, sesiID, guruID)
+	if err != nil {
+		return nil, err
+	}
+	sekarang := time.Now()
+	if sesi.Tanggal.Format("2006-01-02") != sekarang.Format("2006-01-02") {
+		return nil, ErrCheckInBukanHariIni
+	}
+
+	acak := make([]byte, 32)
+	if _, err := rand.Read(acak); err != nil {
+		return nil, err
+	}
+	kode := hex.EncodeToString(acak)
+	sampai := sekarang.Add(time.Duration(durasi) * time.Minute)
+	if err := s.absensiRepo.SetCheckIn(ctx, sesiID, &kode, &sampai); err != nil {
+		return nil, err
+	}
+	return buatTokenCheckIn(sesiID, kode, sampai, sekarang), nil
+}
+
+func (s *AbsensiService) TutupCheckIn(ctx context.Context, sesiID int, guruID *int) error {
+	if _, err := s.getSesiPengajar(ctx, sesiID, guruID); err != nil {
+		return err
+	}
+	return s.absensiRepo.SetCheckIn(ctx, sesiID, nil, nil)
+}
+
+// GetTokenCheckIn mengambil token QR yang berlaku saat ini untuk ditampilkan guru.
+func (s *AbsensiService) GetTokenCheckIn(ctx context.Context, sesiID int, guruID *int) (*TokenCheckIn, error) {
+	sesi, err := s.getSesiPengajar(ctx, sesiID, guruID)
+	if err != nil {
+		return nil, err
+	}
+	sekarang := time.Now()
+	if sesi.KodeCheckIn == nil || sesi.CheckInSampai == nil || !sekarang.Before(*sesi.CheckInSampai) {
+		return nil, ErrCheckInDitutup
+	}
+	return buatTokenCheckIn(sesiID, *sesi.KodeCheckIn, *sesi.CheckInSampai, sekarang), nil
+}
+
+// CheckIn mencatat siswa hadir dari token QR yang dipindai. Token hanya diterima pada periodenya
+// dan satu periode sesudahnya, sehingga tangkapan layar yang dibagikan cepat kedaluwarsa; token dari
+// pembukaan check-in sebelumnya tidak cocok dengan kunci yang baru.
+func (s *AbsensiService) CheckIn(ctx context.Context, siswaID int, token string) (*repositories.SesiAbsensiDetail, error) {
+	bagian := strings.Split(token, ".")
+	if len(bagian) != 3 {
+		return nil, ErrTokenCheckInSalah
+	}
+	sesiID, errSesi := strconv.Atoi(bagian[0])
+	periode, errPeriode := strconv.ParseInt(bagian[1], 10, 64)
+	if errSesi != nil || errPeriode != nil {
+		return nil, ErrTokenCheckInSalah
+	}
+
+	sesi, err := s.absensiRepo.GetSesiByID(ctx, sesiID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrTokenCheckInSalah
+		}
+		return nil, err
+	}
+	sekarang := time.Now()
+	if sesi.KodeCheckIn == nil || sesi.CheckInSampai == nil || !sekarang.Before(*sesi.CheckInSampai) {
+		return nil, ErrCheckInDitutup
+	}
+	if !hmac.Equal([]byte(bagian[2]), []byte(tandaTokenCheckIn(*sesi.KodeCheckIn, sesiID, periode))) {
+		return nil, ErrTokenCheckInSalah
+	}
+	selisih := periodeSaatIni(sekarang) - periode
+	if selisih < 0 || selisih > toleransiTokenCheckIn {
+		return nil, ErrTokenCheckInBasi
+	}
+
+	if err := s.absensiRepo.CheckIn(ctx, sesiID, siswaID); err != nil {
+		return nil, err
+	}
+	return sesi, nil
+}
+
+func buatTokenCheckIn(sesiID int, kode string, checkInSampai time.Time, sekarang time.Time) *TokenCheckIn {
+	periode := periodeSaatIni(sekarang)
+	berlakuSampai := time.Unix((periode+1)*int64(periodeTokenCheckIn/time.Second), 0)
+	if berlakuSampai.After(checkInSampai) {
+		berlakuSampai = checkInSampai
+	}
+	return &TokenCheckIn{
+		Token:         fmt.Sprintf("%d.%d.%s", sesiID, periode, tandaTokenCheckIn(kode, sesiID, periode)),
+		BerlakuSampai: berlakuSampai,
+		CheckInSampai: checkInSampai,
+	}
+}
+
+func periodeSaatIni(t time.Time) int64 {
+	return t.Unix() / int64(periodeTokenCheckIn/time.Second)
+}
+
+// tandaTokenCheckIn menandatangani pasangan sesi dan periode dengan kunci rahasia sesi.
+func tandaTokenCheckIn(kode string, sesiID int, periode int64) string {
+	mac := hmac.New(sha256.New, []byte(kode))
+	fmt.Fprintf(mac, "%d.%d", sesiID, periode)
+	return hex.EncodeToString(mac.Sum(nil))[:32]
+}
@@ -0,0 +1,141 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+)
+
+// DeskripsiRaporService menyusun deskripsi capaian rapor dari template berdasarkan nilai dan topik siswa.
+type DeskripsiRaporService struct {
+	deskripsiRepo repositories.DeskripsiRaporRepository
+	rekapRepo     repositories.RekapNilaiRepository
+	kelasRepo     repositories.KelasRepository
+	mapelRepo     repositories.MapelRepository
+	kkmRepo       repositories.KKMRepository
+}
+
+func NewDeskripsiRaporService(
+	deskripsiRepo repositories.DeskripsiRaporRepository,
+	rekapRepo repositories.RekapNilaiRepository,
+	kelasRepo repositories.KelasRepository,
+	mapelRepo repositories.MapelRepository,
+	kkmRepo repositories.KKMRepository,
+) *DeskripsiRaporService {
+	return &DeskripsiRaporService{
+		deskripsiRepo: deskripsiRepo,
+		rekapRepo:     rekapRepo,
+		kelasRepo:     kelasRepo,
+		mapelRepo:     mapelRepo,
+		kkmRepo:       kkmRepo,
+	}
+}
+
+// BolehMengelola memeriksa apakah pengguna boleh membuat, menyunting, atau memfinalkan deskripsi
+// rapor suatu kelas: wali kelasnya atau admin. Mengembalikan sql.ErrNoRows jika kelas tidak ada.
+func (s *DeskripsiRaporService) BolehMengelola(ctx context.Context, kelasID int, role string, userID int) (bool, error) {
+	kelas, err := s.kelasRepo.GetByID(ctx, kelasID)
+	if err != nil {
+		return false, err
+	}
+	switch role {
+	case "guru":
+		return kelas.GuruID == userID, nil
+	case "super admin", "admin biasa":
+		return true, nil
+	}
+	return false, nil
+}
+
+// DataDeskripsi adalah nilai-nilai pengisi placeholder pada template deskripsi.
+type DataDeskripsi struct {
+	NamaSiswa     string
+	NamaMapel     string
+	Nilai         float64
+	Predikat      string
+	TopikTerkuat  string
+	TopikTerlemah string
+}
+
+// GenerateKelasMapel membuat deskripsi untuk setiap siswa yang sudah memiliki rekap nilai
+// di satu kelas dan mapel. Deskripsi yang sudah difinalkan tidak ditimpa.
+func (s *DeskripsiRaporService) GenerateKelasMapel(ctx context.Context, kelasID int, mapelID int) (int, error) {
+	kelas, err := s.kelasRepo.GetByID(ctx, kelasID)
+	if err != nil {
+		return 0, err
+	}
+	mapel, err := s.mapelRepo.GetByID(ctx, mapelID)
+	if err != nil {
+		return 0, err
+	}
+	kkm, err := getKKMTingkat(ctx, s.kkmRepo, kelas.Tingkat)
+	if err != nil {
+		return 0, err
+	}
+
+	rekap, err := s.rekapRepo.GetAllByKelasAndMapelID(ctx, kelasID, mapelID)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, item := range rekap {
+		topik, err := s.deskripsiRepo.GetNilaiPerTopik(ctx, item.SiswaID, kelasID, mapelID)
+		if err != nil {
+			return 0, err
+		}
+
+		data := DataDeskripsi{
+			NamaSiswa:     item.NamaSiswa,
+			NamaMapel:     mapel.Nama,
+			Nilai:         item.NilaiAkhir,
+			Predikat:      Predikat(item.NilaiAkhir, batasKKM(kkm, mapelID)),
+			TopikTerkuat:  mapel.Nama,
+			TopikTerlemah: mapel.Nama,
+		}
+		// Hasil GetNilaiPerTopik sudah terurut dari nilai tertinggi ke terendah.
+		if len(topik) > 0 {
+			data.TopikTerkuat = topik[0].Topik
+			data.TopikTerlemah = topik[len(topik)-1].Topik
+		}
+
+		var teks string
+		template, err := s.deskripsiRepo.FindTemplate(ctx, mapelID, data.TopikTerkuat, item.NilaiAkhir)
+		switch {
+		case err == nil:
+			teks = IsiTemplate(template.Template, data)
+		case err == sql.ErrNoRows:
+			teks = DeskripsiPredikat(data.Predikat, mapel.Nama)
+		default:
+			return 0, err
+		}
+
+		deskripsi := models.DeskripsiRapor{
+			SiswaID:         item.SiswaID,
+			MataPelajaranID: mapelID,
+			KelasID:         kelasID,
+			Deskripsi:       teks,
+		}
+		if err := s.deskripsiRepo.Upsert(ctx, &deskripsi); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(rekap), nil
+}
+
+// IsiTemplate mengganti placeholder {nama}, {mapel}, {nilai}, {predikat}, {topik_terkuat},
+// dan {topik_terlemah} pada template dengan data siswa.
+func IsiTemplate(template string, data DataDeskripsi) string {
+	replacer := strings.NewReplacer(
+		"{nama}", data.NamaSiswa,
+		"{mapel}", data.NamaMapel,
+		"{nilai}", fmt.Sprintf("%.0f", data.Nilai),
+		"{predikat}", data.Predikat,
+		"{topik_terkuat}", data.TopikTerkuat,
+		"{topik_terlemah}", data.TopikTerlemah,
+	)
+	return replacer.Replace(template)
+}
@@ -0,0 +1,533 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// maksLangkahGenerator membatasi jumlah langkah pencarian agar permintaan generate tidak berjalan
+// tanpa batas pada input yang tidak dapat dipenuhi.
+const maksLangkahGenerator = 50000
+
+var (
+	// ErrInputJadwal menandakan input generator jadwal tidak valid.
+	ErrInputJadwal = errors.New("input generator jadwal tidak valid")
+	// ErrJadwalTidakDitemukan menandakan tidak ada susunan jadwal tanpa bentrok yang ditemukan.
+	ErrJadwalTidakDitemukan = errors.New("tidak ditemukan susunan jadwal tanpa bentrok")
+)
+
+// PeriodeJadwal adalah satu jam pelajaran dalam sehari. Urutan periode sama untuk setiap hari.
+type PeriodeJadwal struct {
+	Mulai   time.Time
+	Selesai time.Time
+}
+
+// KetidaktersediaanGuru menandai periode (nomor 1-based) di satu hari ketika guru tidak dapat
+// mengajar. Periode kosong berarti guru tidak tersedia sepanjang hari.
+type KetidaktersediaanGuru struct {
+	GuruID  int
+	Hari    string
+	Periode []int
+}
+
+// KebutuhanJadwal adalah jumlah jam pelajaran per minggu satu mapel di satu kelas beserta guru
+// pengampunya. Ruang diisi jika pelajaran wajib di ruang tertentu (misalnya laboratorium).
+type KebutuhanJadwal struct {
+	KelasID         int
+	MataPelajaranID int
+	GuruID          int
+	JamPerMinggu    int
+	Ruang           *string
+}
+
+type InputJadwal struct {
+	Hari          []string
+	Periode       []PeriodeJadwal
+	Ruang         []string
+	TidakTersedia []KetidaktersediaanGuru
+	Kebutuhan     []KebutuhanJadwal
+	// MaksBerturut adalah preferensi jumlah maksimum jam berturut-turut satu mapel dalam sehari.
+	MaksBerturut int
+}
+
+// PelanggaranPreferensi mencatat preferensi lunak yang tidak dapat dipenuhi pada hasil generate.
+type PelanggaranPreferensi struct {
+	KelasID         int    `json:"kelas_id"`
+	MataPelajaranID int    `json:"mata_pelajaran_id"`
+	Hari            string `json:"hari"`
+	Keterangan      string `json:"keterangan"`
+}
+
+type HasilGenerateJadwal struct {
+	Jadwal      []models.JadwalKelas
+	Pelanggaran []PelanggaranPreferensi
+	Langkah     int
+}
+
+// KelasIDs mengembalikan kelas yang jadwalnya disusun ulang oleh input ini, tanpa duplikat.
+func (in *InputJadwal) KelasIDs() []int {
+	var ids []int
+	ada := make(map[int]bool)
+	for _, item := range in.Kebutuhan {
+		if !ada[item.KelasID] {
+			ada[item.KelasID] = true
+			ids = append(ids, item.KelasID)
+		}
+	}
+	return ids
+}
+
+func (in *InputJadwal) validasi() error {
+	if len(in.Hari) == 0 || len(in.Periode) == 0 || len(in.Kebutuhan) == 0 {
+		return fmt.Errorf("%w: hari, periode, dan kebutuhan wajib diisi", ErrInputJadwal)
+	}
+	hariValid := map[string]bool{"Senin": true, "Selasa": true, "Rabu": true, "Kamis": true, "Jumat": true, "Sabtu": true}
+	hariAda := make(map[string]bool)
+	for _, hari := range in.Hari {
+		if !hariValid[hari] || hariAda[hari] {
+			return fmt.Errorf("%w: hari '%s' tidak valid atau ganda", ErrInputJadwal, hari)
+		}
+		hariAda[hari] = true
+	}
+	for i, periode := range in.Periode {
+		if menitHari(periode.Selesai) <= menitHari(periode.Mulai) {
+			return fmt.Errorf("%w: periode %d harus selesai setelah mulai", ErrInputJadwal, i+1)
+		}
+		if i > 0 && menitHari(periode.Mulai) < menitHari(in.Periode[i-1].Selesai) {
+			return fmt.Errorf("%w: periode %d beririsan dengan periode sebelumnya", ErrInputJadwal, i+1)
+		}
+	}
+	for _, item := range in.TidakTersedia {
+		if !hariAda[item.Hari] {
+			return fmt.Errorf("%w: hari '%s' pada ketersediaan guru %d tidak termasuk hari sekolah", ErrInputJadwal, item.Hari, item.GuruID)
+		}
+		for _, p := range item.Periode {
+			if p < 1 || p > len(in.Periode) {
+				return fmt.Errorf("%w: periode %d pada ketersediaan guru %d tidak ada", ErrInputJadwal, p, item.GuruID)
+			}
+		}
+	}
+
+	totalSlot := len(in.Hari) * len(in.Periode)
+	jamKelas := make(map[int]int)
+	pasangan := make(map[[2]int]bool)
+	for _, item := range in.Kebutuhan {
+		key := [2]int{item.KelasID, item.MataPelajaranID}
+		if pasangan[key] {
+			return fmt.Errorf("%w: mapel %d pada kelas %d muncul lebih dari sekali", ErrInputJadwal, item.MataPelajaranID, item.KelasID)
+		}
+		pasangan[key] = true
+		if item.JamPerMinggu < 1 {
+			return fmt.Errorf("%w: jam per minggu mapel %d kelas %d harus minimal 1", ErrInputJadwal, item.MataPelajaranID, item.KelasID)
+		}
+		jamKelas[item.KelasID] += item.JamPerMinggu
+		if jamKelas[item.KelasID] > totalSlot {
+			return fmt.Errorf("%w: total jam kelas %d melebihi %d slot per minggu", ErrInputJadwal, item.KelasID, totalSlot)
+		}
+	}
+	return nil
+}
+
+// SusunJadwal membuat jadwal tanpa bentrok kelas, guru, maupun ruang untuk seluruh kebutuhan.
+// Slot tersimpan milik kelas lain (terpakai) ikut dihormati sebagai jam sibuk guru dan ruang;
+// slot milik kelas yang disusun ulang diabaikan karena akan diganti.
+//
+// Pencarian memakai backtracking dengan heuristik MRV (kebutuhan dengan sisa slot paling sempit
+// dipilih lebih dulu). Preferensi lunak (maksimal MaksBerturut jam berturut-turut dan sebaran
+// merata antarhari) dipakai untuk mengurutkan pilihan slot, sehingga dipenuhi sebisa mungkin
+// tanpa membuat jadwal gagal. Hasilnya deterministik untuk input yang sama.
+func SusunJadwal(input InputJadwal, terpakai []models.JadwalKelas) (*HasilGenerateJadwal, error) {
+	if err := input.validasi(); err != nil {
+		return nil, err
+	}
+	if input.MaksBerturut < 1 {
+		input.MaksBerturut = 2
+	}
+
+	s := newSolverJadwal(input, terpakai)
+	if err := s.cekKapasitasGuru(); err != nil {
+		return nil, err
+	}
+	if !s.cari() {
+		return nil, ErrJadwalTidakDitemukan
+	}
+	return s.hasil(), nil
+}
+
+type solverJadwal struct {
+	input    InputJadwal
+	nPeriode int
+	nSlot    int
+
+	guruSibuk  map[int][]bool
+	ruangSibuk map[string][]bool
+	// ruangUmum adalah ruang yang dapat dipakai pelajaran tanpa ruang khusus, diurutkan agar
+	// ruang yang diminta kebutuhan tertentu dipakai paling akhir.
+	ruangUmum []string
+
+	// grid menyimpan indeks kebutuhan yang mengisi setiap slot kelas (-1 jika kosong).
+	grid         map[int][]int
+	posisi       [][]int
+	ruangDipakai [][]string
+	langkah      int
+}
+
+func newSolverJadwal(input InputJadwal, terpakai []models.JadwalKelas) *solverJadwal {
+	s := &solverJadwal{
+		input:        input,
+		nPeriode:     len(input.Periode),
+		nSlot:        len(input.Hari) * len(input.Periode),
+		guruSibuk:    make(map[int][]bool),
+		ruangSibuk:   make(map[string][]bool),
+		grid:         make(map[int][]int),
+		posisi:       make([][]int, len(input.Kebutuhan)),
+		ruangDipakai: make([][]string, len(input.Kebutuhan)),
+	}
+
+	for _, item := range input.Kebutuhan {
+		if s.grid[item.KelasID] == nil {
+			s.grid[item.KelasID] = make([]int, s.nSlot)
+			for i := range s.grid[item.KelasID] {
+				s.grid[item.KelasID][i] = -1
+			}
+		}
+	}
+
+	ruangKhusus := make(map[string]bool)
+	for _, item := range input.Kebutuhan {
+		if item.Ruang != nil {
+			ruangKhusus[strings.ToLower(*item.Ruang)] = true
+		}
+	}
+	for _, ruang := range input.Ruang {
+		if !ruangKhusus[strings.ToLower(ruang)] {
+			s.ruangUmum = append(s.ruangUmum, ruang)
+		}
+	}
+	for _, ruang := range input.Ruang {
+		if ruangKhusus[strings.ToLower(ruang)] {
+			s.ruangUmum = append(s.ruangUmum, ruang)
+		}
+	}
+
+	for _, item := range input.TidakTersedia {
+		for d, hari := range input.Hari {
+			if hari != item.Hari {
+				continue
+			}
+			if len(item.Periode) == 0 {
+				for p := 0; p < s.nPeriode; p++ {
+					s.tandai(s.guruSibuk, item.GuruID, d*s.nPeriode+p, true)
+				}
+			}
+			for _, p := range item.Periode {
+				s.tandai(s.guruSibuk, item.GuruID, d*s.nPeriode+p-1, true)
+			}
+		}
+	}
+
+	for _, jadwal := range terpakai {
+		if _, disusunUlang := s.grid[jadwal.KelasID]; disusunUlang {
+			continue
+		}
+		for d, hari := range input.Hari {
+			if hari != jadwal.Hari {
+				continue
+			}
+			for p, periode := range input.Periode {
+				if menitHari(periode.Mulai) >= menitHari(jadwal.JamSelesai) || menitHari(jadwal.JamMulai) >= menitHari(periode.Selesai) {
+					continue
+				}
+				slot := d*s.nPeriode + p
+				if jadwal.GuruID != nil {
+					s.tandai(s.guruSibuk, *jadwal.GuruID, slot, true)
+				}
+				if jadwal.Ruang != nil {
+					s.tandaiRuang(*jadwal.Ruang, slot, true)
+				}
+			}
+		}
+	}
+	return s
+}
+
+// cekKapasitasGuru menolak lebih awal guru yang jam mengajarnya melebihi slot yang masih tersedia,
+// agar kasus yang jelas mustahil tidak menghabiskan batas langkah pencarian.
+func (s *solverJadwal) cekKapasitasGuru() error {
+	jamGuru := make(map[int]int)
+	var urutanGuru []int
+	for _, item := range s.input.Kebutuhan {
+		if _, ok := jamGuru[item.GuruID]; !ok {
+			urutanGuru = append(urutanGuru, item.GuruID)
+		}
+		jamGuru[item.GuruID] += item.JamPerMinggu
+	}
+	for _, guruID := range urutanGuru {
+		tersedia := s.nSlot
+		for _, sibuk := range s.guruSibuk[guruID] {
+			if sibuk {
+				tersedia--
+			}
+		}
+		if jamGuru[guruID] > tersedia {
+			return fmt.Errorf("%w: guru %d perlu %d jam tetapi hanya tersedia %d slot", ErrJadwalTidakDitemukan, guruID, jamGuru[guruID], tersedia)
+		}
+	}
+	return nil
+}
+
+func (s *solverJadwal) tandai(sibuk map[int][]bool, id int, slot int, nilai bool) {
+	if sibuk[id] == nil {
+		sibuk[id] = make([]bool, s.nSlot)
+	}
+	sibuk[id][slot] = nilai
+}
+
+func (s *solverJadwal) tandaiRuang(ruang string, slot int, nilai bool) {
+	key := strings.ToLower(ruang)
+	if s.ruangSibuk[key] == nil {
+		s.ruangSibuk[key] = make([]bool, s.nSlot)
+	}
+	s.ruangSibuk[key][slot] = nilai
+}
+
+func (s *solverJadwal) ruangKosong(ruang string, slot int) bool {
+	sibuk := s.ruangSibuk[strings.ToLower(ruang)]
+	return sibuk == nil || !sibuk[slot]
+}
+
+// pilihRuang mengembalikan ruang untuk kebutuhan r di slot. ok bernilai false jika tidak ada
+// ruang yang kosong; ruang kosong ("") berarti generator tidak mengelola ruang.
+func (s *solverJadwal) pilihRuang(r int, slot int) (string, bool) {
+	if fixed := s.input.Kebutuhan[r].Ruang; fixed != nil {
+		return *fixed, s.ruangKosong(*fixed, slot)
+	}
+	if len(s.ruangUmum) == 0 {
+		return "", true
+	}
+	for _, ruang := range s.ruangUmum {
+		if s.ruangKosong(ruang, slot) {
+			return ruang, true
+		}
+	}
+	return "", false
+}
+
+// bisaDitempati memeriksa batasan keras untuk kebutuhan r di slot.
+func (s *solverJadwal) bisaDitempati(r int, slot int) bool {
+	item := s.input.Kebutuhan[r]
+	if s.grid[item.KelasID][slot] != -1 {
+		return false
+	}
+	if sibuk := s.guruSibuk[item.GuruID]; sibuk != nil && sibuk[slot] {
+		return false
+	}
+	_, ok := s.pilihRuang(r, slot)
+	return ok
+}
+
+func (s *solverJadwal) tempatkan(r int, slot int) {
+	item := s.input.Kebutuhan[r]
+	ruang, _ := s.pilihRuang(r, slot)
+	s.grid[item.KelasID][slot] = r
+	s.tandai(s.guruSibuk, item.GuruID, slot, true)
+	if ruang != "" {
+		s.tandaiRuang(ruang, slot, true)
+	}
+	s.posisi[r] = append(s.posisi[r], slot)
+	s.ruangDipakai[r] = append(s.ruangDipakai[r], ruang)
+}
+
+func (s *solverJadwal) lepas(r int) {
+	item := s.input.Kebutuhan[r]
+	n := len(s.posisi[r]) - 1
+	slot, ruang := s.posisi[r][n], s.ruangDipakai[r][n]
+	s.grid[item.KelasID][slot] = -1
+	s.tandai(s.guruSibuk, item.GuruID, slot, false)
+	if ruang != "" {
+		s.tandaiRuang(ruang, slot, false)
+	}
+	s.posisi[r] = s.posisi[r][:n]
+	s.ruangDipakai[r] = s.ruangDipakai[r][:n]
+}
+
+// biaya menghitung pelanggaran preferensi lunak jika kebutuhan r ditempatkan di slot.
+// Periode lebih awal sedikit diutamakan agar jadwal harian padat tanpa jam kosong.
+func (s *solverJadwal) biaya(r int, slot int) float64 {
+	item := s.input.Kebutuhan[r]
+	grid := s.grid[item.KelasID]
+	d, p := slot/s.nPeriode, slot%s.nPeriode
+
+	berturut := 1
+	for q := p - 1; q >= 0 && grid[d*s.nPeriode+q] == r; q-- {
+		berturut++
+	}
+	for q := p + 1; q < s.nPeriode && grid[d*s.nPeriode+q] == r; q++ {
+		berturut++
+	}
+
+	var biaya float64
+	if berturut > s.input.MaksBerturut {
+		biaya += 10 * float64(berturut-s.input.MaksBerturut)
+	}
+
+	target := (item.JamPerMinggu + len(s.input.Hari) - 1) / len(s.input.Hari)
+	hariIni := 0
+	for q := 0; q < s.nPeriode; q++ {
+		if grid[d*s.nPeriode+q] == r {
+			hariIni++
+		}
+	}
+	if hariIni >= target {
+		biaya += 3 * float64(hariIni-target+1)
+	}
+	return biaya + float64(p)*0.01
+}
+
+func (s *solverJadwal) cari() bool {
+	s.langkah++
+	if s.langkah > maksLangkahGenerator {
+		return false
+	}
+
+	pilihan, kandidatPilihan, sisaMin := -1, []int(nil), 0
+	for r, item := range s.input.Kebutuhan {
+		sisa := item.JamPerMinggu - len(s.posisi[r])
+		if sisa == 0 {
+			continue
+		}
+		var kandidat []int
+		for slot := 0; slot < s.nSlot; slot++ {
+			if s.bisaDitempati(r, slot) {
+				kandidat = append(kandidat, slot)
+			}
+		}
+		longgar := len(kandidat) - sisa
+		if longgar < 0 {
+			return false
+		}
+		if pilihan == -1 || longgar < sisaMin {
+			pilihan, kandidatPilihan, sisaMin = r, kandidat, longgar
+		}
+	}
+	if pilihan == -1 {
+		return true
+	}
+
+	biaya := make(map[int]float64, len(kandidatPilihan))
+	for _, slot := range kandidatPilihan {
+		biaya[slot] = s.biaya(pilihan, slot)
+	}
+	sort.SliceStable(kandidatPilihan, func(a, b int) bool {
+		return biaya[kandidatPilihan[a]] < biaya[kandidatPilihan[b]]
+	})
+
+	for _, slot := range kandidatPilihan {
+		s.tempatkan(pilihan, slot)
+		if s.cari() {
+			return true
+		}
+		s.lepas(pilihan)
+		if s.langkah > maksLangkahGenerator {
+			return false
+		}
+	}
+	return false
+}
+
+func (s *solverJadwal) hasil() *HasilGenerateJadwal {
+	hasil := &HasilGenerateJadwal{
+		Jadwal:      []models.JadwalKelas{},
+		Pelanggaran: []PelanggaranPreferensi{},
+		Langkah:     s.langkah,
+	}
+
+	for r, item := range s.input.Kebutuhan {
+		guruID := item.GuruID
+		for i, slot := range s.posisi[r] {
+			d, p := slot/s.nPeriode, slot%s.nPeriode
+			jadwal := models.JadwalKelas{
+				KelasID:         item.KelasID,
+				MataPelajaranID: item.MataPelajaranID,
+				Hari:            s.input.Hari[d],
+				JamMulai:        s.input.Periode[p].Mulai,
+				JamSelesai:      s.input.Periode[p].Selesai,
+				GuruID:          &guruID,
+			}
+			if ruang := s.ruangDipakai[r][i]; ruang != "" {
+				jadwal.Ruang = &ruang
+			}
+			hasil.Jadwal = append(hasil.Jadwal, jadwal)
+		}
+
+		for d, hari := range s.input.Hari {
+			berturut := 0
+			for p := 0; p <= s.nPeriode; p++ {
+				if p < s.nPeriode && s.grid[item.KelasID][d*s.nPeriode+p] == r {
+					berturut++
+					continue
+				}
+				if berturut > s.input.MaksBerturut {
+					hasil.Pelanggaran = append(hasil.Pelanggaran, PelanggaranPreferensi{
+						KelasID:         item.KelasID,
+						MataPelajaranID: item.MataPelajaranID,
+						Hari:            hari,
+						Keterangan:      fmt.Sprintf("%d jam pelajaran berturut-turut (preferensi maksimal %d).", berturut, s.input.MaksBerturut),
+					})
+				}
+				berturut = 0
+			}
+		}
+	}
+
+	urutanHari := make(map[string]int)
+	for d, hari := range s.input.Hari {
+		urutanHari[hari] = d
+	}
+	sort.SliceStable(hasil.Jadwal, func(a, b int) bool {
+		ja, jb := hasil.Jadwal[a], hasil.Jadwal[b]
+		if ja.KelasID != jb.KelasID {
+			return ja.KelasID < jb.KelasID
+		}
+		if ja.Hari != jb.Hari {
+			return urutanHari[ja.Hari] < urutanHari[jb.Hari]
+		}
+		return menitHari(ja.JamMulai) < menitHari(jb.JamMulai)
+	})
+	return hasil
+}
+
+// menitHari mengubah jam menjadi menit sejak tengah malam, sehingga jam dari database dan jam
+// hasil parsing dapat dibandingkan tanpa memperhatikan tanggal dan zona waktu.
+func menitHari(t time.Time) int {
+	return t.Hour()*60 + t.Minute()
+}
+
+// GeneratorJadwalService menyusun jadwal otomatis dan menyimpannya menggantikan jadwal lama.
+type GeneratorJadwalService struct {
+	jadwalRepo repositories.JadwalKelasRepository
+}
+
+func NewGeneratorJadwalService(jadwalRepo repositories.JadwalKelasRepository) *GeneratorJadwalService {
+	return &GeneratorJadwalService{jadwalRepo: jadwalRepo}
+}
+
+// Generate menyusun jadwal dengan memperhitungkan jadwal tersimpan milik kelas lain.
+func (s *GeneratorJadwalService) Generate(ctx context.Context, input InputJadwal) (*HasilGenerateJadwal, error) {
+	terpakai, err := s.jadwalRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return SusunJadwal(input, terpakai)
+}
+
+// Simpan mengganti seluruh jadwal kelas yang disusun dengan hasil generate dalam satu transaksi.
+func (s *GeneratorJadwalService) Simpan(ctx context.Context, input InputJadwal, hasil *HasilGenerateJadwal) error {
+	return s.jadwalRepo.ReplaceByKelasIDs(ctx, input.KelasIDs(), hasil.Jadwal)
+}
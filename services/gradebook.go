@@ -0,0 +1,298 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/xuri/excelize/v2"
+)
+
+// KolomGradebook adalah satu kolom nilai (tugas atau quiz) pada gradebook.
+type KolomGradebook struct {
+	Jenis string
+	ID    int
+	Judul string
+}
+
+// BarisSiswaGradebook adalah satu baris gradebook: nilai siswa untuk setiap kolom beserta rekapnya.
+type BarisSiswaGradebook struct {
+	SiswaID    int
+	NamaSiswa  string
+	Nilai      []*float64
+	NilaiTugas *float64
+	NilaiQuiz  *float64
+	NilaiAkhir *float64
+}
+
+// GradebookService menyusun gradebook satu kelas dan mapel lalu menuliskannya sebagai CSV atau XLSX.
+type GradebookService struct {
+	rekapRepo    repositories.RekapNilaiRepository
+	tugasRepo    repositories.TugasRepository
+	quizRepo     repositories.QuizRepository
+	remedialRepo repositories.RemedialRepository
+}
+
+func NewGradebookService(
+	rekapRepo repositories.RekapNilaiRepository,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+	remedialRepo repositories.RemedialRepository,
+) *GradebookService {
+	return &GradebookService{
+		rekapRepo:    rekapRepo,
+		tugasRepo:    tugasRepo,
+		quizRepo:     quizRepo,
+		remedialRepo: remedialRepo,
+	}
+}
+
+// GetKolom mengambil kolom gradebook: seluruh tugas (urut deadline) lalu seluruh quiz.
+// Tugas/quiz remedial tidak dijadikan kolom karena nilainya sudah masuk ke tugas/quiz asal.
+func (s *GradebookService) GetKolom(ctx context.Context, kelasID int, mapelID int) ([]KolomGradebook, error) {
+	daftarRemedial, err := s.remedialRepo.GetAllByKelasAndMapelID(ctx, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	remedial := make(map[string]map[int]bool)
+	for _, item := range daftarRemedial {
+		if remedial[item.Jenis] == nil {
+			remedial[item.Jenis] = make(map[int]bool)
+		}
+		remedial[item.Jenis][item.ItemID] = true
+	}
+
+	var kolom []KolomGradebook
+
+	tugases, err := s.tugasRepo.GetAllByKelasAndMapelID(ctx, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(tugases) - 1; i >= 0; i-- {
+		if !remedial["tugas"][tugases[i].ID] {
+			kolom = append(kolom, KolomGradebook{Jenis: "tugas", ID: tugases[i].ID, Judul: tugases[i].Judul})
+		}
+	}
+
+	quizzes, err := s.quizRepo.GetAllByKelasAndMapelID(ctx, kelasID, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, quiz := range quizzes {
+		if !remedial["quiz"][quiz.ID] {
+			kolom = append(kolom, KolomGradebook{Jenis: "quiz", ID: quiz.ID, Judul: quiz.Judul})
+		}
+	}
+
+	return kolom, nil
+}
+
+// IterasiSiswa membaca gradebook baris demi baris dari database dan memanggil fn sekali untuk setiap siswa.
+func (s *GradebookService) IterasiSiswa(ctx context.Context, kolom []KolomGradebook, kelasID int, mapelID int, fn func(BarisSiswaGradebook) error) error {
+	indexKolom := make(map[string]int)
+	for i, k := range kolom {
+		indexKolom[k.Jenis+":"+strconv.Itoa(k.ID)] = i
+	}
+
+	var current *BarisSiswaGradebook
+	err := s.rekapRepo.IterateGradebook(ctx, kelasID, mapelID, func(baris repositories.BarisGradebook) error {
+		if current == nil || current.SiswaID != baris.SiswaID {
+			if current != nil {
+				if err := fn(*current); err != nil {
+					return err
+				}
+			}
+			current = &BarisSiswaGradebook{
+				SiswaID:    baris.SiswaID,
+				NamaSiswa:  baris.NamaSiswa,
+				Nilai:      make([]*float64, len(kolom)),
+				NilaiTugas: baris.NilaiTugas,
+				NilaiQuiz:  baris.NilaiQuiz,
+				NilaiAkhir: baris.NilaiAkhir,
+			}
+		}
+		if baris.Jenis != nil && baris.SumberID != nil {
+			if i, ok := indexKolom[*baris.Jenis+":"+strconv.Itoa(*baris.SumberID)]; ok {
+				current.Nilai[i] = baris.Nilai
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if current != nil {
+		return fn(*current)
+	}
+	return nil
+}
+
+// JudulKolomGradebook membentuk judul kolom nilai, misalnya "Tugas: PR 1". Dipakai juga saat impor
+// untuk mencocokkan kolom file hasil ekspor dengan tugas/quiz tujuan.
+func JudulKolomGradebook(jenis string, judul string) string {
+	label := "Tugas"
+	if jenis == "quiz" {
+		label = "Quiz"
+	}
+	return fmt.Sprintf("%s: %s", label, judul)
+}
+
+func headerGradebook(kolom []KolomGradebook) []string {
+	header := []string{"No", "ID Siswa", "Nama Siswa"}
+	for _, k := range kolom {
+		header = append(header, JudulKolomGradebook(k.Jenis, k.Judul))
+	}
+	return append(header, "Nilai Tugas", "Nilai Quiz", "Nilai Akhir")
+}
+
+// TulisCSV menuliskan gradebook dalam format CSV. Diawali BOM UTF-8 agar terbaca benar di Excel.
+func (s *GradebookService) TulisCSV(ctx context.Context, w io.Writer, kolom []KolomGradebook, kelasID int, mapelID int) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString("\ufeff"); err != nil {
+		return err
+	}
+	cw := csv.NewWriter(bw)
+	if err := cw.Write(headerGradebook(kolom)); err != nil {
+		return err
+	}
+
+	no := 0
+	err := s.IterasiSiswa(ctx, kolom, kelasID, mapelID, func(baris BarisSiswaGradebook) error {
+		no++
+		record := []string{strconv.Itoa(no), strconv.Itoa(baris.SiswaID), baris.NamaSiswa}
+		for _, nilai := range baris.Nilai {
+			record = append(record, formatNilaiCSV(nilai))
+		}
+		record = append(record, formatNilaiCSV(baris.NilaiTugas), formatNilaiCSV(baris.NilaiQuiz), formatNilaiCSV(baris.NilaiAkhir))
+		return cw.Write(record)
+	})
+	if err != nil {
+		return err
+	}
+
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+func formatNilaiCSV(nilai *float64) string {
+	if nilai == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*nilai, 'f', 2, 64)
+}
+
+// TulisXLSX menuliskan gradebook dalam format XLSX menggunakan stream writer excelize,
+// sehingga baris tidak ditampung seluruhnya di memori.
+func (s *GradebookService) TulisXLSX(ctx context.Context, w io.Writer, kolom []KolomGradebook, kelasID int, mapelID int) error {
+	f := excelize.NewFile()
+	defer f.Close()
+
+	const sheet = "Gradebook"
+	if err := f.SetSheetName("Sheet1", sheet); err != nil {
+		return err
+	}
+
+	styleHeader, err := f.NewStyle(&excelize.Style{
+		Font:      &excelize.Font{Bold: true},
+		Fill:      excelize.Fill{Type: "pattern", Color: []string{"D9E1F2"}, Pattern: 1},
+		Alignment: &excelize.Alignment{Horizontal: "center", Vertical: "center", WrapText: true},
+		Border:    borderTipis(),
+	})
+	if err != nil {
+		return err
+	}
+	styleNilai, err := f.NewStyle(&excelize.Style{NumFmt: 2, Border: borderTipis()})
+	if err != nil {
+		return err
+	}
+	styleRekap, err := f.NewStyle(&excelize.Style{NumFmt: 2, Font: &excelize.Font{Bold: true}, Border: borderTipis()})
+	if err != nil {
+		return err
+	}
+	styleTeks, err := f.NewStyle(&excelize.Style{Border: borderTipis()})
+	if err != nil {
+		return err
+	}
+
+	sw, err := f.NewStreamWriter(sheet)
+	if err != nil {
+		return err
+	}
+
+	jumlahKolom := len(kolom) + 6
+	if err := sw.SetColWidth(1, 2, 8); err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(3, 3, 30); err != nil {
+		return err
+	}
+	if err := sw.SetColWidth(4, jumlahKolom, 14); err != nil {
+		return err
+	}
+	if err := sw.SetPanes(&excelize.Panes{Freeze: true, XSplit: 3, YSplit: 1, TopLeftCell: "D2", ActivePane: "bottomRight"}); err != nil {
+		return err
+	}
+
+	header := headerGradebook(kolom)
+	cells := make([]interface{}, len(header))
+	for i, judul := range header {
+		cells[i] = excelize.Cell{StyleID: styleHeader, Value: judul}
+	}
+	if err := sw.SetRow("A1", cells, excelize.RowOpts{Height: 30}); err != nil {
+		return err
+	}
+
+	row := 1
+	err = s.IterasiSiswa(ctx, kolom, kelasID, mapelID, func(baris BarisSiswaGradebook) error {
+		row++
+		cells := []interface{}{
+			excelize.Cell{StyleID: styleTeks, Value: row - 1},
+			excelize.Cell{StyleID: styleTeks, Value: baris.SiswaID},
+			excelize.Cell{StyleID: styleTeks, Value: baris.NamaSiswa},
+		}
+		for _, nilai := range baris.Nilai {
+			cells = append(cells, cellNilai(styleNilai, nilai))
+		}
+		cells = append(cells,
+			cellNilai(styleRekap, baris.NilaiTugas),
+			cellNilai(styleRekap, baris.NilaiQuiz),
+			cellNilai(styleRekap, baris.NilaiAkhir),
+		)
+		cell, err := excelize.CoordinatesToCellName(1, row)
+		if err != nil {
+			return err
+		}
+		return sw.SetRow(cell, cells)
+	})
+	if err != nil {
+		return err
+	}
+
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return f.Write(w)
+}
+
+func cellNilai(styleID int, nilai *float64) excelize.Cell {
+	if nilai == nil {
+		return excelize.Cell{StyleID: styleID}
+	}
+	return excelize.Cell{StyleID: styleID, Value: *nilai}
+}
+
+func borderTipis() []excelize.Border {
+	return []excelize.Border{
+		{Type: "left", Color: "BFBFBF", Style: 1},
+		{Type: "right", Color: "BFBFBF", Style: 1},
+		{Type: "top", Color: "BFBFBF", Style: 1},
+		{Type: "bottom", Color: "BFBFBF", Style: 1},
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maksHariKetidakhadiran membatasi rentang satu catatan ketidakhadiran agar jumlah pertemuan yang
+// dibuat tetap wajar; ketidakhadiran yang lebih panjang dicatat bertahap.
+const maksHariKetidakhadiran = 60
+
+// maksSaranPengganti adalah jumlah calon pengganti yang ditampilkan per pertemuan.
+const maksSaranPengganti = 5
+
+var (
+	ErrRentangKetidakhadiran = errors.New("rentang tanggal ketidakhadiran tidak valid")
+	ErrGuruTidakDitemukan    = errors.New("guru tidak ditemukan")
+)
+
+// SesiPenggantiDetail adalah pertemuan yang perlu digantikan beserta saran pengganti jika
+// penggantinya belum ditetapkan.
+type SesiPenggantiDetail struct {
+	Sesi  repositories.SesiPengganti
+	Saran []repositories.GuruTersedia
+}
+
+type DetailKetidakhadiran struct {
+	Ketidakhadiran models.KetidakhadiranGuru
+	Sesi           []SesiPenggantiDetail
+}
+
+// GuruPenggantiService mencatat ketidakhadiran guru, menurunkan pertemuan yang terdampak dari
+// jadwal, dan menetapkan guru pengganti beserta notifikasinya.
+type GuruPenggantiService struct {
+	penggantiRepo repositories.GuruPenggantiRepository
+	pribadiRepo   repositories.JadwalPribadiRepository
+	guruRepo      repositories.GuruRepository
+	kalenderRepo  repositories.KalenderAkademikRepository
+}
+
+func NewGuruPenggantiService(
+	penggantiRepo repositories.GuruPenggantiRepository,
+	pribadiRepo repositories.JadwalPribadiRepository,
+	guruRepo repositories.GuruRepository,
+	kalenderRepo repositories.KalenderAkademikRepository,
+) *GuruPenggantiService {
+	return &GuruPenggantiService{
+		penggantiRepo: penggantiRepo,
+		pribadiRepo:   pribadiRepo,
+		guruRepo:      guruRepo,
+		kalenderRepo:  kalenderRepo,
+	}
+}
+
+// CatatKetidakhadiran menyimpan ketidakhadiran dan satu pertemuan untuk setiap slot guru tersebut
+// yang jatuh di rentang tanggal, kecuali pada hari libur. Mengembalikan detail beserta saran
+// pengganti dan jumlah penugasan guru itu sebagai pengganti yang dilepas.
+func (s *GuruPenggantiService) CatatKetidakhadiran(ctx context.Context, ketidakhadiran *models.KetidakhadiranGuru) (*DetailKetidakhadiran, int, error) {
+	mulai := ketidakhadiran.TanggalMulai
+	selesai := ketidakhadiran.TanggalSelesai
+	if selesai.Before(mulai) {
+		return nil, 0, fmt.Errorf("%w: tanggal selesai sebelum tanggal mulai", ErrRentangKetidakhadiran)
+	}
+	if selesai.Sub(mulai) > maksHariKetidakhadiran*24*time.Hour {
+		return nil, 0, fmt.Errorf("%w: rentang lebih dari %d hari", ErrRentangKetidakhadiran, maksHariKetidakhadiran)
+	}
+
+	if _, err := s.guruRepo.GetByID(ctx, ketidakhadiran.GuruID); err != nil {
+		if err == sql.ErrNoRows {
+			return nil, 0, ErrGuruTidakDitemukan
+		}
+		return nil, 0, err
+	}
+
+	slot, err := s.pribadiRepo.GetSlotByGuruID(ctx, ketidakhadiran.GuruID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	libur, err := hariLibur(ctx, s.kalenderRepo, mulai, selesai)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var sesi []models.GuruPengganti
+	for tanggal := mulai; !tanggal.After(selesai); tanggal = tanggal.AddDate(0, 0, 1) {
+		if _, ok := libur[tanggal.Format("2006-01-02")]; ok {
+			continue
+		}
+		for _, item := range slot {
+			if item.Hari == NamaHari(tanggal) {
+				sesi = append(sesi, models.GuruPengganti{JadwalID: item.ID, Tanggal: tanggal})
+			}
+		}
+	}
+
+	dilepas, err := s.penggantiRepo.CreateKetidakhadiran(ctx, ketidakhadiran, sesi)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	detail, err := s.GetDetail(ctx, ketidakhadiran.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+	return detail, dilepas, nil
+}
+
+// GetDetail mengambil ketidakhadiran beserta pertemuannya. Pertemuan yang belum memiliki pengganti
+// dilengkapi saran guru yang bebas pada jam tersebut.
+func (s *GuruPenggantiService) GetDetail(ctx context.Context, id int) (*DetailKetidakhadiran, error) {
+	ketidakhadiran, err := s.penggantiRepo.GetKetidakhadiranByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	sesi, err := s.penggantiRepo.GetSesiByKetidakhadiranID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	detail := &DetailKetidakhadiran{Ketidakhadiran: *ketidakhadiran, Sesi: []SesiPenggantiDetail{}}
+	for _, item := range sesi {
+		saran := []repositories.GuruTersedia{}
+		if item.GuruPenggantiID == nil {
+			tersedia, err := s.penggantiRepo.GetGuruTersedia(ctx, item.ID)
+			if err != nil {
+				return nil, err
+			}
+			if len(tersedia) > maksSaranPengganti {
+				tersedia = tersedia[:maksSaranPengganti]
+			}
+			saran = append(saran, tersedia...)
+		}
+		detail.Sesi = append(detail.Sesi, SesiPenggantiDetail{Sesi: item, Saran: saran})
+	}
+	return detail, nil
+}
+
+// Tetapkan menetapkan guru pengganti satu pertemuan, atau melepasnya jika guruID nil, lalu
+// memberi tahu guru pengganti, guru yang berhalangan, pengganti sebelumnya, dan siswa kelas itu.
+func (s *GuruPenggantiService) Tetapkan(ctx context.Context, sesiID int, guruID *int) (*repositories.SesiPengganti, error) {
+	sesi, err := s.penggantiRepo.GetSesiByID(ctx, sesiID)
+	if err != nil {
+		return nil, err
+	}
+
+	waktu := fmt.Sprintf("%s, %s pukul %s-%s", sesi.Hari, sesi.Tanggal.Format("02/01/2006"),
+		sesi.JamMulai.Format("15:04"), sesi.JamSelesai.Format("15:04"))
+	pertemuan := fmt.Sprintf("%s di kelas %s pada %s", sesi.NamaMapel, sesi.NamaKelas, waktu)
+
+	var notifikasi []models.Notifikasi
+	var untukKelas *repositories.NotifikasiKelas
+	if sesi.GuruPenggantiID != nil && (guruID == nil || *sesi.GuruPenggantiID != *guruID) {
+		notifikasi = append(notifikasi, models.Notifikasi{
+			Role:   "guru",
+			UserID: *sesi.GuruPenggantiID,
+			Judul:  "Penugasan guru pengganti dibatalkan",
+			Pesan:  "Anda tidak lagi menggantikan " + sesi.NamaGuru + " mengajar " + pertemuan + ".",
+		})
+	}
+
+	if guruID != nil {
+		pengganti, err := s.guruRepo.GetByID(ctx, *guruID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, ErrGuruTidakDitemukan
+			}
+			return nil, err
+		}
+		notifikasi = append(notifikasi,
+			models.Notifikasi{
+				Role:   "guru",
+				UserID: pengganti.ID,
+				Judul:  "Tugas guru pengganti",
+				Pesan:  "Anda menggantikan " + sesi.NamaGuru + " mengajar " + pertemuan + ".",
+			},
+			models.Notifikasi{
+				Role:   "guru",
+				UserID: sesi.GuruID,
+				Judul:  "Guru pengganti ditetapkan",
+				Pesan:  pengganti.Nama + " menggantikan Anda mengajar " + pertemuan + ".",
+			},
+		)
+		untukKelas = &repositories.NotifikasiKelas{
+			KelasID: sesi.KelasID,
+			Judul:   "Guru pengganti",
+			Pesan:   sesi.NamaMapel + " pada " + waktu + " diajar oleh " + pengganti.Nama + ".",
+		}
+	}
+
+	if err := s.penggantiRepo.Tetapkan(ctx, sesiID, guruID, notifikasi, untukKelas); err != nil {
+		return nil, err
+	}
+	return s.penggantiRepo.GetSesiByID(ctx, sesiID)
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/xuri/excelize/v2"
+)
+
+var (
+	// ErrFormatImpor menandakan file impor tidak dapat dibaca atau kolom wajibnya tidak ditemukan.
+	ErrFormatImpor = errors.New("format file impor tidak valid")
+	// ErrImporKurvaAktif dikembalikan saat tugas/quiz sedang dikurva. Nilai impor adalah nilai mentah
+	// yang tidak sebanding dengan nilai hasil kurva dan tidak dapat dipulihkan saat kurva dibatalkan,
+	// sehingga kurva harus dibatalkan lebih dulu.
+	ErrImporKurvaAktif = errors.New("tugas/quiz sedang dikurva")
+	// ErrSetelahImpor menandakan nilai impor sudah tersimpan, tetapi remedial atau rekap gagal
+	// diperbarui.
+	ErrSetelahImpor = errors.New("nilai impor tersimpan, tetapi remedial atau rekap gagal diperbarui")
+)
+
+// BarisImpor adalah hasil validasi satu baris file impor beserta perbandingannya dengan nilai saat ini.
+// Status bernilai "baru", "ubah", "sama", "dilewati" (nilai kosong), atau "error".
+type BarisImpor struct {
+	Baris     int      `json:"baris"`
+	SiswaID   *int     `json:"siswa_id,omitempty"`
+	NamaSiswa string   `json:"nama_siswa,omitempty"`
+	NilaiLama *float64 `json:"nilai_lama"`
+	NilaiBaru *float64 `json:"nilai_baru"`
+	Feedback  *string  `json:"feedback,omitempty"`
+	Status    string   `json:"status"`
+	Error     string   `json:"error,omitempty"`
+}
+
+// HasilImpor adalah ringkasan dry-run impor nilai.
+type HasilImpor struct {
+	Baris       []BarisImpor `json:"baris"`
+	JumlahBaru  int          `json:"jumlah_baru"`
+	JumlahUbah  int          `json:"jumlah_ubah"`
+	JumlahSama  int          `json:"jumlah_sama"`
+	JumlahError int          `json:"jumlah_error"`
+}
+
+// ImporNilaiService membaca file CSV/XLSX berisi nilai, memvalidasinya, dan menerapkannya ke tugas/quiz
+// melalui jalur penulisan nilai yang sama dengan penilaian guru.
+type ImporNilaiService struct {
+	imporRepo    repositories.ImporNilaiRepository
+	siswaRepo    repositories.SiswaRepository
+	kurvaRepo    repositories.KurvaNilaiRepository
+	nilaiService *NilaiService
+}
+
+func NewImporNilaiService(
+	imporRepo repositories.ImporNilaiRepository,
+	siswaRepo repositories.SiswaRepository,
+	kurvaRepo repositories.KurvaNilaiRepository,
+	nilaiService *NilaiService,
+) *ImporNilaiService {
+	return &ImporNilaiService{
+		imporRepo:    imporRepo,
+		siswaRepo:    siswaRepo,
+		kurvaRepo:    kurvaRepo,
+		nilaiService: nilaiService,
+	}
+}
+
+// BacaSpreadsheet membaca seluruh baris dari file CSV atau XLSX (sheet pertama).
+func BacaSpreadsheet(r io.Reader, namaFile string) ([][]string, error) {
+	namaFile = strings.ToLower(namaFile)
+	switch {
+	case strings.HasSuffix(namaFile, ".csv"):
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.TrimLeadingSpace = true
+		rows, err := cr.ReadAll()
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormatImpor, err)
+		}
+		if len(rows) > 0 && len(rows[0]) > 0 {
+			rows[0][0] = strings.TrimPrefix(rows[0][0], "\ufeff")
+		}
+		return rows, nil
+	case strings.HasSuffix(namaFile, ".xlsx"):
+		f, err := excelize.OpenReader(r)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormatImpor, err)
+		}
+		defer f.Close()
+		rows, err := f.GetRows(f.GetSheetName(0))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrFormatImpor, err)
+		}
+		return rows, nil
+	default:
+		return nil, fmt.Errorf("%w: hanya file .csv atau .xlsx yang didukung", ErrFormatImpor)
+	}
+}
+
+type kolomImpor struct {
+	siswaID  int
+	email    int
+	nama     int
+	nilai    int
+	feedback int
+}
+
+// cariKolomImpor menentukan posisi kolom dari baris header. Kolom nilai dapat bernama "Nilai"
+// atau memakai judul kolom hasil ekspor gradebook, misalnya "Tugas: PR 1".
+func cariKolomImpor(header []string, judulKolomItem string) (kolomImpor, error) {
+	kolom := kolomImpor{siswaID: -1, email: -1, nama: -1, nilai: -1, feedback: -1}
+	judulItem := normalisasiHeader(judulKolomItem)
+	for i, judul := range header {
+		switch normalisasiHeader(judul) {
+		case "idsiswa", "siswaid":
+			kolom.siswaID = i
+		case "email":
+			kolom.email = i
+		case "nama", "namasiswa":
+			kolom.nama = i
+		case "nilai", judulItem:
+			kolom.nilai = i
+		case "feedback", "catatan":
+			kolom.feedback = i
+		}
+	}
+	if kolom.nilai == -1 {
+		return kolom, fmt.Errorf("%w: kolom 'nilai' atau '%s' tidak ditemukan", ErrFormatImpor, judulKolomItem)
+	}
+	if kolom.siswaID == -1 && kolom.email == -1 {
+		return kolom, fmt.Errorf("%w: kolom 'id siswa' atau 'email' tidak ditemukan", ErrFormatImpor)
+	}
+	return kolom, nil
+}
+
+func normalisasiHeader(judul string) string {
+	judul = strings.ToLower(strings.TrimSpace(judul))
+	return strings.NewReplacer(" ", "", "_", "", "-", "").Replace(judul)
+}
+
+func ambilSel(row []string, i int) string {
+	if i < 0 || i >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[i])
+}
+
+// Pratinjau memvalidasi baris file terhadap siswa di kelas tugas/quiz dan membandingkannya
+// dengan nilai yang tersimpan, tanpa mengubah data apa pun.
+func (s *ImporNilaiService) Pratinjau(ctx context.Context, jenis string, itemID int, judulItem string, kelasID int, rows [][]string) (*HasilImpor, error) {
+	if err := s.pastikanTanpaKurva(ctx, jenis, itemID); err != nil {
+		return nil, err
+	}
+	if len(rows) < 2 {
+		return nil, fmt.Errorf("%w: file tidak berisi data nilai", ErrFormatImpor)
+	}
+	kolom, err := cariKolomImpor(rows[0], JudulKolomGradebook(jenis, judulItem))
+	if err != nil {
+		return nil, err
+	}
+
+	siswaKelas, err := s.siswaRepo.GetAllByKelasID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	siswaByID := make(map[int]int)
+	siswaByEmail := make(map[string]int)
+	for i, siswa := range siswaKelas {
+		siswaByID[siswa.ID] = i
+		siswaByEmail[strings.ToLower(siswa.Email)] = i
+	}
+
+	nilaiSaatIni, err := s.imporRepo.GetNilaiByItemID(ctx, jenis, itemID)
+	if err != nil {
+		return nil, err
+	}
+	nilaiLama := make(map[int]*float64)
+	for _, item := range nilaiSaatIni {
+		nilaiLama[item.SiswaID] = item.Nilai
+	}
+
+	hasil := &HasilImpor{Baris: []BarisImpor{}}
+	sudahAda := make(map[int]int)
+	for i, row := range rows[1:] {
+		baris := BarisImpor{Baris: i + 2}
+
+		idSel, emailSel, namaSel := ambilSel(row, kolom.siswaID), ambilSel(row, kolom.email), ambilSel(row, kolom.nama)
+		nilaiSel := ambilSel(row, kolom.nilai)
+		if idSel == "" && emailSel == "" && namaSel == "" && nilaiSel == "" {
+			continue
+		}
+
+		idx := -1
+		switch {
+		case idSel != "":
+			id, err := strconv.Atoi(idSel)
+			if err != nil {
+				baris.Error = fmt.Sprintf("ID siswa '%s' tidak valid.", idSel)
+				break
+			}
+			pos, ok := siswaByID[id]
+			if !ok {
+				baris.Error = fmt.Sprintf("Siswa dengan ID %d tidak terdaftar di kelas ini.", id)
+				break
+			}
+			idx = pos
+		case emailSel != "":
+			pos, ok := siswaByEmail[strings.ToLower(emailSel)]
+			if !ok {
+				baris.Error = fmt.Sprintf("Siswa dengan email %s tidak terdaftar di kelas ini.", emailSel)
+				break
+			}
+			idx = pos
+		default:
+			baris.Error = "ID siswa atau email wajib diisi."
+		}
+
+		if idx >= 0 {
+			siswa := siswaKelas[idx]
+			baris.SiswaID = &siswa.ID
+			baris.NamaSiswa = siswa.Nama
+			baris.NilaiLama = nilaiLama[siswa.ID]
+
+			if namaSel != "" && !strings.EqualFold(namaSel, siswa.Nama) {
+				baris.Error = fmt.Sprintf("Nama '%s' tidak sesuai dengan data siswa (%s).", namaSel, siswa.Nama)
+			} else if barisSebelumnya, ok := sudahAda[siswa.ID]; ok {
+				baris.Error = fmt.Sprintf("Siswa sudah muncul di baris %d.", barisSebelumnya)
+			}
+			sudahAda[siswa.ID] = baris.Baris
+		}
+
+		if baris.Error == "" && nilaiSel != "" {
+			nilai, err := strconv.ParseFloat(strings.Replace(nilaiSel, ",", ".", 1), 64)
+			if err != nil || math.IsNaN(nilai) {
+				baris.Error = fmt.Sprintf("Nilai '%s' bukan angka.", nilaiSel)
+			} else if nilai < 0 || nilai > 100 {
+				baris.Error = fmt.Sprintf("Nilai %s di luar rentang 0-100.", nilaiSel)
+			} else {
+				baris.NilaiBaru = &nilai
+			}
+		}
+
+		if feedback := ambilSel(row, kolom.feedback); feedback != "" && jenis == "tugas" {
+			baris.Feedback = &feedback
+		}
+
+		switch {
+		case baris.Error != "":
+			baris.Status = "error"
+			hasil.JumlahError++
+		case baris.NilaiBaru == nil:
+			baris.Status = "dilewati"
+		case baris.NilaiLama == nil:
+			baris.Status = "baru"
+			hasil.JumlahBaru++
+		case *baris.NilaiLama == *baris.NilaiBaru && baris.Feedback == nil:
+			baris.Status = "sama"
+			hasil.JumlahSama++
+		default:
+			baris.Status = "ubah"
+			hasil.JumlahUbah++
+		}
+		hasil.Baris = append(hasil.Baris, baris)
+	}
+
+	return hasil, nil
+}
+
+// Terapkan menulis baris berstatus "baru" dan "ubah" dalam satu transaksi, lalu menjalankan
+// NilaiService.SetelahNilaiBerubah. Pemanggil wajib memastikan hasil pratinjau tidak mengandung error.
+// Kegagalan setelah nilai tersimpan dikembalikan sebagai ErrSetelahImpor.
+func (s *ImporNilaiService) Terapkan(ctx context.Context, jenis string, itemID int, hasil *HasilImpor) error {
+	if err := s.pastikanTanpaKurva(ctx, jenis, itemID); err != nil {
+		return err
+	}
+
+	var nilai []repositories.NilaiImpor
+	for _, baris := range hasil.Baris {
+		if baris.Status != "baru" && baris.Status != "ubah" {
+			continue
+		}
+		nilai = append(nilai, repositories.NilaiImpor{
+			SiswaID:  *baris.SiswaID,
+			Nilai:    *baris.NilaiBaru,
+			Feedback: baris.Feedback,
+		})
+	}
+	if len(nilai) == 0 {
+		return nil
+	}
+	if err := s.imporRepo.Terapkan(ctx, jenis, itemID, nilai); err != nil {
+		return err
+	}
+	if err := s.nilaiService.SetelahNilaiBerubah(ctx, jenis, itemID); err != nil {
+		return fmt.Errorf("%w: %v", ErrSetelahImpor, err)
+	}
+	return nil
+}
+
+func (s *ImporNilaiService) pastikanTanpaKurva(ctx context.Context, jenis string, itemID int) error {
+	_, err := s.kurvaRepo.GetAktif(ctx, jenis, itemID)
+	if err == nil {
+		return ErrImporKurvaAktif
+	}
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	return err
+}
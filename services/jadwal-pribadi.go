@@ -0,0 +1,198 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"context"
+	"time"
+)
+
+var namaHari = []string{"Minggu", "Senin", "Selasa", "Rabu", "Kamis", "Jumat", "Sabtu"}
+
+type SlotPribadi struct {
+	JadwalID        int     `json:"jadwal_id"`
+	KelasID         int     `json:"kelas_id"`
+	NamaKelas       string  `json:"nama_kelas"`
+	MataPelajaranID int     `json:"mata_pelajaran_id"`
+	NamaMapel       string  `json:"nama_mapel"`
+	GuruID          *int    `json:"guru_id"`
+	NamaGuru        *string `json:"nama_guru"`
+	Ruang           *string `json:"ruang"`
+	JamMulai        string  `json:"jam_mulai"`
+	JamSelesai      string  `json:"jam_selesai"`
+}
+
+type AgendaPribadi struct {
+	Jenis           string     `json:"jenis"`
+	ItemID          int        `json:"item_id"`
+	Judul           string     `json:"judul"`
+	KelasID         int        `json:"kelas_id"`
+	MataPelajaranID int        `json:"mata_pelajaran_id"`
+	Waktu           time.Time  `json:"waktu"`
+	WaktuSelesai    *time.Time `json:"waktu_selesai,omitempty"`
+}
+
+// HariPribadi adalah isi jadwal satu tanggal. Pada hari libur, Libur berisi nama liburnya dan slot
+// pelajaran dikosongkan; deadline tugas dan quiz tetap ditampilkan.
+type HariPribadi struct {
+	Tanggal string          `json:"tanggal"`
+	Hari    string          `json:"hari"`
+	Libur   *string         `json:"libur"`
+	Slot    []SlotPribadi   `json:"slot"`
+	Agenda  []AgendaPribadi `json:"agenda"`
+}
+
+// JadwalPribadi adalah jadwal satu pekan (Senin-Minggu) yang memuat tanggal acuan, ditambah
+// ringkasan hari acuan itu sendiri untuk tampilan "hari ini".
+type JadwalPribadi struct {
+	Tanggal       string        `json:"tanggal"`
+	MingguMulai   string        `json:"minggu_mulai"`
+	MingguSelesai string        `json:"minggu_selesai"`
+	HariIni       HariPribadi   `json:"hari_ini"`
+	Minggu        []HariPribadi `json:"minggu"`
+}
+
+// JadwalPribadiService menyusun jadwal mingguan siswa atau guru beserta deadline tugas dan quiz.
+type JadwalPribadiService struct {
+	pribadiRepo  repositories.JadwalPribadiRepository
+	siswaRepo    repositories.SiswaRepository
+	kalenderRepo repositories.KalenderAkademikRepository
+}
+
+func NewJadwalPribadiService(
+	pribadiRepo repositories.JadwalPribadiRepository,
+	siswaRepo repositories.SiswaRepository,
+	kalenderRepo repositories.KalenderAkademikRepository,
+) *JadwalPribadiService {
+	return &JadwalPribadiService{
+		pribadiRepo:  pribadiRepo,
+		siswaRepo:    siswaRepo,
+		kalenderRepo: kalenderRepo,
+	}
+}
+
+// GetJadwalSiswa menyusun jadwal kelas siswa pada pekan yang memuat tanggal. Siswa yang belum
+// terdaftar di kelas mendapat jadwal kosong.
+func (s *JadwalPribadiService) GetJadwalSiswa(ctx context.Context, siswaID int, tanggal time.Time) (*JadwalPribadi, error) {
+	siswa, err := s.siswaRepo.GetByID(ctx, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	mulai, selesai := rentangPekan(tanggal)
+	libur, err := hariLibur(ctx, s.kalenderRepo, mulai, selesai.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	if siswa.KelasID == nil {
+		return susunJadwalPribadi(tanggal, mulai, nil, nil, libur), nil
+	}
+
+	slot, err := s.pribadiRepo.GetSlotByKelasID(ctx, *siswa.KelasID)
+	if err != nil {
+		return nil, err
+	}
+	agenda, err := s.pribadiRepo.GetAgendaSiswa(ctx, *siswa.KelasID, siswaID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+	return susunJadwalPribadi(tanggal, mulai, slot, agenda, libur), nil
+}
+
+// GetJadwalGuru menyusun slot mengajar guru di semua kelas pada pekan yang memuat tanggal.
+func (s *JadwalPribadiService) GetJadwalGuru(ctx context.Context, guruID int, tanggal time.Time) (*JadwalPribadi, error) {
+	mulai, selesai := rentangPekan(tanggal)
+	libur, err := hariLibur(ctx, s.kalenderRepo, mulai, selesai.AddDate(0, 0, -1))
+	if err != nil {
+		return nil, err
+	}
+	slot, err := s.pribadiRepo.GetSlotByGuruID(ctx, guruID)
+	if err != nil {
+		return nil, err
+	}
+	agenda, err := s.pribadiRepo.GetAgendaGuru(ctx, guruID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+	return susunJadwalPribadi(tanggal, mulai, slot, agenda, libur), nil
+}
+
+// rentangPekan mengembalikan awal Senin pekan yang memuat tanggal dan awal Senin berikutnya.
+func rentangPekan(tanggal time.Time) (time.Time, time.Time) {
+	awalHari := time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), 0, 0, 0, 0, tanggal.Location())
+	selisih := (int(awalHari.Weekday()) + 6) % 7
+	mulai := awalHari.AddDate(0, 0, -selisih)
+	return mulai, mulai.AddDate(0, 0, 7)
+}
+
+// NamaHari mengembalikan nama hari dalam bahasa Indonesia, sama dengan nilai Hari pada jadwal.
+func NamaHari(t time.Time) string {
+	return namaHari[t.Weekday()]
+}
+
+// susunJadwalPribadi membagi slot mingguan dan agenda ke tujuh hari mulai dari Senin. Quiz yang
+// jendelanya melewati beberapa hari dicantumkan di setiap hari tersebut. Slot pelajaran tidak
+// dicantumkan pada tanggal yang ada di peta libur.
+func susunJadwalPribadi(tanggal time.Time, mulai time.Time, slot []repositories.SlotJadwal, agenda []repositories.AgendaItem, libur map[string]string) *JadwalPribadi {
+	jadwal := &JadwalPribadi{
+		Tanggal:       tanggal.Format("2006-01-02"),
+		MingguMulai:   mulai.Format("2006-01-02"),
+		MingguSelesai: mulai.AddDate(0, 0, 6).Format("2006-01-02"),
+		Minggu:        []HariPribadi{},
+	}
+
+	for i := 0; i < 7; i++ {
+		awal := mulai.AddDate(0, 0, i)
+		akhir := awal.AddDate(0, 0, 1)
+		hari := HariPribadi{
+			Tanggal: awal.Format("2006-01-02"),
+			Hari:    NamaHari(awal),
+			Slot:    []SlotPribadi{},
+			Agenda:  []AgendaPribadi{},
+		}
+		if nama, ok := libur[hari.Tanggal]; ok {
+			hari.Libur = &nama
+		}
+
+		for _, item := range slot {
+			if hari.Libur != nil || item.Hari != hari.Hari {
+				continue
+			}
+			hari.Slot = append(hari.Slot, SlotPribadi{
+				JadwalID:        item.ID,
+				KelasID:         item.KelasID,
+				NamaKelas:       item.NamaKelas,
+				MataPelajaranID: item.MataPelajaranID,
+				NamaMapel:       item.NamaMapel,
+				GuruID:          item.GuruID,
+				NamaGuru:        item.NamaGuru,
+				Ruang:           item.Ruang,
+				JamMulai:        item.JamMulai.Format("15:04"),
+				JamSelesai:      item.JamSelesai.Format("15:04"),
+			})
+		}
+
+		for _, item := range agenda {
+			berakhir := item.Waktu
+			if item.WaktuSelesai != nil {
+				berakhir = *item.WaktuSelesai
+			}
+			if !item.Waktu.Before(akhir) || berakhir.Before(awal) {
+				continue
+			}
+			hari.Agenda = append(hari.Agenda, AgendaPribadi{
+				Jenis:           item.Jenis,
+				ItemID:          item.ItemID,
+				Judul:           item.Judul,
+				KelasID:         item.KelasID,
+				MataPelajaranID: item.MataPelajaranID,
+				Waktu:           item.Waktu,
+				WaktuSelesai:    item.WaktuSelesai,
+			})
+		}
+
+		if hari.Tanggal == jadwal.Tanggal {
+			jadwal.HariIni = hari
+		}
+		jadwal.Minggu = append(jadwal.Minggu, hari)
+	}
+	return jadwal
+}
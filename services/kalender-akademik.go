@@ -0,0 +1,178 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrKalenderAkademik = errors.New("data kalender akademik tidak valid")
+
+// StatusTanggal merangkum kedudukan satu tanggal di kalender akademik. DiLuarSemester hanya bernilai
+// true jika tahun ajaran sudah diatur tetapi tidak ada semester yang memuat tanggal tersebut.
+// HariEfektif bernilai true jika tanggal itu bukan hari Minggu, bukan hari libur, dan tidak di
+// luar semester.
+type StatusTanggal struct {
+	Tanggal        time.Time
+	Semester       *models.Semester
+	DiLuarSemester bool
+	Libur          []models.AgendaAkademik
+	Ujian          []models.AgendaAkademik
+	HariEfektif    bool
+}
+
+// KalenderAkademikService memvalidasi tahun ajaran, semester, dan agenda akademik, serta menjadi
+// rujukan hari libur bagi fitur berbasis jadwal.
+type KalenderAkademikService struct {
+	kalenderRepo repositories.KalenderAkademikRepository
+}
+
+func NewKalenderAkademikService(kalenderRepo repositories.KalenderAkademikRepository) *KalenderAkademikService {
+	return &KalenderAkademikService{kalenderRepo: kalenderRepo}
+}
+
+func (s *KalenderAkademikService) SimpanTahunAjaran(ctx context.Context, tahunAjaran *models.TahunAjaran) error {
+	if !tahunAjaran.TanggalSelesai.After(tahunAjaran.TanggalMulai) {
+		return fmt.Errorf("%w: tanggal selesai tahun ajaran harus setelah tanggal mulai", ErrKalenderAkademik)
+	}
+	if tahunAjaran.ID == 0 {
+		return s.kalenderRepo.CreateTahunAjaran(ctx, tahunAjaran)
+	}
+	return s.kalenderRepo.UpdateTahunAjaran(ctx, tahunAjaran)
+}
+
+// SimpanSemester memastikan semester berada di dalam rentang tahun ajarannya dan tidak beririsan
+// dengan semester lain pada tahun ajaran yang sama.
+func (s *KalenderAkademikService) SimpanSemester(ctx context.Context, semester *models.Semester) error {
+	if semester.TanggalSelesai.Before(semester.TanggalMulai) {
+		return fmt.Errorf("%w: tanggal selesai semester sebelum tanggal mulai", ErrKalenderAkademik)
+	}
+
+	if semester.ID != 0 {
+		lama, err := s.kalenderRepo.GetSemesterByID(ctx, semester.ID)
+		if err != nil {
+			return err
+		}
+		semester.TahunAjaranID = lama.TahunAjaranID
+	}
+	tahunAjaran, err := s.kalenderRepo.GetTahunAjaranByID(ctx, semester.TahunAjaranID)
+	if err != nil {
+		return err
+	}
+	if semester.TanggalMulai.Before(tahunAjaran.TanggalMulai) || semester.TanggalSelesai.After(tahunAjaran.TanggalSelesai) {
+		return fmt.Errorf("%w: semester harus berada di dalam tahun ajaran %s", ErrKalenderAkademik, tahunAjaran.Nama)
+	}
+
+	semesterLain, err := s.kalenderRepo.GetSemesterByTahunAjaranID(ctx, semester.TahunAjaranID)
+	if err != nil {
+		return err
+	}
+	for _, item := range semesterLain {
+		if item.ID == semester.ID {
+			continue
+		}
+		if !item.TanggalMulai.After(semester.TanggalSelesai) && !item.TanggalSelesai.Before(semester.TanggalMulai) {
+			return fmt.Errorf("%w: beririsan dengan semester %s", ErrKalenderAkademik, item.Nama)
+		}
+	}
+
+	if semester.ID == 0 {
+		return s.kalenderRepo.CreateSemester(ctx, semester)
+	}
+	return s.kalenderRepo.UpdateSemester(ctx, semester)
+}
+
+func (s *KalenderAkademikService) SimpanAgenda(ctx context.Context, agenda *models.AgendaAkademik) error {
+	if agenda.TanggalSelesai.Before(agenda.TanggalMulai) {
+		return fmt.Errorf("%w: tanggal selesai agenda sebelum tanggal mulai", ErrKalenderAkademik)
+	}
+	if agenda.ID == 0 {
+		return s.kalenderRepo.CreateAgenda(ctx, agenda)
+	}
+	return s.kalenderRepo.UpdateAgenda(ctx, agenda)
+}
+
+// GetStatusTanggal menentukan apakah tanggal merupakan hari efektif beserta libur, pekan ujian, dan
+// semester yang memuatnya.
+func (s *KalenderAkademikService) GetStatusTanggal(ctx context.Context, tanggal time.Time) (*StatusTanggal, error) {
+	agenda, err := s.kalenderRepo.GetAgenda(ctx, tanggal, tanggal, "")
+	if err != nil {
+		return nil, err
+	}
+	status := &StatusTanggal{
+		Tanggal: tanggal,
+	}
+	for _, item := range agenda {
+		if item.Libur() {
+			status.Libur = append(status.Libur, item)
+		} else if item.Jenis == models.AgendaUjian {
+			status.Ujian = append(status.Ujian, item)
+		}
+	}
+
+	semester, err := s.kalenderRepo.GetSemesterPadaTanggal(ctx, tanggal)
+	switch {
+	case err == nil:
+		status.Semester = semester
+	case err == sql.ErrNoRows:
+		tahunAjaran, err := s.kalenderRepo.GetAllTahunAjaran(ctx)
+		if err != nil {
+			return nil, err
+		}
+		status.DiLuarSemester = len(tahunAjaran) > 0
+	default:
+		return nil, err
+	}
+
+	status.HariEfektif = tanggal.Weekday() != time.Sunday && len(status.Libur) == 0 && !status.DiLuarSemester
+	return status, nil
+}
+
+// PeriksaDeadline mengembalikan peringatan untuk deadline yang jatuh pada hari libur, pekan ujian,
+// atau di luar semester. Deadline tetap boleh disimpan; peringatan hanya untuk guru.
+func (s *KalenderAkademikService) PeriksaDeadline(ctx context.Context, deadline time.Time) ([]string, error) {
+	deadline = deadline.In(time.Local)
+	status, err := s.GetStatusTanggal(ctx, deadline)
+	if err != nil {
+		return nil, err
+	}
+
+	peringatan := []string{}
+	for _, item := range status.Libur {
+		peringatan = append(peringatan, "Deadline jatuh pada hari libur: "+item.Nama+".")
+	}
+	for _, item := range status.Ujian {
+		peringatan = append(peringatan, "Deadline jatuh pada pekan ujian: "+item.Nama+".")
+	}
+	if deadline.Weekday() == time.Sunday {
+		peringatan = append(peringatan, "Deadline jatuh pada hari Minggu.")
+	}
+	if status.DiLuarSemester {
+		peringatan = append(peringatan, "Deadline berada di luar semester yang terdaftar.")
+	}
+	return peringatan, nil
+}
+
+// hariLibur mengembalikan nama libur untuk setiap tanggal libur dalam rentang [mulai, selesai],
+// dengan kunci tanggal berformat YYYY-MM-DD. Dipakai fitur berbasis jadwal untuk melewati hari libur.
+func hariLibur(ctx context.Context, kalenderRepo repositories.KalenderAkademikRepository, mulai time.Time, selesai time.Time) (map[string]string, error) {
+	agenda, err := kalenderRepo.GetAgenda(ctx, mulai, selesai, "libur")
+	if err != nil {
+		return nil, err
+	}
+
+	libur := map[string]string{}
+	for _, item := range agenda {
+		for tanggal := item.TanggalMulai; !tanggal.After(item.TanggalSelesai); tanggal = tanggal.AddDate(0, 0, 1) {
+			kunci := tanggal.Format("2006-01-02")
+			if _, ada := libur[kunci]; !ada {
+				libur[kunci] = item.Nama
+			}
+		}
+	}
+	return libur, nil
+}
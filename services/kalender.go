@@ -0,0 +1,259 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Rentang agenda tugas/quiz yang dimuat ke feed, relatif terhadap waktu feed diambil.
+const (
+	feedHariLalu  = 30
+	feedHariDepan = 365
+)
+
+var bydayHari = map[string]string{
+	"Senin":  "MO",
+	"Selasa": "TU",
+	"Rabu":   "WE",
+	"Kamis":  "TH",
+	"Jumat":  "FR",
+	"Sabtu":  "SA",
+	"Minggu": "SU",
+}
+
+// KalenderService mengelola token feed ICS dan menyusun isi feed jadwal, deadline tugas, dan
+// jendela quiz milik seorang siswa atau guru.
+type KalenderService struct {
+	tokenRepo    repositories.TokenKalenderRepository
+	pribadiRepo  repositories.JadwalPribadiRepository
+	siswaRepo    repositories.SiswaRepository
+	kalenderRepo repositories.KalenderAkademikRepository
+}
+
+func NewKalenderService(
+	tokenRepo repositories.TokenKalenderRepository,
+	pribadiRepo repositories.JadwalPribadiRepository,
+	siswaRepo repositories.SiswaRepository,
+	kalenderRepo repositories.KalenderAkademikRepository,
+) *KalenderService {
+	return &KalenderService{
+		tokenRepo:    tokenRepo,
+		pribadiRepo:  pribadiRepo,
+		siswaRepo:    siswaRepo,
+		kalenderRepo: kalenderRepo,
+	}
+}
+
+// BuatToken membuat token feed baru untuk pengguna dan mengembalikan token aslinya. Token lama
+// milik pengguna yang sama otomatis tidak berlaku.
+func (s *KalenderService) BuatToken(ctx context.Context, role string, userID int) (string, error) {
+	acak := make([]byte, 32)
+	if _, err := rand.Read(acak); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(acak)
+
+	if err := s.tokenRepo.Simpan(ctx, &models.TokenKalender{
+		Role:      role,
+		UserID:    userID,
+		TokenHash: hashToken(token),
+	}); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// GetFeed menyusun feed ICS milik pemilik token. Mengembalikan sql.ErrNoRows jika token tidak
+// dikenal atau sudah dicabut.
+func (s *KalenderService) GetFeed(ctx context.Context, token string) ([]byte, error) {
+	pemilik, err := s.tokenRepo.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, err
+	}
+
+	sekarang := time.Now()
+	mulai := sekarang.AddDate(0, 0, -feedHariLalu)
+	selesai := sekarang.AddDate(0, 0, feedHariDepan)
+
+	var slot []repositories.SlotJadwal
+	var agenda []repositories.AgendaItem
+	if pemilik.Role == "guru" {
+		if slot, err = s.pribadiRepo.GetSlotByGuruID(ctx, pemilik.UserID); err != nil {
+			return nil, err
+		}
+		if agenda, err = s.pribadiRepo.GetAgendaGuru(ctx, pemilik.UserID, mulai, selesai); err != nil {
+			return nil, err
+		}
+	} else {
+		siswa, err := s.siswaRepo.GetByID(ctx, pemilik.UserID)
+		if err != nil {
+			return nil, err
+		}
+		if siswa.KelasID != nil {
+			if slot, err = s.pribadiRepo.GetSlotByKelasID(ctx, *siswa.KelasID); err != nil {
+				return nil, err
+			}
+			if agenda, err = s.pribadiRepo.GetAgendaSiswa(ctx, *siswa.KelasID, siswa.ID, mulai, selesai); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Slot jadwal hanya berulang sampai akhir semester berjalan dan melewati hari libur di dalamnya.
+	semester, err := s.kalenderRepo.GetSemesterPadaTanggal(ctx, sekarang)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	var libur map[string]string
+	if semester != nil {
+		if libur, err = hariLibur(ctx, s.kalenderRepo, semester.TanggalMulai, semester.TanggalSelesai); err != nil {
+			return nil, err
+		}
+	}
+
+	return SusunICS(sekarang, semester, libur, slot, agenda), nil
+}
+
+func hashToken(token string) string {
+	jumlah := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(jumlah[:])
+}
+
+// SusunICS menulis kalender iCalendar (RFC 5545). Slot jadwal menjadi acara berulang mingguan
+// dengan waktu lokal mengambang (tanpa zona) sejak pekan slot dibuat atau awal semester, berakhir
+// (UNTIL) di akhir semester, dan tanggal pada libur dikecualikan (EXDATE). Tanpa semester berjalan,
+// slot jadwal tidak ditulis. Deadline tugas dan jendela quiz ditulis dalam UTC.
+func SusunICS(sekarang time.Time, semester *models.Semester, libur map[string]string, slot []repositories.SlotJadwal, agenda []repositories.AgendaItem) []byte {
+	var b strings.Builder
+	stamp := formatICSUTC(sekarang)
+
+	tulisBarisICS(&b, "BEGIN:VCALENDAR")
+	tulisBarisICS(&b, "VERSION:2.0")
+	tulisBarisICS(&b, "PRODID:-//be-pui//Jadwal//ID")
+	tulisBarisICS(&b, "CALSCALE:GREGORIAN")
+	tulisBarisICS(&b, "METHOD:PUBLISH")
+	tulisBarisICS(&b, "X-WR-CALNAME:Jadwal Sekolah")
+
+	var tanggalLibur []string
+	for kunci := range libur {
+		tanggalLibur = append(tanggalLibur, kunci)
+	}
+	sort.Strings(tanggalLibur)
+
+	for _, item := range slot {
+		byday, ok := bydayHari[item.Hari]
+		if !ok || semester == nil {
+			continue
+		}
+		acuan := item.Created
+		if acuan.Before(semester.TanggalMulai) {
+			acuan = semester.TanggalMulai
+		}
+		tanggal := tanggalHariPertama(acuan, item.Hari)
+		akhirSemester := time.Date(semester.TanggalSelesai.Year(), semester.TanggalSelesai.Month(), semester.TanggalSelesai.Day(), 23, 59, 59, 0, time.Local)
+		if tanggal.After(akhirSemester) {
+			continue
+		}
+		mulai := gabungTanggalJam(tanggal, item.JamMulai)
+		selesai := gabungTanggalJam(tanggal, item.JamSelesai)
+
+		var exdate []string
+		for _, kunci := range tanggalLibur {
+			hari, err := time.ParseInLocation("2006-01-02", kunci, time.Local)
+			if err != nil || hari.Before(tanggal) || hari.After(akhirSemester) || NamaHari(hari) != item.Hari {
+				continue
+			}
+			exdate = append(exdate, gabungTanggalJam(hari, item.JamMulai).Format("20060102T150405"))
+		}
+
+		tulisBarisICS(&b, "BEGIN:VEVENT")
+		tulisBarisICS(&b, fmt.Sprintf("UID:jadwal-%d@be-pui", item.ID))
+		tulisBarisICS(&b, "DTSTAMP:"+stamp)
+		tulisBarisICS(&b, "DTSTART:"+mulai.Format("20060102T150405"))
+		tulisBarisICS(&b, "DTEND:"+selesai.Format("20060102T150405"))
+		tulisBarisICS(&b, "RRULE:FREQ=WEEKLY;BYDAY="+byday+";UNTIL="+akhirSemester.Format("20060102T150405"))
+		if len(exdate) > 0 {
+			tulisBarisICS(&b, "EXDATE:"+strings.Join(exdate, ","))
+		}
+		tulisBarisICS(&b, "SUMMARY:"+escapeICS(item.NamaMapel+" - "+item.NamaKelas))
+		if item.Ruang != nil && *item.Ruang != "" {
+			tulisBarisICS(&b, "LOCATION:"+escapeICS(*item.Ruang))
+		}
+		if item.NamaGuru != nil {
+			tulisBarisICS(&b, "DESCRIPTION:"+escapeICS("Guru: "+*item.NamaGuru))
+		}
+		tulisBarisICS(&b, "END:VEVENT")
+	}
+
+	for _, item := range agenda {
+		judul := "Deadline tugas: " + item.Judul
+		if item.Jenis == "quiz" {
+			judul = "Quiz: " + item.Judul
+		}
+
+		tulisBarisICS(&b, "BEGIN:VEVENT")
+		tulisBarisICS(&b, fmt.Sprintf("UID:%s-%d@be-pui", item.Jenis, item.ItemID))
+		tulisBarisICS(&b, "DTSTAMP:"+stamp)
+		tulisBarisICS(&b, "DTSTART:"+formatICSUTC(item.Waktu))
+		if item.WaktuSelesai != nil && item.WaktuSelesai.After(item.Waktu) {
+			tulisBarisICS(&b, "DTEND:"+formatICSUTC(*item.WaktuSelesai))
+		}
+		tulisBarisICS(&b, "SUMMARY:"+escapeICS(judul))
+		tulisBarisICS(&b, "END:VEVENT")
+	}
+
+	tulisBarisICS(&b, "END:VCALENDAR")
+	return []byte(b.String())
+}
+
+// tanggalHariPertama mengembalikan tanggal pertama bernama hari tersebut pada atau setelah acuan.
+func tanggalHariPertama(acuan time.Time, hari string) time.Time {
+	tanggal := time.Date(acuan.Year(), acuan.Month(), acuan.Day(), 0, 0, 0, 0, time.Local)
+	for i := 0; i < 7 && NamaHari(tanggal) != hari; i++ {
+		tanggal = tanggal.AddDate(0, 0, 1)
+	}
+	return tanggal
+}
+
+func gabungTanggalJam(tanggal time.Time, jam time.Time) time.Time {
+	return time.Date(tanggal.Year(), tanggal.Month(), tanggal.Day(), jam.Hour(), jam.Minute(), jam.Second(), 0, time.Local)
+}
+
+func formatICSUTC(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+func escapeICS(teks string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(teks)
+}
+
+// tulisBarisICS menulis satu baris konten dengan CRLF dan melipat baris yang lebih dari 75 oktet
+// tanpa memotong karakter UTF-8.
+func tulisBarisICS(b *strings.Builder, baris string) {
+	panjang := 0
+	for _, r := range baris {
+		ukuran := len(string(r))
+		if panjang+ukuran > 75 {
+			b.WriteString("\r\n ")
+			panjang = 1
+		}
+		b.WriteRune(r)
+		panjang += ukuran
+	}
+	b.WriteString("\r\n")
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+)
+
+// Metode kurva nilai yang didukung.
+const (
+	KurvaLinear  = "linear"
+	KurvaAkar    = "akar"
+	KurvaMinimum = "minimum"
+)
+
+var (
+	// ErrKurvaSudahAda menandakan tugas/quiz sudah memiliki kurva aktif yang harus dibatalkan dulu.
+	ErrKurvaSudahAda = errors.New("kurva nilai sudah diterapkan")
+	// ErrTanpaNilai menandakan belum ada nilai yang dapat dikurva.
+	ErrTanpaNilai = errors.New("belum ada nilai untuk dikurva")
+)
+
+type BarisKurva struct {
+	SiswaID     int     `json:"siswa_id"`
+	NamaSiswa   string  `json:"nama_siswa"`
+	NilaiMentah float64 `json:"nilai_mentah"`
+	NilaiKurva  float64 `json:"nilai_kurva"`
+}
+
+type RingkasanNilai struct {
+	RataRata       float64 `json:"rata_rata"`
+	NilaiTertinggi float64 `json:"nilai_tertinggi"`
+	NilaiTerendah  float64 `json:"nilai_terendah"`
+}
+
+type HasilKurva struct {
+	Metode    string         `json:"metode"`
+	Parameter *float64       `json:"parameter,omitempty"`
+	Sebelum   RingkasanNilai `json:"sebelum"`
+	Sesudah   RingkasanNilai `json:"sesudah"`
+	Baris     []BarisKurva   `json:"baris"`
+}
+
+// KurvaNilaiService menghitung, menerapkan, dan membatalkan kurva pada nilai satu tugas/quiz.
+type KurvaNilaiService struct {
+	kurvaRepo repositories.KurvaNilaiRepository
+}
+
+func NewKurvaNilaiService(kurvaRepo repositories.KurvaNilaiRepository) *KurvaNilaiService {
+	return &KurvaNilaiService{kurvaRepo: kurvaRepo}
+}
+
+// Pratinjau menghitung nilai hasil kurva untuk seluruh siswa yang sudah dinilai tanpa menyimpannya.
+// Mengembalikan ErrKurvaSudahAda jika kurva lain masih aktif, karena nilai saat ini bukan nilai mentah.
+func (s *KurvaNilaiService) Pratinjau(ctx context.Context, jenis string, itemID int, metode string, parameter *float64) (*HasilKurva, error) {
+	if _, err := s.kurvaRepo.GetAktif(ctx, jenis, itemID); err == nil {
+		return nil, ErrKurvaSudahAda
+	} else if err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	nilaiItem, err := s.kurvaRepo.GetNilaiItem(ctx, jenis, itemID)
+	if err != nil {
+		return nil, err
+	}
+	if len(nilaiItem) == 0 {
+		return nil, ErrTanpaNilai
+	}
+
+	mentah := make([]float64, len(nilaiItem))
+	for i, item := range nilaiItem {
+		mentah[i] = item.Nilai
+	}
+	dikurva := HitungKurva(metode, parameter, mentah)
+
+	hasil := &HasilKurva{
+		Metode:    metode,
+		Parameter: parameter,
+		Sebelum:   ringkasNilai(mentah),
+		Sesudah:   ringkasNilai(dikurva),
+		Baris:     []BarisKurva{},
+	}
+	for i, item := range nilaiItem {
+		hasil.Baris = append(hasil.Baris, BarisKurva{
+			SiswaID:     item.SiswaID,
+			NamaSiswa:   item.NamaSiswa,
+			NilaiMentah: item.Nilai,
+			NilaiKurva:  dikurva[i],
+		})
+	}
+	return hasil, nil
+}
+
+// Terapkan menyimpan hasil pratinjau: nilai mentah dicatat dan nilai siswa diganti nilai hasil kurva.
+func (s *KurvaNilaiService) Terapkan(ctx context.Context, jenis string, itemID int, guruID int, hasil *HasilKurva) (*models.KurvaNilai, error) {
+	kurva := &models.KurvaNilai{
+		Jenis:     jenis,
+		ItemID:    itemID,
+		Metode:    hasil.Metode,
+		Parameter: hasil.Parameter,
+		GuruID:    guruID,
+	}
+	nilai := make([]models.NilaiMentahKurva, len(hasil.Baris))
+	for i, baris := range hasil.Baris {
+		nilai[i] = models.NilaiMentahKurva{
+			SiswaID:     baris.SiswaID,
+			NilaiMentah: baris.NilaiMentah,
+			NilaiKurva:  baris.NilaiKurva,
+		}
+	}
+	if err := s.kurvaRepo.Terapkan(ctx, kurva, nilai); err != nil {
+		return nil, err
+	}
+	return kurva, nil
+}
+
+// Kembalikan membatalkan kurva aktif. Mengembalikan jumlah nilai yang dipulihkan dan nilai mentah
+// yang tidak dipulihkan karena nilainya sudah diubah setelah kurva diterapkan.
+func (s *KurvaNilaiService) Kembalikan(ctx context.Context, jenis string, itemID int) (int, []models.NilaiMentahKurva, error) {
+	kurva, err := s.kurvaRepo.GetAktif(ctx, jenis, itemID)
+	if err != nil {
+		return 0, nil, err
+	}
+	return s.kurvaRepo.Kembalikan(ctx, kurva)
+}
+
+// HitungKurva menerapkan metode kurva pada setiap nilai; hasil dibatasi 0-100 dan dibulatkan 2 desimal.
+//   - linear: nilai dikalikan agar rata-rata menjadi parameter (rata-rata target).
+//   - akar: 10 x akar nilai, sehingga nilai rendah naik lebih banyak dan 100 tetap 100.
+//   - minimum: nilai di bawah parameter dinaikkan menjadi parameter.
+func HitungKurva(metode string, parameter *float64, nilai []float64) []float64 {
+	hasil := make([]float64, len(nilai))
+	var faktor float64 = 1
+	if metode == KurvaLinear && parameter != nil {
+		if rata := ringkasNilai(nilai).RataRata; rata > 0 {
+			faktor = *parameter / rata
+		}
+	}
+
+	for i, x := range nilai {
+		y := x
+		switch metode {
+		case KurvaLinear:
+			y = x * faktor
+		case KurvaAkar:
+			y = 10 * math.Sqrt(math.Max(x, 0))
+		case KurvaMinimum:
+			if parameter != nil {
+				y = math.Max(x, *parameter)
+			}
+		}
+		hasil[i] = bulatkan(math.Min(math.Max(y, 0), 100))
+	}
+	return hasil
+}
+
+func ringkasNilai(nilai []float64) RingkasanNilai {
+	if len(nilai) == 0 {
+		return RingkasanNilai{}
+	}
+	ringkasan := RingkasanNilai{NilaiTertinggi: nilai[0], NilaiTerendah: nilai[0]}
+	var total float64
+	for _, x := range nilai {
+		total += x
+		ringkasan.NilaiTertinggi = math.Max(ringkasan.NilaiTertinggi, x)
+		ringkasan.NilaiTerendah = math.Min(ringkasan.NilaiTerendah, x)
+	}
+	ringkasan.RataRata = bulatkan(total / float64(len(nilai)))
+	return ringkasan
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"context"
+)
+
+// NilaiService adalah jalur yang dijalankan setiap kali nilai tugas/quiz ditulis, baik dari penilaian
+// guru, nilai kelompok, banding, impor, kurva, maupun penilaian sejawat: kebijakan remedial diterapkan
+// ke tugas/quiz asal, lalu rekap kelas dan mapelnya dihitung ulang.
+type NilaiService struct {
+	remedialService *RemedialService
+	rekapService    *RekapNilaiService
+	tugasRepo       repositories.TugasRepository
+	quizRepo        repositories.QuizRepository
+}
+
+func NewNilaiService(
+	remedialService *RemedialService,
+	rekapService *RekapNilaiService,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+) *NilaiService {
+	return &NilaiService{
+		remedialService: remedialService,
+		rekapService:    rekapService,
+		tugasRepo:       tugasRepo,
+		quizRepo:        quizRepo,
+	}
+}
+
+// BolehMenilai memeriksa apakah guru boleh menulis nilai tugas/quiz, yaitu pengampunya: wali kelas
+// tugas/quiz tersebut atau guru yang mengajar mapelnya di kelas itu.
+func (s *NilaiService) BolehMenilai(ctx context.Context, jenis string, itemID int, guruID int) (bool, error) {
+	if jenis == "quiz" {
+		return s.quizRepo.DiampuGuru(ctx, itemID, guruID)
+	}
+	return s.tugasRepo.DiampuGuru(ctx, itemID, guruID)
+}
+
+// SetelahNilaiBerubah menerapkan kebijakan remedial untuk tugas/quiz yang nilainya baru ditulis lalu
+// menghitung ulang rekap kelas dan mapelnya. Tugas/quiz remedial selalu berada di kelas dan mapel
+// yang sama dengan tugas/quiz asalnya, sehingga satu perhitungan rekap mencakup keduanya.
+func (s *NilaiService) SetelahNilaiBerubah(ctx context.Context, jenis string, itemID int) error {
+	var kelasID, mapelID int
+	if jenis == "quiz" {
+		quiz, err := s.quizRepo.GetByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		kelasID, mapelID = quiz.KelasID, quiz.MataPelajaranID
+	} else {
+		tugas, err := s.tugasRepo.GetByID(ctx, itemID)
+		if err != nil {
+			return err
+		}
+		kelasID, mapelID = tugas.KelasID, tugas.MataPelajaranID
+	}
+
+	if _, err := s.remedialService.TerapkanItem(ctx, jenis, itemID, kelasID, mapelID); err != nil {
+		return err
+	}
+	return s.rekapService.HitungKelasMapel(ctx, kelasID, mapelID)
+}
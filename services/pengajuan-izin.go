@@ -0,0 +1,170 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// maksHariPengajuanIzin membatasi rentang satu pengajuan izin; izin yang lebih panjang diajukan
+// bertahap agar wali kelas dapat menilainya.
+const maksHariPengajuanIzin = 30
+
+var (
+	ErrPengajuanIzin      = errors.New("pengajuan izin tidak valid")
+	ErrAksesPengajuanIzin = errors.New("tidak berhak atas pengajuan izin ini")
+)
+
+// PengajuanIzinService memvalidasi pengajuan izin dari siswa atau orang tuanya dan meneruskannya ke
+// wali kelas untuk disetujui atau ditolak.
+type PengajuanIzinService struct {
+	pengajuanRepo repositories.PengajuanIzinRepository
+	siswaRepo     repositories.SiswaRepository
+	kelasRepo     repositories.KelasRepository
+	orangTuaRepo  repositories.OrangTuaRepository
+}
+
+func NewPengajuanIzinService(
+	pengajuanRepo repositories.PengajuanIzinRepository,
+	siswaRepo repositories.SiswaRepository,
+	kelasRepo repositories.KelasRepository,
+	orangTuaRepo repositories.OrangTuaRepository,
+) *PengajuanIzinService {
+	return &PengajuanIzinService{
+		pengajuanRepo: pengajuanRepo,
+		siswaRepo:     siswaRepo,
+		kelasRepo:     kelasRepo,
+		orangTuaRepo:  orangTuaRepo,
+	}
+}
+
+// Ajukan menyimpan pengajuan izin atau sakit dan memberi tahu wali kelas siswa. Siswa hanya dapat
+// mengajukan untuk dirinya sendiri; orang tua hanya untuk anak yang terhubung dengan akunnya.
+func (s *PengajuanIzinService) Ajukan(ctx context.Context, pengajuan *models.PengajuanIzin) error {
+	if pengajuan.Jenis != models.AbsensiIzin && pengajuan.Jenis != models.AbsensiSakit {
+		return fmt.Errorf("%w: jenis harus izin atau sakit", ErrPengajuanIzin)
+	}
+	if pengajuan.TanggalSelesai.Before(pengajuan.TanggalMulai) {
+		return fmt.Errorf("%w: tanggal selesai sebelum tanggal mulai", ErrPengajuanIzin)
+	}
+	if pengajuan.TanggalSelesai.Sub(pengajuan.TanggalMulai) >= maksHariPengajuanIzin*24*time.Hour {
+		return fmt.Errorf("%w: rentang lebih dari %d hari", ErrPengajuanIzin, maksHariPengajuanIzin)
+	}
+
+	switch pengajuan.DiajukanRole {
+	case "siswa":
+		if pengajuan.DiajukanID != pengajuan.SiswaID {
+			return ErrAksesPengajuanIzin
+		}
+	case "orang tua":
+		terhubung, err := s.orangTuaRepo.IsOrangTuaSiswa(ctx, pengajuan.DiajukanID, pengajuan.SiswaID)
+		if err != nil {
+			return err
+		}
+		if !terhubung {
+			return ErrAksesPengajuanIzin
+		}
+	default:
+		return ErrAksesPengajuanIzin
+	}
+
+	siswa, err := s.siswaRepo.GetByID(ctx, pengajuan.SiswaID)
+	if err != nil {
+		return err
+	}
+	if siswa.KelasID == nil {
+		return fmt.Errorf("%w: siswa belum terdaftar di kelas mana pun", ErrPengajuanIzin)
+	}
+	kelas, err := s.kelasRepo.GetByID(ctx, *siswa.KelasID)
+	if err != nil {
+		return err
+	}
+
+	notifikasi := []models.Notifikasi{{
+		Role:   "guru",
+		UserID: kelas.GuruID,
+		Judul:  "Pengajuan " + pengajuan.Jenis + " baru",
+		Pesan: fmt.Sprintf("%s (%s) mengajukan %s untuk %s: %s", siswa.Nama, kelas.Name, pengajuan.Jenis,
+			rentangTanggalIzin(pengajuan), pengajuan.Alasan),
+	}}
+	return s.pengajuanRepo.Create(ctx, pengajuan, notifikasi)
+}
+
+// Proses menyetujui atau menolak pengajuan sebagai wali kelas siswa tersebut, lalu memberi tahu
+// siswa dan orang tua yang mengajukan. Mengembalikan pengajuan terbaru dan jumlah absensi yang
+// diubah menjadi izin/sakit.
+func (s *PengajuanIzinService) Proses(ctx context.Context, id int, guruID int, setuju bool, tanggapan *string) (*repositories.PengajuanIzinDetail, int, error) {
+	pengajuan, err := s.pengajuanRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	if pengajuan.WaliKelasID == nil || *pengajuan.WaliKelasID != guruID {
+		return nil, 0, ErrAksesPengajuanIzin
+	}
+	if pengajuan.Status != "menunggu" {
+		return nil, 0, repositories.ErrPengajuanSudahDiproses
+	}
+
+	status := "ditolak"
+	if setuju {
+		status = "disetujui"
+	}
+	pesan := fmt.Sprintf("Pengajuan %s %s untuk %s %s oleh wali kelas.", pengajuan.Jenis, pengajuan.NamaSiswa,
+		rentangTanggalIzin(&pengajuan.PengajuanIzin), status)
+	if tanggapan != nil && *tanggapan != "" {
+		pesan += " Tanggapan: " + *tanggapan
+	}
+
+	notifikasi := []models.Notifikasi{{
+		Role:   "siswa",
+		UserID: pengajuan.SiswaID,
+		Judul:  "Pengajuan izin " + status,
+		Pesan:  pesan,
+	}}
+	if pengajuan.DiajukanRole == "orang tua" {
+		notifikasi = append(notifikasi, models.Notifikasi{
+			Role:   "orang tua",
+			UserID: pengajuan.DiajukanID,
+			Judul:  "Pengajuan izin " + status,
+			Pesan:  pesan,
+		})
+	}
+
+	diterapkan, err := s.pengajuanRepo.Proses(ctx, id, status, guruID, tanggapan, notifikasi)
+	if err != nil {
+		return nil, 0, err
+	}
+	pengajuan, err = s.pengajuanRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	return pengajuan, diterapkan, nil
+}
+
+// BolehMelihat memeriksa apakah pengguna boleh melihat pengajuan: siswanya sendiri, orang tua yang
+// terhubung, wali kelasnya, atau admin.
+func (s *PengajuanIzinService) BolehMelihat(ctx context.Context, pengajuan *repositories.PengajuanIzinDetail, role string, userID int) (bool, error) {
+	switch role {
+	case "siswa":
+		return pengajuan.SiswaID == userID, nil
+	case "orang tua":
+		return s.orangTuaRepo.IsOrangTuaSiswa(ctx, userID, pengajuan.SiswaID)
+	case "guru":
+		return pengajuan.WaliKelasID != nil && *pengajuan.WaliKelasID == userID, nil
+	case "super admin", "admin biasa":
+		return true, nil
+	}
+	return false, nil
+}
+
+func rentangTanggalIzin(pengajuan *models.PengajuanIzin) string {
+	mulai := pengajuan.TanggalMulai.Format("02/01/2006")
+	selesai := pengajuan.TanggalSelesai.Format("02/01/2006")
+	if mulai == selesai {
+		return "tanggal " + mulai
+	}
+	return "tanggal " + mulai + " s.d. " + selesai
+}
@@ -0,0 +1,202 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"context"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bobot tiap indikator pada skor risiko (total 100).
+const (
+	BobotRisikoTugasHilang = 35.0
+	BobotRisikoTerlambat   = 20.0
+	BobotRisikoTrenNilai   = 25.0
+	BobotRisikoKehadiran   = 20.0
+)
+
+const (
+	// jumlahNilaiTren adalah banyaknya nilai terakhir yang dipakai untuk menghitung tren.
+	jumlahNilaiTren = 6
+	// penurunanTrenMaksimum adalah penurunan nilai rata-rata per penilaian yang dianggap risiko penuh.
+	penurunanTrenMaksimum = 5.0
+	// hariRekapKehadiran adalah rentang hari terakhir yang dipakai untuk menghitung rasio tidak hadir.
+	hariRekapKehadiran = 30
+	// rasioTidakHadirMaksimum adalah rasio tidak hadir yang dianggap risiko penuh.
+	rasioTidakHadirMaksimum = 0.2
+)
+
+type IndikatorRisiko struct {
+	TugasJatuhTempo       int     `json:"tugas_jatuh_tempo"`
+	TugasTidakDikumpulkan int     `json:"tugas_tidak_dikumpulkan"`
+	JumlahDikumpulkan     int     `json:"jumlah_dikumpulkan"`
+	JumlahTerlambat       int     `json:"jumlah_terlambat"`
+	RasioTerlambat        float64 `json:"rasio_terlambat"`
+	TrenNilai             float64 `json:"tren_nilai"`
+	JumlahPertemuan       int     `json:"jumlah_pertemuan"`
+	JumlahTidakHadir      int     `json:"jumlah_tidak_hadir"`
+	RasioTidakHadir       float64 `json:"rasio_tidak_hadir"`
+}
+
+type RisikoSiswa struct {
+	SiswaID   int             `json:"siswa_id"`
+	NamaSiswa string          `json:"nama_siswa"`
+	KelasID   int             `json:"kelas_id"`
+	NamaKelas string          `json:"nama_kelas"`
+	Skor      float64         `json:"skor"`
+	Level     string          `json:"level"`
+	Indikator IndikatorRisiko `json:"indikator"`
+}
+
+// PeringatanDiniService menyusun daftar siswa berisiko berdasarkan pengumpulan tugas, tren nilai, dan
+// kehadiran.
+type PeringatanDiniService struct {
+	peringatanRepo repositories.PeringatanDiniRepository
+	absensiRepo    repositories.AbsensiRepository
+}
+
+func NewPeringatanDiniService(
+	peringatanRepo repositories.PeringatanDiniRepository,
+	absensiRepo repositories.AbsensiRepository,
+) *PeringatanDiniService {
+	return &PeringatanDiniService{
+		peringatanRepo: peringatanRepo,
+		absensiRepo:    absensiRepo,
+	}
+}
+
+// GetDaftarRisiko mengembalikan siswa terurut dari skor risiko tertinggi. kelasID 0 berarti seluruh kelas.
+func (s *PeringatanDiniService) GetDaftarRisiko(ctx context.Context, kelasID int) ([]RisikoSiswa, error) {
+	ringkasan, err := s.peringatanRepo.GetRingkasanTugas(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	riwayat, err := s.peringatanRepo.GetRiwayatNilai(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+
+	nilaiSiswa := make(map[int][]float64)
+	for _, item := range riwayat {
+		nilaiSiswa[item.SiswaID] = append(nilaiSiswa[item.SiswaID], item.Nilai)
+	}
+
+	kehadiran, err := s.getKehadiran(ctx, ringkasan)
+	if err != nil {
+		return nil, err
+	}
+
+	results := []RisikoSiswa{}
+	for _, item := range ringkasan {
+		indikator := IndikatorRisiko{
+			TugasJatuhTempo:       item.TugasJatuhTempo,
+			TugasTidakDikumpulkan: item.TugasTidakDikumpulkan,
+			JumlahDikumpulkan:     item.JumlahDikumpulkan,
+			JumlahTerlambat:       item.JumlahTerlambat,
+			TrenNilai:             bulatkan(TrenNilai(nilaiSiswa[item.SiswaID])),
+		}
+		if item.JumlahDikumpulkan > 0 {
+			indikator.RasioTerlambat = bulatkan(float64(item.JumlahTerlambat) / float64(item.JumlahDikumpulkan))
+		}
+		if rekap, ok := kehadiran[item.SiswaID]; ok {
+			indikator.JumlahPertemuan = rekap.Hadir + rekap.Izin + rekap.Sakit + rekap.Alpa
+			indikator.JumlahTidakHadir = rekap.Izin + rekap.Sakit + rekap.Alpa
+			if indikator.JumlahPertemuan > 0 {
+				indikator.RasioTidakHadir = bulatkan(float64(indikator.JumlahTidakHadir) / float64(indikator.JumlahPertemuan))
+			}
+		}
+
+		skor := HitungSkorRisiko(indikator)
+		results = append(results, RisikoSiswa{
+			SiswaID:   item.SiswaID,
+			NamaSiswa: item.NamaSiswa,
+			KelasID:   item.KelasID,
+			NamaKelas: item.NamaKelas,
+			Skor:      skor,
+			Level:     LevelRisiko(skor),
+			Indikator: indikator,
+		})
+	}
+
+	sort.SliceStable(results, func(a, b int) bool {
+		if results[a].Skor != results[b].Skor {
+			return results[a].Skor > results[b].Skor
+		}
+		return results[a].NamaSiswa < results[b].NamaSiswa
+	})
+	return results, nil
+}
+
+// getKehadiran mengambil rekap absensi hariRekapKehadiran hari terakhir untuk setiap kelas yang
+// muncul di ringkasan, dipetakan per siswa.
+func (s *PeringatanDiniService) getKehadiran(ctx context.Context, ringkasan []repositories.RingkasanTugasSiswa) (map[int]repositories.RekapAbsensi, error) {
+	selesai := time.Now()
+	mulai := selesai.AddDate(0, 0, -hariRekapKehadiran)
+
+	kehadiran := make(map[int]repositories.RekapAbsensi)
+	sudah := make(map[int]bool)
+	for _, item := range ringkasan {
+		if sudah[item.KelasID] {
+			continue
+		}
+		sudah[item.KelasID] = true
+
+		rekap, err := s.absensiRepo.GetRekapKelas(ctx, item.KelasID, mulai, selesai)
+		if err != nil {
+			return nil, err
+		}
+		for _, baris := range rekap {
+			kehadiran[baris.SiswaID] = baris
+		}
+	}
+	return kehadiran, nil
+}
+
+// HitungSkorRisiko menggabungkan indikator menjadi skor 0-100: proporsi tugas lewat deadline yang
+// tidak dikumpulkan, rasio pengumpulan terlambat, penurunan tren nilai, dan rasio tidak hadir.
+func HitungSkorRisiko(indikator IndikatorRisiko) float64 {
+	var skor float64
+	if indikator.TugasJatuhTempo > 0 {
+		skor += BobotRisikoTugasHilang * float64(indikator.TugasTidakDikumpulkan) / float64(indikator.TugasJatuhTempo)
+	}
+	skor += BobotRisikoTerlambat * indikator.RasioTerlambat
+	if indikator.TrenNilai < 0 {
+		skor += BobotRisikoTrenNilai * math.Min(-indikator.TrenNilai/penurunanTrenMaksimum, 1)
+	}
+	skor += BobotRisikoKehadiran * math.Min(indikator.RasioTidakHadir/rasioTidakHadirMaksimum, 1)
+	return bulatkan(skor)
+}
+
+func LevelRisiko(skor float64) string {
+	switch {
+	case skor >= 60:
+		return "tinggi"
+	case skor >= 30:
+		return "sedang"
+	default:
+		return "rendah"
+	}
+}
+
+// TrenNilai menghitung kemiringan garis regresi dari beberapa nilai terakhir (perubahan nilai per
+// penilaian). Nilai negatif berarti nilai siswa menurun. Kurang dari 3 nilai dianggap tanpa tren.
+func TrenNilai(nilai []float64) float64 {
+	if len(nilai) > jumlahNilaiTren {
+		nilai = nilai[len(nilai)-jumlahNilaiTren:]
+	}
+	n := float64(len(nilai))
+	if n < 3 {
+		return 0
+	}
+
+	var sumX, sumY, sumXY, sumXX float64
+	for i, y := range nilai {
+		x := float64(i)
+		sumX += x
+		sumY += y
+		sumXY += x * y
+		sumXX += x * x
+	}
+	return (n*sumXY - sumX*sumY) / (n*sumXX - sumX*sumX)
+}
@@ -0,0 +1,456 @@
+package services
+
+import (
+	"be-pui/config"
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// ErrSemesterRapor dikembalikan saat semester yang diminta belum diatur di kalender akademik,
+// sehingga nilai dan kehadiran tidak dapat dibatasi pada periode semester tersebut.
+var ErrSemesterRapor = errors.New("semester rapor belum diatur di kalender akademik")
+
+// DefaultKKM dipakai untuk menentukan predikat jika KKM mapel belum diatur.
+const DefaultKKM = 70.0
+
+type NilaiMapelRapor struct {
+	NamaMapel  string
+	NilaiAkhir float64
+	Predikat   string
+	Deskripsi  string
+}
+
+// KehadiranRapor adalah jumlah hari tidak hadir siswa selama semester.
+type KehadiranRapor struct {
+	Sakit int
+	Izin  int
+	Alpa  int
+}
+
+// RaporSiswa adalah seluruh data yang dicetak pada satu rapor siswa.
+type RaporSiswa struct {
+	Siswa       models.Siswa
+	Kelas       models.Kelas
+	WaliKelas   string
+	Semester    int
+	TahunAjaran string
+	Nilai       []NilaiMapelRapor
+	Kehadiran   KehadiranRapor
+}
+
+// RaporService menyusun data rapor dari nilai terbit selama semester dan mencetaknya ke PDF.
+type RaporService struct {
+	rekapService  *RekapNilaiService
+	siswaRepo     repositories.SiswaRepository
+	kelasRepo     repositories.KelasRepository
+	guruRepo      repositories.GuruRepository
+	kkmRepo       repositories.KKMRepository
+	deskripsiRepo repositories.DeskripsiRaporRepository
+	absensiRepo   repositories.AbsensiRepository
+	kalenderRepo  repositories.KalenderAkademikRepository
+	sekolah       config.SekolahConfig
+}
+
+func NewRaporService(
+	rekapService *RekapNilaiService,
+	siswaRepo repositories.SiswaRepository,
+	kelasRepo repositories.KelasRepository,
+	guruRepo repositories.GuruRepository,
+	kkmRepo repositories.KKMRepository,
+	deskripsiRepo repositories.DeskripsiRaporRepository,
+	absensiRepo repositories.AbsensiRepository,
+	kalenderRepo repositories.KalenderAkademikRepository,
+	sekolah config.SekolahConfig,
+) *RaporService {
+	return &RaporService{
+		rekapService:  rekapService,
+		siswaRepo:     siswaRepo,
+		kelasRepo:     kelasRepo,
+		guruRepo:      guruRepo,
+		kkmRepo:       kkmRepo,
+		deskripsiRepo: deskripsiRepo,
+		absensiRepo:   absensiRepo,
+		kalenderRepo:  kalenderRepo,
+		sekolah:       sekolah,
+	}
+}
+
+// BuatRapor menyusun rapor satu siswa di kelasnya saat ini. Mengembalikan sql.ErrNoRows
+// jika siswa tidak ditemukan atau belum terdaftar di kelas mana pun.
+func (s *RaporService) BuatRapor(ctx context.Context, siswaID int, semester int, tahunAjaran string) (*RaporSiswa, error) {
+	siswa, err := s.siswaRepo.GetByID(ctx, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	if siswa.KelasID == nil {
+		return nil, sql.ErrNoRows
+	}
+
+	kelas, err := s.kelasRepo.GetByID(ctx, *siswa.KelasID)
+	if err != nil {
+		return nil, err
+	}
+
+	kkm, err := getKKMTingkat(ctx, s.kkmRepo, kelas.Tingkat)
+	if err != nil {
+		return nil, err
+	}
+
+	waliKelas, err := s.getNamaWaliKelas(ctx, kelas)
+	if err != nil {
+		return nil, err
+	}
+
+	periode, err := getPeriodeSemester(ctx, s.kalenderRepo, semester, tahunAjaran)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.susunRapor(ctx, siswa, kelas, waliKelas, kkm, semester, tahunAjaran, periode)
+}
+
+// BuatRaporKelas menyusun rapor untuk seluruh siswa di satu kelas.
+func (s *RaporService) BuatRaporKelas(ctx context.Context, kelasID int, semester int, tahunAjaran string) ([]RaporSiswa, error) {
+	kelas, err := s.kelasRepo.GetByID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+
+	kkm, err := getKKMTingkat(ctx, s.kkmRepo, kelas.Tingkat)
+	if err != nil {
+		return nil, err
+	}
+
+	waliKelas, err := s.getNamaWaliKelas(ctx, kelas)
+	if err != nil {
+		return nil, err
+	}
+
+	periode, err := getPeriodeSemester(ctx, s.kalenderRepo, semester, tahunAjaran)
+	if err != nil {
+		return nil, err
+	}
+
+	siswaKelas, err := s.siswaRepo.GetAllByKelasID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+
+	var results []RaporSiswa
+	for i := range siswaKelas {
+		rapor, err := s.susunRapor(ctx, &siswaKelas[i], kelas, waliKelas, kkm, semester, tahunAjaran, periode)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *rapor)
+	}
+	return results, nil
+}
+
+// susunRapor menyusun rapor satu siswa. Nilai dan kehadiran hanya diambil dari periode semester,
+// dan nilai hanya dari tugas atau quiz yang sudah terbit.
+func (s *RaporService) susunRapor(ctx context.Context, siswa *models.Siswa, kelas *models.Kelas, waliKelas string, kkm map[int]float64, semester int, tahunAjaran string, periode *models.Semester) (*RaporSiswa, error) {
+	nilaiMapel, err := s.rekapService.HitungSiswaPeriode(ctx, siswa.ID, kelas.ID, periode.TanggalMulai, periode.TanggalSelesai)
+	if err != nil {
+		return nil, err
+	}
+
+	rapor := RaporSiswa{
+		Siswa:       *siswa,
+		Kelas:       *kelas,
+		WaliKelas:   waliKelas,
+		Semester:    semester,
+		TahunAjaran: tahunAjaran,
+	}
+
+	// Deskripsi yang sudah difinalkan guru menggantikan deskripsi standar dari predikat.
+	deskripsiFinal, err := s.deskripsiRepo.GetFinalBySiswaAndKelasID(ctx, siswa.ID, kelas.ID)
+	if err != nil {
+		return nil, err
+	}
+	deskripsiMapel := make(map[int]string)
+	for _, item := range deskripsiFinal {
+		deskripsiMapel[item.MataPelajaranID] = item.Deskripsi
+	}
+
+	for _, item := range nilaiMapel {
+		predikat := Predikat(item.NilaiAkhir, batasKKM(kkm, item.MataPelajaranID))
+		deskripsi, ok := deskripsiMapel[item.MataPelajaranID]
+		if !ok {
+			deskripsi = DeskripsiPredikat(predikat, item.NamaMapel)
+		}
+		rapor.Nilai = append(rapor.Nilai, NilaiMapelRapor{
+			NamaMapel:  item.NamaMapel,
+			NilaiAkhir: item.NilaiAkhir,
+			Predikat:   predikat,
+			Deskripsi:  deskripsi,
+		})
+	}
+
+	riwayat, err := s.absensiRepo.GetRiwayatSiswa(ctx, siswa.ID, periode.TanggalMulai, periode.TanggalSelesai)
+	if err != nil {
+		return nil, err
+	}
+	rapor.Kehadiran = hitungHariTidakHadir(riwayat)
+
+	return &rapor, nil
+}
+
+// getPeriodeSemester mencari semester ke-n (urut tanggal mulai) pada tahun ajaran bernama
+// tahunAjaran. Mengembalikan ErrSemesterRapor jika tahun ajaran atau semesternya belum diatur.
+func getPeriodeSemester(ctx context.Context, kalenderRepo repositories.KalenderAkademikRepository, semester int, tahunAjaran string) (*models.Semester, error) {
+	daftarTahunAjaran, err := kalenderRepo.GetAllTahunAjaran(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range daftarTahunAjaran {
+		if item.Nama != tahunAjaran {
+			continue
+		}
+		daftarSemester, err := kalenderRepo.GetSemesterByTahunAjaranID(ctx, item.ID)
+		if err != nil {
+			return nil, err
+		}
+		if semester < 1 || semester > len(daftarSemester) {
+			return nil, ErrSemesterRapor
+		}
+		return &daftarSemester[semester-1], nil
+	}
+	return nil, ErrSemesterRapor
+}
+
+// hitungHariTidakHadir mengubah absensi per pertemuan menjadi jumlah hari. Satu tanggal dihitung
+// sekali: sakit jika ada pertemuan sakit, lalu izin, lalu alpa. Tanggal yang seluruhnya hadir tidak
+// dihitung.
+func hitungHariTidakHadir(riwayat []repositories.RiwayatAbsensi) KehadiranRapor {
+	statusHari := make(map[string]string)
+	for _, item := range riwayat {
+		kunci := item.Tanggal.Format("2006-01-02")
+		if urutanTidakHadir(item.Status) > urutanTidakHadir(statusHari[kunci]) {
+			statusHari[kunci] = item.Status
+		}
+	}
+
+	var kehadiran KehadiranRapor
+	for _, status := range statusHari {
+		switch status {
+		case models.AbsensiSakit:
+			kehadiran.Sakit++
+		case models.AbsensiIzin:
+			kehadiran.Izin++
+		case models.AbsensiAlpa:
+			kehadiran.Alpa++
+		}
+	}
+	return kehadiran
+}
+
+func urutanTidakHadir(status string) int {
+	switch status {
+	case models.AbsensiSakit:
+		return 3
+	case models.AbsensiIzin:
+		return 2
+	case models.AbsensiAlpa:
+		return 1
+	}
+	return 0
+}
+
+func (s *RaporService) getNamaWaliKelas(ctx context.Context, kelas *models.Kelas) (string, error) {
+	guru, err := s.guruRepo.GetByID(ctx, kelas.GuruID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return "", nil
+		}
+		return "", err
+	}
+	return guru.Nama, nil
+}
+
+// getKKMTingkat mengembalikan KKM per mapel untuk satu tingkat kelas.
+func getKKMTingkat(ctx context.Context, kkmRepo repositories.KKMRepository, tingkat int) (map[int]float64, error) {
+	semua, err := kkmRepo.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	kkm := make(map[int]float64)
+	for _, item := range semua {
+		if item.Tingkat == tingkat {
+			kkm[item.MataPelajaranID] = item.Nilai
+		}
+	}
+	return kkm, nil
+}
+
+func batasKKM(kkm map[int]float64, mapelID int) float64 {
+	if batas, ok := kkm[mapelID]; ok {
+		return batas
+	}
+	return DefaultKKM
+}
+
+// Predikat mengubah nilai angka menjadi huruf A-D. Nilai di bawah KKM mendapat D,
+// sedangkan rentang KKM sampai 100 dibagi rata menjadi C, B, dan A.
+func Predikat(nilai float64, kkm float64) string {
+	interval := (100 - kkm) / 3
+	switch {
+	case nilai < kkm:
+		return "D"
+	case nilai < kkm+interval:
+		return "C"
+	case nilai < kkm+2*interval:
+		return "B"
+	default:
+		return "A"
+	}
+}
+
+// DeskripsiPredikat menghasilkan kalimat capaian standar berdasarkan predikat.
+func DeskripsiPredikat(predikat string, namaMapel string) string {
+	switch predikat {
+	case "A":
+		return fmt.Sprintf("Sangat baik dalam menguasai materi %s.", namaMapel)
+	case "B":
+		return fmt.Sprintf("Baik dalam menguasai materi %s.", namaMapel)
+	case "C":
+		return fmt.Sprintf("Cukup dalam menguasai materi %s, perlu latihan lebih lanjut.", namaMapel)
+	default:
+		return fmt.Sprintf("Perlu bimbingan dalam menguasai materi %s.", namaMapel)
+	}
+}
+
+// RenderPDF mencetak rapor siswa ke dalam format PDF A4.
+func (s *RaporService) RenderPDF(w io.Writer, rapor *RaporSiswa) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(15, 15, 15)
+	pdf.SetAutoPageBreak(true, 15)
+	pdf.AddPage()
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+
+	pdf.SetFont("Helvetica", "B", 14)
+	pdf.CellFormat(0, 7, "LAPORAN HASIL BELAJAR PESERTA DIDIK", "", 1, "C", false, 0, "")
+	if s.sekolah.Nama != "" {
+		pdf.SetFont("Helvetica", "B", 12)
+		pdf.CellFormat(0, 6, tr(s.sekolah.Nama), "", 1, "C", false, 0, "")
+	}
+	pdf.Ln(5)
+
+	semester := "1 (Ganjil)"
+	if rapor.Semester == 2 {
+		semester = "2 (Genap)"
+	}
+	identitas := [][2]string{
+		{"Nama Siswa", rapor.Siswa.Nama},
+		{"Kelas", rapor.Kelas.Name},
+		{"Semester", semester},
+		{"Tahun Ajaran", rapor.TahunAjaran},
+	}
+	pdf.SetFont("Helvetica", "", 10)
+	for _, baris := range identitas {
+		pdf.CellFormat(35, 6, baris[0], "", 0, "L", false, 0, "")
+		pdf.CellFormat(0, 6, ": "+tr(baris[1]), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, "A. Nilai Akademik", "", 1, "L", false, 0, "")
+
+	lebar := []float64{10, 45, 18, 20, 87}
+	pdf.SetFont("Helvetica", "B", 10)
+	pdf.SetFillColor(230, 230, 230)
+	for i, judul := range []string{"No", "Mata Pelajaran", "Nilai", "Predikat", "Deskripsi"} {
+		pdf.CellFormat(lebar[i], 8, judul, "1", 0, "C", true, 0, "")
+	}
+	pdf.Ln(-1)
+
+	pdf.SetFont("Helvetica", "", 10)
+	const tinggiBaris = 5.0
+	if len(rapor.Nilai) == 0 {
+		pdf.CellFormat(0, 8, "Belum ada nilai.", "1", 1, "C", false, 0, "")
+	}
+	for i, nilai := range rapor.Nilai {
+		mapel := pdf.SplitText(tr(nilai.NamaMapel), lebar[1]-2)
+		deskripsi := pdf.SplitText(tr(nilai.Deskripsi), lebar[4]-2)
+		jumlahBaris := len(mapel)
+		if len(deskripsi) > jumlahBaris {
+			jumlahBaris = len(deskripsi)
+		}
+		tinggi := float64(jumlahBaris) * tinggiBaris
+		if pdf.GetY()+tinggi > 297-15 {
+			pdf.AddPage()
+		}
+
+		x, y := pdf.GetXY()
+		pdf.CellFormat(lebar[0], tinggi, fmt.Sprintf("%d", i+1), "1", 0, "C", false, 0, "")
+		pdf.Rect(x+lebar[0], y, lebar[1], tinggi, "D")
+		pdf.MultiCell(lebar[1], tinggiBaris, tr(nilai.NamaMapel), "", "L", false)
+		pdf.SetXY(x+lebar[0]+lebar[1], y)
+		pdf.CellFormat(lebar[2], tinggi, fmt.Sprintf("%.0f", nilai.NilaiAkhir), "1", 0, "C", false, 0, "")
+		pdf.CellFormat(lebar[3], tinggi, nilai.Predikat, "1", 0, "C", false, 0, "")
+		xDeskripsi := pdf.GetX()
+		pdf.Rect(xDeskripsi, y, lebar[4], tinggi, "D")
+		pdf.MultiCell(lebar[4], tinggiBaris, tr(nilai.Deskripsi), "", "L", false)
+		pdf.SetXY(x, y+tinggi)
+	}
+	pdf.Ln(4)
+
+	pdf.SetFont("Helvetica", "B", 11)
+	pdf.CellFormat(0, 7, "B. Ketidakhadiran", "", 1, "L", false, 0, "")
+	pdf.SetFont("Helvetica", "", 10)
+	kehadiran := [][2]string{
+		{"Sakit", fmt.Sprintf("%d hari", rapor.Kehadiran.Sakit)},
+		{"Izin", fmt.Sprintf("%d hari", rapor.Kehadiran.Izin)},
+		{"Tanpa Keterangan", fmt.Sprintf("%d hari", rapor.Kehadiran.Alpa)},
+	}
+	for _, baris := range kehadiran {
+		pdf.CellFormat(55, 6, baris[0], "1", 0, "L", false, 0, "")
+		pdf.CellFormat(30, 6, baris[1], "1", 1, "C", false, 0, "")
+	}
+	pdf.Ln(10)
+
+	if pdf.GetY()+45 > 297-15 {
+		pdf.AddPage()
+	}
+	tanggal := formatTanggal(time.Now())
+	if s.sekolah.Kota != "" {
+		tanggal = s.sekolah.Kota + ", " + tanggal
+	}
+	kolom := 90.0
+	pdf.CellFormat(kolom, 6, "", "", 0, "C", false, 0, "")
+	pdf.CellFormat(kolom, 6, tr(tanggal), "", 1, "C", false, 0, "")
+	pdf.CellFormat(kolom, 6, "Wali Kelas", "", 0, "C", false, 0, "")
+	pdf.CellFormat(kolom, 6, "Kepala Sekolah", "", 1, "C", false, 0, "")
+	pdf.Ln(20)
+	pdf.SetFont("Helvetica", "BU", 10)
+	pdf.CellFormat(kolom, 6, tr(namaTandaTangan(rapor.WaliKelas)), "", 0, "C", false, 0, "")
+	pdf.CellFormat(kolom, 6, tr(namaTandaTangan(s.sekolah.KepalaSekolah)), "", 1, "C", false, 0, "")
+	if s.sekolah.NIPKepalaSekolah != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.CellFormat(kolom, 6, "", "", 0, "C", false, 0, "")
+		pdf.CellFormat(kolom, 6, "NIP. "+tr(s.sekolah.NIPKepalaSekolah), "", 1, "C", false, 0, "")
+	}
+
+	return pdf.Output(w)
+}
+
+func namaTandaTangan(nama string) string {
+	if nama == "" {
+		return "(..............................)"
+	}
+	return nama
+}
+
+var namaBulan = []string{"Januari", "Februari", "Maret", "April", "Mei", "Juni", "Juli", "Agustus", "September", "Oktober", "November", "Desember"}
+
+func formatTanggal(t time.Time) string {
+	return fmt.Sprintf("%d %s %d", t.Day(), namaBulan[t.Month()-1], t.Year())
+}
@@ -0,0 +1,297 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"math"
+	"sort"
+	"time"
+)
+
+// Bobot bawaan jika mata pelajaran belum memiliki konfigurasi bobot_nilai.
+const (
+	DefaultBobotTugas = 50
+	DefaultBobotQuiz  = 50
+)
+
+// RekapNilaiService menghitung dan menyimpan RekapNilai dari hasil_tugas dan hasil_quiz yang nilainya
+// sudah terbit.
+type RekapNilaiService struct {
+	rekapRepo repositories.RekapNilaiRepository
+	bobotRepo repositories.BobotNilaiRepository
+	siswaRepo repositories.SiswaRepository
+	tugasRepo repositories.TugasRepository
+	quizRepo  repositories.QuizRepository
+}
+
+func NewRekapNilaiService(
+	rekapRepo repositories.RekapNilaiRepository,
+	bobotRepo repositories.BobotNilaiRepository,
+	siswaRepo repositories.SiswaRepository,
+	tugasRepo repositories.TugasRepository,
+	quizRepo repositories.QuizRepository,
+) *RekapNilaiService {
+	return &RekapNilaiService{
+		rekapRepo: rekapRepo,
+		bobotRepo: bobotRepo,
+		siswaRepo: siswaRepo,
+		tugasRepo: tugasRepo,
+		quizRepo:  quizRepo,
+	}
+}
+
+type konfigurasiBobot struct {
+	bobot    models.BobotNilai
+	kategori map[string]float64
+}
+
+// HitungKelasMapel menghitung ulang rekap seluruh siswa di satu kelas untuk satu mapel.
+func (s *RekapNilaiService) HitungKelasMapel(ctx context.Context, kelasID int, mapelID int) error {
+	konfigurasi, err := s.loadKonfigurasi(ctx, mapelID)
+	if err != nil {
+		return err
+	}
+
+	siswas, err := s.siswaRepo.GetAllByKelasID(ctx, kelasID)
+	if err != nil {
+		return err
+	}
+
+	for _, siswa := range siswas {
+		if err := s.hitungSiswa(ctx, konfigurasi, siswa.ID, kelasID, mapelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HitungMapel menghitung ulang rekap satu mapel di setiap kelas yang memiliki tugas atau quiz mapel
+// tersebut, misalnya setelah konfigurasi bobotnya diubah.
+func (s *RekapNilaiService) HitungMapel(ctx context.Context, mapelID int) error {
+	kelasIDs, err := s.rekapRepo.GetKelasIDsByMapelID(ctx, mapelID)
+	if err != nil {
+		return err
+	}
+	for _, kelasID := range kelasIDs {
+		if err := s.HitungKelasMapel(ctx, kelasID, mapelID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// HitungUntukTugas menghitung ulang rekap kelas dan mapel milik tugas setelah nilainya berubah.
+func (s *RekapNilaiService) HitungUntukTugas(ctx context.Context, tugasID int) error {
+	tugas, err := s.tugasRepo.GetByID(ctx, tugasID)
+	if err != nil {
+		return err
+	}
+	return s.HitungKelasMapel(ctx, tugas.KelasID, tugas.MataPelajaranID)
+}
+
+// HitungUntukQuiz menghitung ulang rekap kelas dan mapel milik quiz setelah nilainya berubah.
+func (s *RekapNilaiService) HitungUntukQuiz(ctx context.Context, quizID int) error {
+	quiz, err := s.quizRepo.GetByID(ctx, quizID)
+	if err != nil {
+		return err
+	}
+	return s.HitungKelasMapel(ctx, quiz.KelasID, quiz.MataPelajaranID)
+}
+
+func (s *RekapNilaiService) hitungSiswa(ctx context.Context, konfigurasi *konfigurasiBobot, siswaID int, kelasID int, mapelID int) error {
+	nilaiTugas, err := s.rekapRepo.GetNilaiTugas(ctx, siswaID, kelasID, mapelID)
+	if err != nil {
+		return err
+	}
+	nilaiQuiz, err := s.rekapRepo.GetNilaiQuiz(ctx, siswaID, kelasID, mapelID)
+	if err != nil {
+		return err
+	}
+
+	rataTugas, rataQuiz, nilaiAkhir, ada := hitungNilaiAkhir(konfigurasi, nilaiTugas, nilaiQuiz)
+	if !ada {
+		// Rekap lama dihapus agar nilai yang belum (atau tidak lagi) terbit tidak tertinggal di rekap.
+		return s.rekapRepo.Delete(ctx, siswaID, kelasID, mapelID)
+	}
+
+	return s.rekapRepo.Upsert(ctx, &models.RekapNilai{
+		SiswaID:         siswaID,
+		MataPelajaranID: mapelID,
+		KelasID:         kelasID,
+		NilaiTugas:      bulatkan(rataTugas),
+		NilaiQuiz:       bulatkan(rataQuiz),
+		NilaiAkhir:      bulatkan(nilaiAkhir),
+	})
+}
+
+// NilaiMapelPeriode adalah nilai akhir satu mapel yang dihitung dari nilai terbit dalam satu periode.
+type NilaiMapelPeriode struct {
+	MataPelajaranID int
+	NamaMapel       string
+	NilaiAkhir      float64
+}
+
+// HitungSiswaPeriode menghitung nilai akhir setiap mapel siswa di satu kelas hanya dari tugas dan
+// quiz terbit di antara mulai dan selesai, dengan bobot yang sama seperti rekap. Hasil terurut
+// menurut nama mapel; mapel tanpa nilai pada periode itu tidak disertakan.
+func (s *RekapNilaiService) HitungSiswaPeriode(ctx context.Context, siswaID int, kelasID int, mulai time.Time, selesai time.Time) ([]NilaiMapelPeriode, error) {
+	nilaiTugas, err := s.rekapRepo.GetNilaiTugasPeriode(ctx, siswaID, kelasID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+	nilaiQuiz, err := s.rekapRepo.GetNilaiQuizPeriode(ctx, siswaID, kelasID, mulai, selesai)
+	if err != nil {
+		return nil, err
+	}
+
+	namaMapel := make(map[int]string)
+	tugasPerMapel := make(map[int][]repositories.NilaiKomponen)
+	quizPerMapel := make(map[int][]repositories.NilaiKomponen)
+	for _, item := range nilaiTugas {
+		namaMapel[item.MataPelajaranID] = item.NamaMapel
+		tugasPerMapel[item.MataPelajaranID] = append(tugasPerMapel[item.MataPelajaranID], item.NilaiKomponen)
+	}
+	for _, item := range nilaiQuiz {
+		namaMapel[item.MataPelajaranID] = item.NamaMapel
+		quizPerMapel[item.MataPelajaranID] = append(quizPerMapel[item.MataPelajaranID], item.NilaiKomponen)
+	}
+
+	var results []NilaiMapelPeriode
+	for mapelID, nama := range namaMapel {
+		konfigurasi, err := s.loadKonfigurasi(ctx, mapelID)
+		if err != nil {
+			return nil, err
+		}
+		_, _, nilaiAkhir, ada := hitungNilaiAkhir(konfigurasi, tugasPerMapel[mapelID], quizPerMapel[mapelID])
+		if !ada {
+			continue
+		}
+		results = append(results, NilaiMapelPeriode{
+			MataPelajaranID: mapelID,
+			NamaMapel:       nama,
+			NilaiAkhir:      bulatkan(nilaiAkhir),
+		})
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].NamaMapel < results[j].NamaMapel })
+	return results, nil
+}
+
+// hitungNilaiAkhir menggabungkan rata-rata tugas dan quiz sesuai bobot mapel. ada bernilai false
+// jika tidak ada satu pun nilai pada komponen yang berbobot.
+func hitungNilaiAkhir(konfigurasi *konfigurasiBobot, nilaiTugas []repositories.NilaiKomponen, nilaiQuiz []repositories.NilaiKomponen) (rataTugas float64, rataQuiz float64, nilaiAkhir float64, ada bool) {
+	rataTugas, adaTugas := HitungRataRata(nilaiTugas, konfigurasi.bobot.TugasDibuang, konfigurasi.kategori)
+	rataQuiz, adaQuiz := HitungRataRata(nilaiQuiz, konfigurasi.bobot.QuizDibuang, nil)
+	if !adaTugas && !adaQuiz {
+		return 0, 0, 0, false
+	}
+
+	var total, totalBobot float64
+	if adaTugas {
+		total += rataTugas * konfigurasi.bobot.BobotTugas
+		totalBobot += konfigurasi.bobot.BobotTugas
+	}
+	if adaQuiz {
+		total += rataQuiz * konfigurasi.bobot.BobotQuiz
+		totalBobot += konfigurasi.bobot.BobotQuiz
+	}
+	if totalBobot == 0 {
+		return rataTugas, rataQuiz, 0, false
+	}
+	return rataTugas, rataQuiz, total / totalBobot, true
+}
+
+func (s *RekapNilaiService) loadKonfigurasi(ctx context.Context, mapelID int) (*konfigurasiBobot, error) {
+	konfigurasi := &konfigurasiBobot{
+		bobot: models.BobotNilai{
+			MataPelajaranID: mapelID,
+			BobotTugas:      DefaultBobotTugas,
+			BobotQuiz:       DefaultBobotQuiz,
+		},
+		kategori: make(map[string]float64),
+	}
+
+	bobot, err := s.bobotRepo.GetByMapelID(ctx, mapelID)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if bobot != nil {
+		konfigurasi.bobot = *bobot
+	}
+
+	kategori, err := s.bobotRepo.GetKategoriByMapelID(ctx, mapelID)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range kategori {
+		konfigurasi.kategori[item.Kategori] = item.Bobot
+	}
+	return konfigurasi, nil
+}
+
+// HitungRataRata membuang `dibuang` nilai terendah (minimal satu nilai tetap dipakai), lalu
+// menghitung rata-rata. Jika bobot kategori diberikan, rata-rata dihitung per kategori lalu
+// digabung sesuai bobotnya. Nilai tanpa kategori atau dengan kategori tanpa bobot dikumpulkan
+// dalam satu kelompok "lainnya" yang berbobot rata-rata bobot kategori, sehingga tidak pernah
+// hilang dari perhitungan.
+func HitungRataRata(nilai []repositories.NilaiKomponen, dibuang int, bobotKategori map[string]float64) (float64, bool) {
+	if len(nilai) == 0 {
+		return 0, false
+	}
+
+	urut := make([]repositories.NilaiKomponen, len(nilai))
+	copy(urut, nilai)
+	sort.SliceStable(urut, func(i, j int) bool { return urut[i].Nilai < urut[j].Nilai })
+
+	if dibuang > len(urut)-1 {
+		dibuang = len(urut) - 1
+	}
+	if dibuang > 0 {
+		urut = urut[dibuang:]
+	}
+
+	jumlahPerKategori := make(map[string]float64)
+	banyakPerKategori := make(map[string]int)
+	var jumlahLainnya float64
+	var banyakLainnya int
+	for _, item := range urut {
+		if _, berbobot := bobotKategori[item.Kategori]; berbobot {
+			jumlahPerKategori[item.Kategori] += item.Nilai
+			banyakPerKategori[item.Kategori]++
+		} else {
+			jumlahLainnya += item.Nilai
+			banyakLainnya++
+		}
+	}
+
+	var total, totalBobot float64
+	for kategori, banyak := range banyakPerKategori {
+		bobot := bobotKategori[kategori]
+		total += (jumlahPerKategori[kategori] / float64(banyak)) * bobot
+		totalBobot += bobot
+	}
+	if banyakLainnya > 0 {
+		bobot := bobotKategoriLainnya(bobotKategori)
+		total += (jumlahLainnya / float64(banyakLainnya)) * bobot
+		totalBobot += bobot
+	}
+	return total / totalBobot, true
+}
+
+// bobotKategoriLainnya adalah bobot kelompok nilai tanpa kategori berbobot: rata-rata bobot
+// kategori yang dikonfigurasi, atau 1 jika belum ada kategori sama sekali.
+func bobotKategoriLainnya(bobotKategori map[string]float64) float64 {
+	if len(bobotKategori) == 0 {
+		return 1
+	}
+	var jumlah float64
+	for _, bobot := range bobotKategori {
+		jumlah += bobot
+	}
+	return jumlah / float64(len(bobotKategori))
+}
+
+func bulatkan(nilai float64) float64 {
+	return math.Round(nilai*100) / 100
+}
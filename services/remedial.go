@@ -0,0 +1,159 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+	"math"
+)
+
+// toleransiNilaiAsal adalah selisih terkecil antara nilai asal dan nilai akhir remedial yang dianggap
+// sebagai penilaian ulang tugas/quiz asal, bukan sekadar perbedaan pembulatan.
+const toleransiNilaiAsal = 0.005
+
+// RemedialService menerapkan nilai tugas/quiz remedial ke nilai asal sesuai kebijakan sekolah.
+type RemedialService struct {
+	remedialRepo repositories.RemedialRepository
+	kkmRepo      repositories.KKMRepository
+	kelasRepo    repositories.KelasRepository
+}
+
+func NewRemedialService(
+	remedialRepo repositories.RemedialRepository,
+	kkmRepo repositories.KKMRepository,
+	kelasRepo repositories.KelasRepository,
+) *RemedialService {
+	return &RemedialService{
+		remedialRepo: remedialRepo,
+		kkmRepo:      kkmRepo,
+		kelasRepo:    kelasRepo,
+	}
+}
+
+// TerapkanItem dipanggil setelah nilai sebuah tugas/quiz berubah. Jika item tersebut adalah remedial,
+// nilai setiap peserta yang sudah dinilai dan berbeda dari nilai remedial yang terakhir diterapkan
+// dihitung ulang sesuai kebijakan lalu ditulis ke tugas/quiz asal. Jika item tersebut adalah tugas/quiz
+// asal yang dinilai ulang, kebijakan diterapkan ulang dengan nilai barunya sebagai nilai awal.
+// Mengembalikan false jika item bukan remedial.
+func (s *RemedialService) TerapkanItem(ctx context.Context, jenis string, itemID int, kelasID int, mapelID int) (bool, error) {
+	remedial, err := s.remedialRepo.GetByItemID(ctx, jenis, itemID)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return false, s.terapkanUlangAsal(ctx, jenis, itemID, kelasID, mapelID)
+		}
+		return false, err
+	}
+
+	// Nilai awal diambil dari data peserta, bukan dari hasil asal yang mungkin sudah tertimpa penilaian sebelumnya.
+	daftarPeserta, err := s.remedialRepo.GetPeserta(ctx, remedial.ID)
+	if err != nil {
+		return true, err
+	}
+	nilaiItem, err := s.remedialRepo.GetNilaiItem(ctx, jenis, itemID)
+	if err != nil {
+		return true, err
+	}
+
+	kkm, err := s.getKKM(ctx, remedial, kelasID, mapelID)
+	if err != nil {
+		return true, err
+	}
+
+	for _, peserta := range daftarPeserta {
+		nilai, dinilai := nilaiItem[peserta.SiswaID]
+		if !dinilai {
+			continue
+		}
+		if peserta.Diterapkan != nil && peserta.NilaiRemedial != nil && *peserta.NilaiRemedial == nilai {
+			continue
+		}
+		nilaiAkhir := HitungNilaiRemedial(remedial.Kebijakan, peserta.NilaiAwal, nilai, kkm)
+		if err := s.remedialRepo.TerapkanNilai(ctx, remedial, peserta.SiswaID, peserta.NilaiAwal, nilai, nilaiAkhir); err != nil {
+			return true, err
+		}
+	}
+	return true, nil
+}
+
+// terapkanUlangAsal menerapkan ulang kebijakan remedial setelah tugas/quiz asal dinilai ulang. Hanya
+// peserta yang hasil remedialnya sudah diterapkan dan nilai asalnya kini berbeda dari nilai akhir
+// remedial yang diproses; nilai asal yang baru menjadi nilai awal peserta.
+func (s *RemedialService) terapkanUlangAsal(ctx context.Context, jenis string, asalID int, kelasID int, mapelID int) error {
+	daftarRemedial, err := s.remedialRepo.GetAllByAsalID(ctx, jenis, asalID)
+	if err != nil || len(daftarRemedial) == 0 {
+		return err
+	}
+	nilaiAsal, err := s.remedialRepo.GetNilaiItem(ctx, jenis, asalID)
+	if err != nil {
+		return err
+	}
+
+	for i := range daftarRemedial {
+		remedial := &daftarRemedial[i]
+		daftarPeserta, err := s.remedialRepo.GetPeserta(ctx, remedial.ID)
+		if err != nil {
+			return err
+		}
+		kkm, err := s.getKKM(ctx, remedial, kelasID, mapelID)
+		if err != nil {
+			return err
+		}
+
+		for _, peserta := range daftarPeserta {
+			if peserta.Diterapkan == nil || peserta.NilaiRemedial == nil || peserta.NilaiAkhir == nil {
+				continue
+			}
+			nilai, dinilai := nilaiAsal[peserta.SiswaID]
+			if !dinilai || math.Abs(nilai-*peserta.NilaiAkhir) < toleransiNilaiAsal {
+				continue
+			}
+			nilaiAkhir := HitungNilaiRemedial(remedial.Kebijakan, &nilai, *peserta.NilaiRemedial, kkm)
+			if err := s.remedialRepo.TerapkanNilai(ctx, remedial, peserta.SiswaID, &nilai, *peserta.NilaiRemedial, nilaiAkhir); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// getKKM mengembalikan KKM mapel untuk tingkat kelas jika kebijakan remedial "batas_kkm", atau tak
+// hingga (tanpa batas) untuk kebijakan lain maupun jika KKM belum diatur.
+func (s *RemedialService) getKKM(ctx context.Context, remedial *models.Remedial, kelasID int, mapelID int) (float64, error) {
+	kkm := math.Inf(1)
+	if remedial.Kebijakan != "batas_kkm" {
+		return kkm, nil
+	}
+	kelas, err := s.kelasRepo.GetByID(ctx, kelasID)
+	if err != nil {
+		return 0, err
+	}
+	dataKKM, err := s.kkmRepo.GetByMapelAndTingkat(ctx, mapelID, kelas.Tingkat)
+	if err != nil && err != sql.ErrNoRows {
+		return 0, err
+	}
+	if dataKKM != nil {
+		kkm = dataKKM.Nilai
+	}
+	return kkm, nil
+}
+
+// HitungNilaiRemedial menentukan nilai akhir setelah remedial:
+//   - "ganti": nilai remedial menggantikan nilai awal,
+//   - "maksimum": diambil yang lebih tinggi dari nilai awal dan nilai remedial,
+//   - "batas_kkm": nilai remedial dibatasi maksimal sebesar KKM, tetapi tidak menurunkan nilai awal.
+func HitungNilaiRemedial(kebijakan string, nilaiAwal *float64, nilaiRemedial float64, kkm float64) float64 {
+	awal := 0.0
+	if nilaiAwal != nil {
+		awal = *nilaiAwal
+	}
+
+	switch kebijakan {
+	case "maksimum":
+		return math.Max(awal, nilaiRemedial)
+	case "batas_kkm":
+		return math.Max(awal, math.Min(nilaiRemedial, kkm))
+	default:
+		return nilaiRemedial
+	}
+}
@@ -0,0 +1,265 @@
+package services
+
+import (
+	"be-pui/repositories"
+	"context"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// JumlahRentangHistogram adalah jumlah rentang nilai pada histogram (0-9, 10-19, ..., 90-100).
+const JumlahRentangHistogram = 10
+
+type RentangNilai struct {
+	Rentang string `json:"rentang"`
+	Jumlah  int    `json:"jumlah"`
+}
+
+type PeringkatSiswa struct {
+	Peringkat   int     `json:"peringkat"`
+	SiswaID     int     `json:"siswa_id"`
+	NamaSiswa   string  `json:"nama_siswa"`
+	RataRata    float64 `json:"rata_rata"`
+	JumlahMapel int     `json:"jumlah_mapel"`
+	MapelTuntas int     `json:"mapel_tuntas"`
+}
+
+type StatistikMapel struct {
+	MataPelajaranID int            `json:"mata_pelajaran_id"`
+	NamaMapel       string         `json:"nama_mapel"`
+	KKM             float64        `json:"kkm"`
+	JumlahSiswa     int            `json:"jumlah_siswa"`
+	RataRata        float64        `json:"rata_rata"`
+	NilaiTertinggi  float64        `json:"nilai_tertinggi"`
+	NilaiTerendah   float64        `json:"nilai_terendah"`
+	JumlahTuntas    int            `json:"jumlah_tuntas"`
+	PersenTuntas    float64        `json:"persen_tuntas"`
+	Histogram       []RentangNilai `json:"histogram"`
+}
+
+// StatistikKelas adalah statistik nilai satu kelas. Peringkat hanya memuat siswa yang memiliki nilai
+// di semua mapel kelas (JumlahMapel); siswa lain dicantumkan di BelumLengkap tanpa peringkat agar
+// rata-rata dari sebagian mapel tidak dibandingkan dengan rata-rata seluruh mapel.
+type StatistikKelas struct {
+	KelasID      int              `json:"kelas_id"`
+	JumlahMapel  int              `json:"jumlah_mapel"`
+	RataRata     float64          `json:"rata_rata"`
+	PersenTuntas float64          `json:"persen_tuntas"`
+	Peringkat    []PeringkatSiswa `json:"peringkat"`
+	BelumLengkap []PeringkatSiswa `json:"belum_lengkap"`
+	Mapel        []StatistikMapel `json:"mapel"`
+	Histogram    []RentangNilai   `json:"histogram"`
+}
+
+// StatistikKelasService menyusun peringkat dan statistik nilai satu kelas dari rekap nilai, atau dari
+// nilai terbit dalam satu semester.
+type StatistikKelasService struct {
+	rekapRepo    repositories.RekapNilaiRepository
+	kelasRepo    repositories.KelasRepository
+	kkmRepo      repositories.KKMRepository
+	siswaRepo    repositories.SiswaRepository
+	kalenderRepo repositories.KalenderAkademikRepository
+	rekapService *RekapNilaiService
+}
+
+func NewStatistikKelasService(
+	rekapRepo repositories.RekapNilaiRepository,
+	kelasRepo repositories.KelasRepository,
+	kkmRepo repositories.KKMRepository,
+	siswaRepo repositories.SiswaRepository,
+	kalenderRepo repositories.KalenderAkademikRepository,
+	rekapService *RekapNilaiService,
+) *StatistikKelasService {
+	return &StatistikKelasService{
+		rekapRepo:    rekapRepo,
+		kelasRepo:    kelasRepo,
+		kkmRepo:      kkmRepo,
+		siswaRepo:    siswaRepo,
+		kalenderRepo: kalenderRepo,
+		rekapService: rekapService,
+	}
+}
+
+// GetStatistik menghitung statistik kelas. Semester 0 memakai rekap nilai seluruh waktu; selain itu
+// nilai dihitung ulang dari tugas/quiz terbit dalam semester tersebut dan ErrSemesterRapor
+// dikembalikan jika semesternya belum diatur di kalender akademik.
+func (s *StatistikKelasService) GetStatistik(ctx context.Context, kelasID int, semester int, tahunAjaran string) (*StatistikKelas, error) {
+	kelas, err := s.kelasRepo.GetByID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	kkm, err := getKKMTingkat(ctx, s.kkmRepo, kelas.Tingkat)
+	if err != nil {
+		return nil, err
+	}
+
+	var rekap []repositories.RekapNilaiKelas
+	if semester == 0 {
+		rekap, err = s.rekapRepo.GetAllByKelasID(ctx, kelasID)
+	} else {
+		rekap, err = s.getRekapSemester(ctx, kelasID, semester, tahunAjaran)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	statistik := HitungStatistikKelas(rekap, kkm)
+	statistik.KelasID = kelasID
+	return statistik, nil
+}
+
+// getRekapSemester menyusun nilai akhir setiap siswa kelas per mapel dari nilai terbit dalam semester,
+// dalam bentuk yang sama dengan rekap nilai kelas.
+func (s *StatistikKelasService) getRekapSemester(ctx context.Context, kelasID int, semester int, tahunAjaran string) ([]repositories.RekapNilaiKelas, error) {
+	periode, err := getPeriodeSemester(ctx, s.kalenderRepo, semester, tahunAjaran)
+	if err != nil {
+		return nil, err
+	}
+	siswas, err := s.siswaRepo.GetAllByKelasID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+
+	var rekap []repositories.RekapNilaiKelas
+	for _, siswa := range siswas {
+		nilaiMapel, err := s.rekapService.HitungSiswaPeriode(ctx, siswa.ID, kelasID, periode.TanggalMulai, periode.TanggalSelesai)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range nilaiMapel {
+			baris := repositories.RekapNilaiKelas{NamaSiswa: siswa.Nama, NamaMapel: item.NamaMapel}
+			baris.SiswaID = siswa.ID
+			baris.KelasID = kelasID
+			baris.MataPelajaranID = item.MataPelajaranID
+			baris.NilaiAkhir = item.NilaiAkhir
+			rekap = append(rekap, baris)
+		}
+	}
+	return rekap, nil
+}
+
+// HitungStatistikKelas menghitung peringkat siswa berdasarkan rata-rata NilaiAkhir semua mapel,
+// statistik per mapel, histogram nilai, dan persentase ketuntasan terhadap KKM.
+// Hanya siswa yang memiliki nilai di semua mapel kelas yang diberi peringkat; siswa dengan rata-rata
+// sama mendapat peringkat yang sama (1, 1, 3, ...).
+func HitungStatistikKelas(rekap []repositories.RekapNilaiKelas, kkm map[int]float64) *StatistikKelas {
+	statistik := &StatistikKelas{
+		Peringkat:    []PeringkatSiswa{},
+		BelumLengkap: []PeringkatSiswa{},
+		Mapel:        []StatistikMapel{},
+		Histogram:    histogramKosong(),
+	}
+	if len(rekap) == 0 {
+		return statistik
+	}
+
+	indexSiswa := make(map[int]int)
+	indexMapel := make(map[int]int)
+	totalSiswa := make(map[int]float64)
+	totalMapel := make(map[int]float64)
+	var total float64
+	var tuntas int
+
+	for _, item := range rekap {
+		batas := batasKKM(kkm, item.MataPelajaranID)
+		lulus := item.NilaiAkhir >= batas
+		rentang := rentangHistogram(item.NilaiAkhir)
+
+		i, ok := indexSiswa[item.SiswaID]
+		if !ok {
+			statistik.Peringkat = append(statistik.Peringkat, PeringkatSiswa{SiswaID: item.SiswaID, NamaSiswa: item.NamaSiswa})
+			i = len(statistik.Peringkat) - 1
+			indexSiswa[item.SiswaID] = i
+		}
+		statistik.Peringkat[i].JumlahMapel++
+		totalSiswa[item.SiswaID] += item.NilaiAkhir
+
+		j, ok := indexMapel[item.MataPelajaranID]
+		if !ok {
+			statistik.Mapel = append(statistik.Mapel, StatistikMapel{
+				MataPelajaranID: item.MataPelajaranID,
+				NamaMapel:       item.NamaMapel,
+				KKM:             batas,
+				NilaiTertinggi:  item.NilaiAkhir,
+				NilaiTerendah:   item.NilaiAkhir,
+				Histogram:       histogramKosong(),
+			})
+			j = len(statistik.Mapel) - 1
+			indexMapel[item.MataPelajaranID] = j
+		}
+		mapel := &statistik.Mapel[j]
+		mapel.JumlahSiswa++
+		mapel.NilaiTertinggi = math.Max(mapel.NilaiTertinggi, item.NilaiAkhir)
+		mapel.NilaiTerendah = math.Min(mapel.NilaiTerendah, item.NilaiAkhir)
+		mapel.Histogram[rentang].Jumlah++
+		totalMapel[item.MataPelajaranID] += item.NilaiAkhir
+
+		if lulus {
+			statistik.Peringkat[i].MapelTuntas++
+			mapel.JumlahTuntas++
+			tuntas++
+		}
+		statistik.Histogram[rentang].Jumlah++
+		total += item.NilaiAkhir
+	}
+
+	statistik.JumlahMapel = len(statistik.Mapel)
+	lengkap := statistik.Peringkat[:0]
+	for _, siswa := range statistik.Peringkat {
+		siswa.RataRata = bulatkan(totalSiswa[siswa.SiswaID] / float64(siswa.JumlahMapel))
+		if siswa.JumlahMapel < statistik.JumlahMapel {
+			statistik.BelumLengkap = append(statistik.BelumLengkap, siswa)
+			continue
+		}
+		lengkap = append(lengkap, siswa)
+	}
+	statistik.Peringkat = lengkap
+	sort.SliceStable(statistik.Peringkat, func(a, b int) bool {
+		return statistik.Peringkat[a].RataRata > statistik.Peringkat[b].RataRata
+	})
+	for i := range statistik.Peringkat {
+		if i > 0 && statistik.Peringkat[i].RataRata == statistik.Peringkat[i-1].RataRata {
+			statistik.Peringkat[i].Peringkat = statistik.Peringkat[i-1].Peringkat
+		} else {
+			statistik.Peringkat[i].Peringkat = i + 1
+		}
+	}
+
+	for i := range statistik.Mapel {
+		mapel := &statistik.Mapel[i]
+		mapel.RataRata = bulatkan(totalMapel[mapel.MataPelajaranID] / float64(mapel.JumlahSiswa))
+		mapel.PersenTuntas = bulatkan(float64(mapel.JumlahTuntas) * 100 / float64(mapel.JumlahSiswa))
+	}
+	sort.Slice(statistik.Mapel, func(a, b int) bool {
+		return statistik.Mapel[a].NamaMapel < statistik.Mapel[b].NamaMapel
+	})
+
+	statistik.RataRata = bulatkan(total / float64(len(rekap)))
+	statistik.PersenTuntas = bulatkan(float64(tuntas) * 100 / float64(len(rekap)))
+	return statistik
+}
+
+func histogramKosong() []RentangNilai {
+	histogram := make([]RentangNilai, JumlahRentangHistogram)
+	for i := range histogram {
+		batasAtas := i*10 + 9
+		if i == JumlahRentangHistogram-1 {
+			batasAtas = 100
+		}
+		histogram[i].Rentang = fmt.Sprintf("%d-%d", i*10, batasAtas)
+	}
+	return histogram
+}
+
+// rentangHistogram mengembalikan indeks rentang untuk sebuah nilai; nilai 100 masuk rentang 90-100.
+func rentangHistogram(nilai float64) int {
+	i := int(nilai / 10)
+	if i < 0 {
+		return 0
+	}
+	if i >= JumlahRentangHistogram {
+		return JumlahRentangHistogram - 1
+	}
+	return i
+}
@@ -0,0 +1,196 @@
+package services
+
+import (
+	"be-pui/models"
+	"be-pui/repositories"
+	"context"
+	"database/sql"
+)
+
+// Batas bawah tiap tingkat ketercapaian tujuan pembelajaran.
+const (
+	BatasMahir = 86.0
+	BatasCakap = 71.0
+	BatasLayak = 56.0
+)
+
+type KetercapaianTujuan struct {
+	TujuanID            int      `json:"tujuan_id"`
+	MataPelajaranID     int      `json:"mata_pelajaran_id"`
+	Kode                string   `json:"kode"`
+	CapaianPembelajaran string   `json:"capaian_pembelajaran"`
+	Deskripsi           string   `json:"deskripsi"`
+	Nilai               *float64 `json:"nilai"`
+	JumlahBukti         int      `json:"jumlah_bukti"`
+	Ketercapaian        string   `json:"ketercapaian"`
+}
+
+type KetercapaianSiswa struct {
+	SiswaID   int                  `json:"siswa_id"`
+	NamaSiswa string               `json:"nama_siswa"`
+	Tujuan    []KetercapaianTujuan `json:"tujuan"`
+}
+
+// RingkasanTujuan menghitung banyaknya siswa di kelas pada tiap tingkat ketercapaian satu tujuan.
+type RingkasanTujuan struct {
+	TujuanID     int            `json:"tujuan_id"`
+	Kode         string         `json:"kode"`
+	Deskripsi    string         `json:"deskripsi"`
+	Ketercapaian map[string]int `json:"ketercapaian"`
+}
+
+type KetercapaianKelas struct {
+	KelasID   int                 `json:"kelas_id"`
+	MapelID   int                 `json:"mata_pelajaran_id"`
+	Ringkasan []RingkasanTujuan   `json:"ringkasan"`
+	Siswa     []KetercapaianSiswa `json:"siswa"`
+}
+
+// TujuanPembelajaranService menyusun laporan ketercapaian tujuan pembelajaran per siswa.
+type TujuanPembelajaranService struct {
+	tujuanRepo repositories.TujuanPembelajaranRepository
+	siswaRepo  repositories.SiswaRepository
+	kelasRepo  repositories.KelasRepository
+}
+
+func NewTujuanPembelajaranService(
+	tujuanRepo repositories.TujuanPembelajaranRepository,
+	siswaRepo repositories.SiswaRepository,
+	kelasRepo repositories.KelasRepository,
+) *TujuanPembelajaranService {
+	return &TujuanPembelajaranService{
+		tujuanRepo: tujuanRepo,
+		siswaRepo:  siswaRepo,
+		kelasRepo:  kelasRepo,
+	}
+}
+
+// GetKetercapaianKelas menyusun ketercapaian seluruh siswa di kelas untuk tujuan pembelajaran
+// satu mapel pada tingkat kelas tersebut.
+func (s *TujuanPembelajaranService) GetKetercapaianKelas(ctx context.Context, kelasID int, mapelID int) (*KetercapaianKelas, error) {
+	kelas, err := s.kelasRepo.GetByID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	tujuan, err := s.tujuanRepo.GetAll(ctx, mapelID, kelas.Tingkat)
+	if err != nil {
+		return nil, err
+	}
+	siswaKelas, err := s.siswaRepo.GetAllByKelasID(ctx, kelasID)
+	if err != nil {
+		return nil, err
+	}
+	nilai, err := s.tujuanRepo.GetNilaiTujuan(ctx, kelasID, mapelID, 0, false)
+	if err != nil {
+		return nil, err
+	}
+
+	hasil := &KetercapaianKelas{
+		KelasID:   kelasID,
+		MapelID:   mapelID,
+		Ringkasan: []RingkasanTujuan{},
+		Siswa:     []KetercapaianSiswa{},
+	}
+	for _, item := range tujuan {
+		hasil.Ringkasan = append(hasil.Ringkasan, RingkasanTujuan{
+			TujuanID:     item.ID,
+			Kode:         item.Kode,
+			Deskripsi:    item.Deskripsi,
+			Ketercapaian: map[string]int{},
+		})
+	}
+
+	nilaiSiswa := kelompokkanNilaiTujuan(nilai)
+	for _, siswa := range siswaKelas {
+		laporan := KetercapaianSiswa{
+			SiswaID:   siswa.ID,
+			NamaSiswa: siswa.Nama,
+			Tujuan:    susunKetercapaian(tujuan, nilaiSiswa[siswa.ID]),
+		}
+		for i, item := range laporan.Tujuan {
+			hasil.Ringkasan[i].Ketercapaian[item.Ketercapaian]++
+		}
+		hasil.Siswa = append(hasil.Siswa, laporan)
+	}
+	return hasil, nil
+}
+
+// GetKetercapaianSiswa menyusun ketercapaian satu siswa untuk tujuan pembelajaran di tingkat
+// kelasnya. mapelID 0 berarti seluruh mapel. hanyaTerbit dipakai saat siswa melihat laporannya
+// sendiri agar nilai yang belum terbit tidak ikut dihitung.
+func (s *TujuanPembelajaranService) GetKetercapaianSiswa(ctx context.Context, siswaID int, mapelID int, hanyaTerbit bool) (*KetercapaianSiswa, error) {
+	siswa, err := s.siswaRepo.GetByID(ctx, siswaID)
+	if err != nil {
+		return nil, err
+	}
+	if siswa.KelasID == nil {
+		return nil, sql.ErrNoRows
+	}
+	kelas, err := s.kelasRepo.GetByID(ctx, *siswa.KelasID)
+	if err != nil {
+		return nil, err
+	}
+	tujuan, err := s.tujuanRepo.GetAll(ctx, mapelID, kelas.Tingkat)
+	if err != nil {
+		return nil, err
+	}
+	nilai, err := s.tujuanRepo.GetNilaiTujuan(ctx, kelas.ID, mapelID, siswaID, hanyaTerbit)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KetercapaianSiswa{
+		SiswaID:   siswa.ID,
+		NamaSiswa: siswa.Nama,
+		Tujuan:    susunKetercapaian(tujuan, kelompokkanNilaiTujuan(nilai)[siswa.ID]),
+	}, nil
+}
+
+func kelompokkanNilaiTujuan(nilai []repositories.NilaiTujuanSiswa) map[int]map[int]repositories.NilaiTujuanSiswa {
+	hasil := make(map[int]map[int]repositories.NilaiTujuanSiswa)
+	for _, item := range nilai {
+		if hasil[item.SiswaID] == nil {
+			hasil[item.SiswaID] = make(map[int]repositories.NilaiTujuanSiswa)
+		}
+		hasil[item.SiswaID][item.TujuanID] = item
+	}
+	return hasil
+}
+
+// susunKetercapaian mengurutkan laporan sesuai daftar tujuan. Tujuan tanpa bukti nilai tetap
+// dicantumkan dengan ketercapaian "Belum Dinilai".
+func susunKetercapaian(tujuan []models.TujuanPembelajaran, nilai map[int]repositories.NilaiTujuanSiswa) []KetercapaianTujuan {
+	hasil := []KetercapaianTujuan{}
+	for _, item := range tujuan {
+		laporan := KetercapaianTujuan{
+			TujuanID:            item.ID,
+			MataPelajaranID:     item.MataPelajaranID,
+			Kode:                item.Kode,
+			CapaianPembelajaran: item.CapaianPembelajaran,
+			Deskripsi:           item.Deskripsi,
+			Ketercapaian:        "Belum Dinilai",
+		}
+		if bukti, ok := nilai[item.ID]; ok {
+			rata := bulatkan(bukti.Nilai)
+			laporan.Nilai = &rata
+			laporan.JumlahBukti = bukti.JumlahBukti
+			laporan.Ketercapaian = TingkatKetercapaian(rata)
+		}
+		hasil = append(hasil, laporan)
+	}
+	return hasil
+}
+
+// TingkatKetercapaian mengelompokkan nilai ke tingkat ketercapaian tujuan pembelajaran.
+func TingkatKetercapaian(nilai float64) string {
+	switch {
+	case nilai >= BatasMahir:
+		return "Mahir"
+	case nilai >= BatasCakap:
+		return "Cakap"
+	case nilai >= BatasLayak:
+		return "Layak"
+	default:
+		return "Baru Berkembang"
+	}
+}